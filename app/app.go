@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/mount-joy/thelist-lambda/config"
+	"github.com/mount-joy/thelist-lambda/handlers"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/logging"
+	"github.com/mount-joy/thelist-lambda/metrics"
+	"github.com/mount-joy/thelist-lambda/tracing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// requestIDHeader is the response header carrying the API Gateway request ID, which every log line for the request has
+const requestIDHeader = "X-Request-Id"
+
+// Handler routes each request, logging its outcome and emitting its metrics
+type Handler struct {
+	router  iface.Router
+	logger  *logging.Logger
+	metrics *metrics.Emitter
+}
+
+// DoRequest handles the request with a logger, metrics recorder and the trace it's part of in the context,
+// logging a line with the outcome and emitting its metrics once it's done
+func (h *Handler) DoRequest(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	start := time.Now()
+	requestID := request.RequestContext.RequestID
+
+	route, params := h.router.Match(request)
+	logger := h.logger.With(
+		"requestId", requestID,
+		"lambdaRequestId", lambdaRequestID(ctx),
+		"method", request.RequestContext.HTTP.Method,
+		"path", request.RequestContext.HTTP.Path,
+		"route", route,
+		"listId", params.ListID,
+		"itemId", params.ItemID,
+	)
+
+	recorder := &metrics.Recorder{}
+	ctx = metrics.NewContext(logging.NewContext(ctx, logger), recorder)
+	ctx = tracing.Extract(ctx, request.Headers)
+
+	response := h.respond(ctx, request)
+	if requestID != "" {
+		if response.Headers == nil {
+			response.Headers = map[string]string{}
+		}
+		response.Headers[requestIDHeader] = requestID
+	}
+
+	latency := time.Since(start)
+	logger.Log(logging.LevelForStatus(response.StatusCode), "Request handled",
+		"status", response.StatusCode,
+		"latencyMs", latency.Milliseconds(),
+	)
+	if err := h.metrics.Emit(route, response.StatusCode, latency, recorder.DynamoDBUsage()); err != nil {
+		logger.Error("Unable to emit metrics", "error", err)
+	}
+	return response, nil
+}
+
+// lambdaRequestID returns the ID Lambda gave the invocation, which is empty outside of Lambda
+func lambdaRequestID(ctx context.Context) string {
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		return lc.AwsRequestID
+	}
+	return ""
+}
+
+// respond routes the request and serialises the result
+// API Gateway base64 encodes bodies which aren't text, they're decoded so handlers only ever see the body that was sent
+func (h *Handler) respond(ctx context.Context, request events.APIGatewayV2HTTPRequest) events.APIGatewayV2HTTPResponse {
+	if request.IsBase64Encoded {
+		body, err := base64.StdEncoding.DecodeString(request.Body)
+		if err != nil {
+			p := problem.ForStatus(http.StatusBadRequest, "The request body isn't valid base64").WithRequestID(request.RequestContext.RequestID)
+			return problemResponse(p, http.StatusBadRequest, nil)
+		}
+		request.Body = string(body)
+		request.IsBase64Encoded = false
+	}
+
+	result, statusCode := h.router.Route(ctx, request)
+
+	var responseHeaders map[string]string
+	if response, ok := result.(*iface.Response); ok {
+		result = response.Body
+		responseHeaders = response.Headers
+	}
+
+	if statusCode >= http.StatusBadRequest {
+		p := problem.FromResult(result, statusCode).WithRequestID(request.RequestContext.RequestID)
+		return problemResponse(p, statusCode, responseHeaders)
+	}
+
+	// A 204 never has a body, not even null
+	if statusCode == http.StatusNoContent {
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: statusCode,
+			Headers:    responseHeaders,
+		}
+	}
+
+	res, err := json.Marshal(result)
+	if err != nil {
+		logging.FromContext(ctx).Error("Unable to serialise the response", "error", err)
+		p := problem.ForStatus(http.StatusInternalServerError, "").WithRequestID(request.RequestContext.RequestID)
+		return problemResponse(p, http.StatusInternalServerError, nil)
+	}
+
+	return events.APIGatewayV2HTTPResponse{
+		Body:       string(res),
+		StatusCode: statusCode,
+		Headers:    responseHeaders,
+	}
+}
+
+// problemResponse serialises p as application/problem+json, keeping any headers the route set
+func problemResponse(p *problem.Problem, statusCode int, headers map[string]string) events.APIGatewayV2HTTPResponse {
+	res, _ := json.Marshal(p)
+
+	responseHeaders := map[string]string{"Content-Type": problem.ContentType}
+	for key, value := range headers {
+		responseHeaders[key] = value
+	}
+
+	return events.APIGatewayV2HTTPResponse{
+		Body:       string(res),
+		StatusCode: statusCode,
+		Headers:    responseHeaders,
+	}
+}
+
+// New returns the Handler for conf, with spans exported as conf says
+func New(conf config.Config) *Handler {
+	if err := tracing.Setup(conf.Tracing); err != nil {
+		logging.Default().Error("Unable to set up tracing, spans won't be exported", "error", err)
+	}
+
+	return &Handler{
+		router:  handlers.NewRouter(),
+		logger:  logging.Default(),
+		metrics: metrics.NewEmitter(os.Stdout, conf.Metrics),
+	}
+}
@@ -0,0 +1,383 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/mount-joy/thelist-lambda/config"
+	"github.com/mount-joy/thelist-lambda/handlers"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/logging"
+	"github.com/mount-joy/thelist-lambda/metrics"
+	"github.com/mount-joy/thelist-lambda/tracing/tracingtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFullFlow(t *testing.T) {
+	t.Run("Successful Request", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(200)
+			fmt.Fprintf(w, "127.0.0.1")
+		}))
+		defer ts.Close()
+
+		h := Handler{
+			router:  handlers.NewRouter(),
+			logger:  logging.New(ioutil.Discard),
+			metrics: metrics.NewEmitter(ioutil.Discard, config.Metrics{}),
+		}
+
+		request := events.APIGatewayV2HTTPRequest{
+			Headers: map[string]string{"Origin": "thelist.app"},
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+					Method: "GET",
+					Path:   "/hello",
+				},
+			},
+			QueryStringParameters: map[string]string{"name": "Joy"},
+		}
+
+		gotResponse, gotErr := h.DoRequest(context.Background(), request)
+
+		expected := "{\"message\":\"Hello, Joy\"}"
+		assert.NoError(t, gotErr)
+		assert.Equal(t, expected, gotResponse.Body)
+		assert.Equal(t, "thelist.app", gotResponse.Headers["Access-Control-Allow-Origin"])
+	})
+}
+
+type mockRouter struct {
+	mock.Mock
+}
+
+func (mr *mockRouter) Route(ctx context.Context, request events.APIGatewayV2HTTPRequest) (interface{}, int) {
+	args := mr.Called(request)
+	return args.Get(0), args.Int(1)
+}
+
+func (mr *mockRouter) Match(request events.APIGatewayV2HTTPRequest) (string, iface.PathParams) {
+	args := mr.Called(request)
+	return args.String(0), args.Get(1).(iface.PathParams)
+}
+
+func TestHandler(t *testing.T) {
+	type mockRoute struct {
+		body   interface{}
+		status int
+	}
+	tests := []struct {
+		name            string
+		mockRoute       *mockRoute
+		expectedBody    string
+		expectedStatus  int
+		expectedHeaders map[string]string
+		expectedErr     error
+	}{
+		{
+			name: "Router doesn't error",
+			mockRoute: &mockRoute{
+				body:   map[string]string{"message": "huge success"},
+				status: 200,
+			},
+			expectedBody:    "{\"message\":\"huge success\"}",
+			expectedStatus:  200,
+			expectedHeaders: map[string]string{"X-Request-Id": "request-1"},
+		},
+		{
+			name: "Route returns nil",
+			mockRoute: &mockRoute{
+				body:   nil,
+				status: 203,
+			},
+			expectedStatus:  203,
+			expectedBody:    "null",
+			expectedHeaders: map[string]string{"X-Request-Id": "request-1"},
+		},
+		{
+			name: "Headers set by the route are added to the response",
+			mockRoute: &mockRoute{
+				body: &iface.Response{
+					Body:    map[string]string{"message": "huge success"},
+					Headers: map[string]string{"Access-Control-Allow-Origin": "test-place", "ETag": `"3"`},
+				},
+				status: 200,
+			},
+			expectedBody:   "{\"message\":\"huge success\"}",
+			expectedStatus: 200,
+			expectedHeaders: map[string]string{
+				"X-Request-Id":                "request-1",
+				"Access-Control-Allow-Origin": "test-place",
+				"ETag":                        `"3"`,
+			},
+		},
+		{
+			name: "Headers set by the route are added when there is no body",
+			mockRoute: &mockRoute{
+				body:   &iface.Response{Body: nil, Headers: map[string]string{"ETag": `"3"`}},
+				status: 200,
+			},
+			expectedBody:    "null",
+			expectedStatus:  200,
+			expectedHeaders: map[string]string{"X-Request-Id": "request-1", "ETag": `"3"`},
+		},
+		{
+			name: "No Content responses don't have a body",
+			mockRoute: &mockRoute{
+				body: &iface.Response{
+					Headers: map[string]string{"Access-Control-Allow-Methods": "DELETE, GET, PATCH, POST"},
+				},
+				status: 204,
+			},
+			expectedBody:    "",
+			expectedStatus:  204,
+			expectedHeaders: map[string]string{"X-Request-Id": "request-1", "Access-Control-Allow-Methods": "DELETE, GET, PATCH, POST"},
+		},
+		{
+			name: "Problems are returned as problem+json with the request ID",
+			mockRoute: &mockRoute{
+				body:   problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "limit", Message: "too big"}),
+				status: 400,
+			},
+			expectedBody:    `{"type":"about:blank","title":"Bad Request","status":400,"code":"bad_request","detail":"Invalid query parameter","requestId":"request-1","fields":[{"name":"limit","message":"too big"}]}`,
+			expectedStatus:  400,
+			expectedHeaders: map[string]string{"X-Request-Id": "request-1", "Content-Type": "application/problem+json"},
+		},
+		{
+			name: "Errors without a problem get one for their status",
+			mockRoute: &mockRoute{
+				body:   nil,
+				status: 401,
+			},
+			expectedBody:    `{"type":"about:blank","title":"Unauthorized","status":401,"code":"unauthorized","requestId":"request-1"}`,
+			expectedStatus:  401,
+			expectedHeaders: map[string]string{"X-Request-Id": "request-1", "Content-Type": "application/problem+json"},
+		},
+		{
+			name: "Headers set by the route are kept on problems",
+			mockRoute: &mockRoute{
+				body: &iface.Response{
+					Body:    problem.ForStatus(405, ""),
+					Headers: map[string]string{"Allow": "GET"},
+				},
+				status: 405,
+			},
+			expectedBody:    `{"type":"about:blank","title":"Method Not Allowed","status":405,"code":"method_not_allowed","requestId":"request-1"}`,
+			expectedStatus:  405,
+			expectedHeaders: map[string]string{"X-Request-Id": "request-1", "Allow": "GET", "Content-Type": "application/problem+json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := events.APIGatewayV2HTTPRequest{
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					RequestID: "request-1",
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+						Path: "/test",
+					},
+				},
+			}
+
+			router := &mockRouter{}
+			router.Test(t)
+			defer router.AssertExpectations(t)
+			router.
+				On("Match", request).
+				Return("GET /test", iface.PathParams{}).
+				Once()
+			router.
+				On("Route", request).
+				Return(tt.mockRoute.body, tt.mockRoute.status).
+				Once()
+
+			h := Handler{
+				router:  router,
+				logger:  logging.New(ioutil.Discard),
+				metrics: metrics.NewEmitter(ioutil.Discard, config.Metrics{}),
+			}
+
+			gotRes, gotErr := h.DoRequest(context.Background(), request)
+
+			assert.Equal(t, tt.expectedErr, gotErr)
+			assert.Equal(t, tt.expectedBody, gotRes.Body)
+			assert.Equal(t, tt.expectedStatus, gotRes.StatusCode)
+			assert.Equal(t, tt.expectedHeaders, gotRes.Headers)
+		})
+	}
+}
+
+func TestHandlerDecodesBase64Body(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedRoute  bool
+		expectedStatus int
+	}{
+		{
+			name:           "Routes the decoded body",
+			body:           "eyJOYW1lIjoiU2hvcHBpbmcifQ==",
+			expectedRoute:  true,
+			expectedStatus: 201,
+		},
+		{
+			name:           "Rejects a body which isn't base64",
+			body:           "{not base64",
+			expectedStatus: 400,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := events.APIGatewayV2HTTPRequest{Body: tt.body, IsBase64Encoded: true}
+			decoded := events.APIGatewayV2HTTPRequest{Body: `{"Name":"Shopping"}`}
+
+			router := &mockRouter{}
+			router.Test(t)
+			defer router.AssertExpectations(t)
+			router.On("Match", request).Return("POST /lists", iface.PathParams{})
+			if tt.expectedRoute {
+				router.On("Route", decoded).Return(map[string]string{}, 201).Once()
+			}
+
+			h := Handler{
+				router:  router,
+				logger:  logging.New(ioutil.Discard),
+				metrics: metrics.NewEmitter(ioutil.Discard, config.Metrics{}),
+			}
+
+			gotRes, gotErr := h.DoRequest(context.Background(), request)
+
+			assert.NoError(t, gotErr)
+			assert.Equal(t, tt.expectedStatus, gotRes.StatusCode)
+		})
+	}
+}
+
+func TestHandlerLogs(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		expectedLevel string
+	}{
+		{name: "Logs a success at INFO", status: 200, expectedLevel: "INFO"},
+		{name: "Logs a client error at WARN", status: 404, expectedLevel: "WARN"},
+		{name: "Logs a server error at ERROR", status: 500, expectedLevel: "ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := events.APIGatewayV2HTTPRequest{
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					RequestID: "request-1",
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+						Method: "GET",
+						Path:   "/lists/list-1/items/item-1",
+					},
+				},
+			}
+			ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "lambda-1"})
+
+			router := &mockRouter{}
+			router.Test(t)
+			defer router.AssertExpectations(t)
+			router.
+				On("Match", request).
+				Return("GET /lists/{listId}/items/{itemId}", iface.PathParams{ListID: "list-1", ItemID: "item-1"}).
+				Once()
+			router.
+				On("Route", request).
+				Return(nil, tt.status).
+				Once()
+
+			out := &bytes.Buffer{}
+			h := Handler{
+				router:  router,
+				logger:  logging.New(out),
+				metrics: metrics.NewEmitter(ioutil.Discard, config.Metrics{}),
+			}
+
+			_, err := h.DoRequest(ctx, request)
+			assert.NoError(t, err)
+
+			var line map[string]interface{}
+			assert.NoError(t, json.Unmarshal(out.Bytes(), &line))
+			assert.Equal(t, tt.expectedLevel, line["level"])
+			assert.Equal(t, "Request handled", line["msg"])
+			assert.Equal(t, "request-1", line["requestId"])
+			assert.Equal(t, "lambda-1", line["lambdaRequestId"])
+			assert.Equal(t, "GET", line["method"])
+			assert.Equal(t, "GET /lists/{listId}/items/{itemId}", line["route"])
+			assert.Equal(t, "list-1", line["listId"])
+			assert.Equal(t, "item-1", line["itemId"])
+			assert.Equal(t, float64(tt.status), line["status"])
+			assert.Contains(t, line, "latencyMs")
+		})
+	}
+}
+
+// dynamoDBRouter responds as if the route had made a call to DynamoDB
+type dynamoDBRouter struct{}
+
+func (dynamoDBRouter) Route(ctx context.Context, request events.APIGatewayV2HTTPRequest) (interface{}, int) {
+	metrics.FromContext(ctx).RecordDynamoDBCall(20*time.Millisecond, 1.5)
+	return nil, 201
+}
+
+func (dynamoDBRouter) Match(request events.APIGatewayV2HTTPRequest) (string, iface.PathParams) {
+	return "POST /lists", iface.PathParams{}
+}
+
+func TestHandlerEmitsMetrics(t *testing.T) {
+	out := &bytes.Buffer{}
+	h := Handler{
+		router:  dynamoDBRouter{},
+		logger:  logging.New(ioutil.Discard),
+		metrics: metrics.NewEmitter(out, config.Metrics{Namespace: "TheList", Names: config.MetricNames{Requests: "Requests", DynamoDBCalls: "DynamoDBCalls", DynamoDBConsumedCapacity: "DynamoDBConsumedCapacity"}}),
+	}
+
+	_, err := h.DoRequest(context.Background(), events.APIGatewayV2HTTPRequest{})
+	assert.NoError(t, err)
+
+	var document map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &document))
+	assert.Equal(t, "POST /lists", document["Route"])
+	assert.Equal(t, "2xx", document["StatusClass"])
+	assert.Equal(t, float64(1), document["Requests"])
+	assert.Equal(t, float64(1), document["DynamoDBCalls"])
+	assert.Equal(t, 1.5, document["DynamoDBConsumedCapacity"])
+}
+
+func TestHandlerJoinsTrace(t *testing.T) {
+	recorder := tracingtest.Record(t)
+	h := Handler{
+		router:  handlers.NewRouter(),
+		logger:  logging.New(ioutil.Discard),
+		metrics: metrics.NewEmitter(ioutil.Discard, config.Metrics{}),
+	}
+
+	request := events.APIGatewayV2HTTPRequest{
+		Headers: map[string]string{"x-amzn-trace-id": "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"},
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET", Path: "/hello"},
+		},
+	}
+
+	_, err := h.DoRequest(context.Background(), request)
+
+	assert.NoError(t, err)
+	spans := recorder.Ended()
+	assert.Equal(t, []string{"RouteHandler.Handle", "router.Route"}, tracingtest.Names(spans))
+	routeSpan := spans[1]
+	assert.Equal(t, "5759e988bd862e3fe1be46a994272793", routeSpan.SpanContext().TraceID().String())
+	assert.Equal(t, "53995c3f42cd8ad8", routeSpan.Parent().SpanID().String())
+	assert.True(t, routeSpan.Parent().IsRemote())
+}
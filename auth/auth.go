@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/config"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/logging"
+)
+
+const authorizationHeader = "Authorization"
+
+// ErrorNoToken is returned when the request doesn't have a bearer token
+var ErrorNoToken = errors.New("Authorization header must be a bearer token")
+
+// Authenticator checks who made a request
+type Authenticator interface {
+	// Authenticate returns the request with the caller's verified claims in its authorizer context
+	Authenticate(request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPRequest, error)
+}
+
+// NewAuthenticator returns the Authenticator for the runtime configuration
+// Without a key set the request is trusted as it is, leaving API Gateway's JWT authorizer to check the token
+func NewAuthenticator() Authenticator {
+	conf := config.GetConfiguration().Auth
+	keys, err := loadKeySet(conf)
+	if err != nil {
+		panic(fmt.Sprintf("Unable to load JWKS: %s", err.Error()))
+	}
+	if keys == nil {
+		return &apiGatewayAuthenticator{}
+	}
+
+	return &jwtAuthenticator{
+		keys:     keys,
+		issuer:   conf.Issuer,
+		audience: conf.Audience,
+		now:      time.Now,
+	}
+}
+
+type apiGatewayAuthenticator struct{}
+
+func (a *apiGatewayAuthenticator) Authenticate(request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPRequest, error) {
+	return request, nil
+}
+
+type jwtAuthenticator struct {
+	keys     keySet
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// Authenticate verifies the bearer token, replacing whatever authorizer context the request came with
+func (a *jwtAuthenticator) Authenticate(request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPRequest, error) {
+	token, err := getBearerToken(request.Headers)
+	if err != nil {
+		return request, err
+	}
+
+	claims, err := a.keys.verify(token)
+	if err != nil {
+		return request, err
+	}
+
+	err = validateClaims(claims, a.now(), a.issuer, a.audience)
+	if err != nil {
+		return request, err
+	}
+
+	request.RequestContext.Authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+		JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{
+			Claims: flattenClaims(claims),
+			Scopes: getScopes(claims),
+		},
+	}
+	return request, nil
+}
+
+// Middleware authenticates requests before passing them on, returning 401 when the caller can't be authenticated
+func Middleware(authenticator Authenticator) iface.Middleware {
+	return func(next iface.HandlerFunc) iface.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+			authenticated, err := authenticator.Authenticate(request)
+			if err != nil {
+				logging.FromContext(ctx).Warn("Request failed", "error", err)
+				return problem.Respond(http.StatusUnauthorized, err.Error())
+			}
+			return next(ctx, authenticated, params)
+		}
+	}
+}
+
+// flattenClaims turns the claims into strings the way API Gateway's JWT authorizer does
+func flattenClaims(claims map[string]interface{}) map[string]string {
+	flat := make(map[string]string, len(claims))
+	for name, value := range claims {
+		switch v := value.(type) {
+		case string:
+			flat[name] = v
+		case json.Number:
+			flat[name] = v.String()
+		default:
+			raw, err := json.Marshal(v)
+			if err == nil {
+				flat[name] = string(raw)
+			}
+		}
+	}
+	return flat
+}
+
+func getScopes(claims map[string]interface{}) []string {
+	scope, ok := claims["scope"].(string)
+	if !ok {
+		return nil
+	}
+	return strings.Fields(scope)
+}
+
+func getBearerToken(headers map[string]string) (string, error) {
+	h := http.Header{}
+	for key, value := range headers {
+		h.Add(key, value)
+	}
+
+	value := strings.TrimSpace(h.Get(authorizationHeader))
+	parts := strings.SplitN(value, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || strings.TrimSpace(parts[1]) == "" {
+		return "", ErrorNoToken
+	}
+	return strings.TrimSpace(parts[1]), nil
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWTAuthenticatorAuthenticate(t *testing.T) {
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	claims := map[string]interface{}{"sub": "user-1", "exp": now.Add(time.Hour).Unix(), "iss": "issuer", "aud": "audience", "scope": "lists:read lists:write"}
+	token := signToken(t, algorithmRS256, "rsa", claims)
+
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		expectedClaims map[string]string
+		expectedScopes []string
+		expectedErr    error
+	}{
+		{
+			name:    "A valid token puts its claims in the authorizer context",
+			headers: map[string]string{"authorization": "Bearer " + token},
+			expectedClaims: map[string]string{
+				"sub":   "user-1",
+				"exp":   "1609506000",
+				"iss":   "issuer",
+				"aud":   "audience",
+				"scope": "lists:read lists:write",
+			},
+			expectedScopes: []string{"lists:read", "lists:write"},
+		},
+		{
+			name:        "A request without a token is rejected",
+			headers:     map[string]string{},
+			expectedErr: ErrorNoToken,
+		},
+		{
+			name:        "A request with basic auth is rejected",
+			headers:     map[string]string{"Authorization": "Basic dXNlcjpwYXNz"},
+			expectedErr: ErrorNoToken,
+		},
+		{
+			name:        "A request with an invalid token is rejected",
+			headers:     map[string]string{"Authorization": "Bearer " + token + "x"},
+			expectedErr: ErrorInvalidToken,
+		},
+	}
+
+	keys, err := parseKeySet([]byte(testJWKS()))
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := jwtAuthenticator{keys: keys, issuer: "issuer", audience: "audience", now: func() time.Time { return now }}
+
+			// Claims put there by anything else must not survive
+			request := events.APIGatewayV2HTTPRequest{
+				Headers: tt.headers,
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					Authorizer: &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+						JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{Claims: map[string]string{"sub": "forged"}},
+					},
+				},
+			}
+
+			gotRequest, gotErr := a.Authenticate(request)
+
+			if tt.expectedErr != nil {
+				assert.True(t, errors.Is(gotErr, tt.expectedErr), "expected %v, got %v", tt.expectedErr, gotErr)
+				return
+			}
+			assert.NoError(t, gotErr)
+			assert.Equal(t, tt.expectedClaims, gotRequest.RequestContext.Authorizer.JWT.Claims)
+			assert.Equal(t, tt.expectedScopes, gotRequest.RequestContext.Authorizer.JWT.Scopes)
+		})
+	}
+}
+
+func TestAPIGatewayAuthenticatorAuthenticate(t *testing.T) {
+	request := events.APIGatewayV2HTTPRequest{Body: "body"}
+
+	gotRequest, gotErr := (&apiGatewayAuthenticator{}).Authenticate(request)
+
+	assert.NoError(t, gotErr)
+	assert.Equal(t, request, gotRequest)
+}
+
+type mockAuthenticator struct {
+	err error
+}
+
+func (m *mockAuthenticator) Authenticate(request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPRequest, error) {
+	request.Body = "authenticated"
+	return request, m.err
+}
+
+func TestMiddleware(t *testing.T) {
+	next := func(ctx context.Context, request events.APIGatewayV2HTTPRequest, _ iface.PathParams) (interface{}, int) {
+		return request.Body, 200
+	}
+
+	gotRes, gotStatus := Middleware(&mockAuthenticator{})(next)(context.Background(), events.APIGatewayV2HTTPRequest{}, iface.PathParams{})
+	assert.Equal(t, "authenticated", gotRes)
+	assert.Equal(t, 200, gotStatus)
+
+	gotRes, gotStatus = Middleware(&mockAuthenticator{err: ErrorNoToken})(next)(context.Background(), events.APIGatewayV2HTTPRequest{}, iface.PathParams{})
+	assert.Equal(t, problem.ForStatus(401, ErrorNoToken.Error()), gotRes)
+	assert.Equal(t, 401, gotStatus)
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/mount-joy/thelist-lambda/config"
+)
+
+const algorithmHS256 = "HS256"
+const algorithmRS256 = "RS256"
+
+// jsonWebKey is a single key from a JSON Web Key Set, only the fields for RSA and symmetric keys are read
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+type key struct {
+	algorithm string
+	secret    []byte
+	public    *rsa.PublicKey
+}
+
+// keySet holds the keys tokens can be signed with, by key ID
+type keySet map[string]key
+
+// loadKeySet reads the keys from the JWKS in the config, falling back to the JWKS file
+// nil is returned when neither is set
+func loadKeySet(conf config.Auth) (keySet, error) {
+	raw := []byte(conf.JWKS)
+	if len(raw) == 0 && conf.JWKSFile != "" {
+		var err error
+		raw, err = ioutil.ReadFile(conf.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	return parseKeySet(raw)
+}
+
+// parseKeySet reads the HS256 and RS256 keys from a JWKS, keys of any other type are ignored
+func parseKeySet(raw []byte) (keySet, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &jwks); err != nil {
+		return nil, fmt.Errorf("Malformed JWKS: %s", err.Error())
+	}
+
+	keys := keySet{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		var k key
+		var err error
+		switch jwk.Kty {
+		case "oct":
+			k, err = symmetricKey(jwk)
+		case "RSA":
+			k, err = rsaKey(jwk)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Key %q: %s", jwk.Kid, err.Error())
+		}
+		keys[jwk.Kid] = k
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no HS256 or RS256 keys")
+	}
+	return keys, nil
+}
+
+func symmetricKey(jwk jsonWebKey) (key, error) {
+	if jwk.Alg != "" && jwk.Alg != algorithmHS256 {
+		return key{}, fmt.Errorf("unsupported algorithm %q", jwk.Alg)
+	}
+
+	secret, err := base64.RawURLEncoding.DecodeString(jwk.K)
+	if err != nil || len(secret) == 0 {
+		return key{}, errors.New("\"k\" must be a base64url encoded secret")
+	}
+	return key{algorithm: algorithmHS256, secret: secret}, nil
+}
+
+func rsaKey(jwk jsonWebKey) (key, error) {
+	if jwk.Alg != "" && jwk.Alg != algorithmRS256 {
+		return key{}, fmt.Errorf("unsupported algorithm %q", jwk.Alg)
+	}
+
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil || len(n) == 0 {
+		return key{}, errors.New("\"n\" must be a base64url encoded modulus")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return key{}, errors.New("\"e\" must be a base64url encoded exponent")
+	}
+
+	public := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}
+	return key{algorithm: algorithmRS256, public: public}, nil
+}
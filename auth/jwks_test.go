@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeySet(t *testing.T) {
+	tests := []struct {
+		name         string
+		jwks         string
+		expectedKids []string
+		wantErr      bool
+	}{
+		{
+			name:         "HS256 and RS256 keys are read",
+			jwks:         testJWKS(),
+			expectedKids: []string{"hmac", "rsa"},
+		},
+		{
+			name:         "Keys of other types are ignored",
+			jwks:         `{"keys": [{"kty": "EC", "kid": "ec"}, {"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"}]}`,
+			expectedKids: []string{"hmac"},
+		},
+		{
+			name:         "Keys for encryption are ignored",
+			jwks:         `{"keys": [{"kty": "oct", "kid": "enc", "use": "enc", "k": "c2VjcmV0"}, {"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"}]}`,
+			expectedKids: []string{"hmac"},
+		},
+		{
+			name:    "A key for another algorithm is rejected",
+			jwks:    `{"keys": [{"kty": "oct", "kid": "hmac", "alg": "HS512", "k": "c2VjcmV0"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "An RSA key without a modulus is rejected",
+			jwks:    `{"keys": [{"kty": "RSA", "kid": "rsa", "e": "AQAB"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "A key set without usable keys is rejected",
+			jwks:    `{"keys": [{"kty": "EC", "kid": "ec"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "A key set which isn't json is rejected",
+			jwks:    `keys`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRes, gotErr := parseKeySet([]byte(tt.jwks))
+
+			if tt.wantErr {
+				assert.Error(t, gotErr)
+				return
+			}
+			assert.NoError(t, gotErr)
+			kids := []string{}
+			for kid := range gotRes {
+				kids = append(kids, kid)
+			}
+			assert.ElementsMatch(t, tt.expectedKids, kids)
+		})
+	}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwks")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "jwks.json")
+	assert.NoError(t, ioutil.WriteFile(file, []byte(testJWKS()), 0600))
+
+	keys, err := loadKeySet(config.Auth{})
+	assert.NoError(t, err)
+	assert.Nil(t, keys)
+
+	keys, err = loadKeySet(config.Auth{JWKSFile: file})
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	keys, err = loadKeySet(config.Auth{JWKS: `{"keys": [{"kty": "oct", "kid": "env", "k": "c2VjcmV0"}]}`, JWKSFile: file})
+	assert.NoError(t, err)
+	assert.Contains(t, keys, "env")
+
+	_, err = loadKeySet(config.Auth{JWKSFile: filepath.Join(dir, "missing.json")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// clockSkew is how far our clock may differ from the issuer's when checking exp and nbf
+const clockSkew = 30 * time.Second
+
+// ErrorInvalidToken is returned when a token can't be trusted
+var ErrorInvalidToken = errors.New("Invalid token")
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// verify checks the signature of a compact JWS and returns its claims
+func (k keySet) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 parts, got %d", ErrorInvalidToken, len(parts))
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: malformed header: %s", ErrorInvalidToken, err.Error())
+	}
+
+	signingKey, err := k.find(h.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	// The key decides the algorithm, so a token can't switch an RSA public key to being used as an HMAC secret
+	if h.Alg != signingKey.algorithm {
+		return nil, fmt.Errorf("%w: algorithm %q doesn't match the key", ErrorInvalidToken, h.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrorInvalidToken)
+	}
+	if !signingKey.verifySignature(parts[0]+"."+parts[1], signature) {
+		return nil, fmt.Errorf("%w: bad signature", ErrorInvalidToken)
+	}
+
+	claims := map[string]interface{}{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims: %s", ErrorInvalidToken, err.Error())
+	}
+	return claims, nil
+}
+
+// find returns the key with the given ID, a token without an ID can only be used when there is a single key
+func (k keySet) find(kid string) (key, error) {
+	if kid == "" && len(k) == 1 {
+		for _, only := range k {
+			return only, nil
+		}
+	}
+
+	found, ok := k[kid]
+	if !ok {
+		return key{}, fmt.Errorf("%w: unknown key %q", ErrorInvalidToken, kid)
+	}
+	return found, nil
+}
+
+func (k key) verifySignature(signed string, signature []byte) bool {
+	switch k.algorithm {
+	case algorithmHS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write([]byte(signed))
+		return hmac.Equal(signature, mac.Sum(nil))
+	case algorithmRS256:
+		hash := sha256.Sum256([]byte(signed))
+		return rsa.VerifyPKCS1v15(k.public, crypto.SHA256, hash[:], signature) == nil
+	default:
+		return false
+	}
+}
+
+// validateClaims checks the token is in date and was issued by and for who we expect
+// exp and sub are required, iss and aud are only checked when they're configured
+func validateClaims(claims map[string]interface{}, now time.Time, issuer string, audience string) error {
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return fmt.Errorf("%w: \"exp\" is required", ErrorInvalidToken)
+	}
+	if now.After(exp.Add(clockSkew)) {
+		return fmt.Errorf("%w: expired at %s", ErrorInvalidToken, exp.Format(time.RFC3339))
+	}
+
+	if value, present := claims["nbf"]; present {
+		nbf, ok := numericDate(value)
+		if !ok {
+			return fmt.Errorf("%w: malformed \"nbf\"", ErrorInvalidToken)
+		}
+		if now.Add(clockSkew).Before(nbf) {
+			return fmt.Errorf("%w: not valid until %s", ErrorInvalidToken, nbf.Format(time.RFC3339))
+		}
+	}
+
+	if issuer != "" && claims["iss"] != issuer {
+		return fmt.Errorf("%w: issued by %v", ErrorInvalidToken, claims["iss"])
+	}
+
+	if audience != "" && !hasAudience(claims["aud"], audience) {
+		return fmt.Errorf("%w: not issued for %q", ErrorInvalidToken, audience)
+	}
+
+	if sub, ok := claims["sub"].(string); !ok || sub == "" {
+		return fmt.Errorf("%w: \"sub\" is required", ErrorInvalidToken)
+	}
+	return nil
+}
+
+// hasAudience returns true if aud, which may be a string or a list of them, includes audience
+func hasAudience(aud interface{}, audience string) bool {
+	switch value := aud.(type) {
+	case string:
+		return value == audience
+	case []interface{}:
+		for _, each := range value {
+			if each == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func numericDate(value interface{}) (time.Time, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	keys, err := parseKeySet([]byte(testJWKS()))
+	assert.NoError(t, err)
+	claims := map[string]interface{}{"sub": "user-1"}
+
+	hmacToken := signToken(t, algorithmHS256, "hmac", claims)
+	rsaToken := signToken(t, algorithmRS256, "rsa", claims)
+	parts := strings.Split(hmacToken, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{
+			name:  "An HS256 token signed with a known secret is valid",
+			token: hmacToken,
+		},
+		{
+			name:  "An RS256 token signed with a known key is valid",
+			token: rsaToken,
+		},
+		{
+			name:    "A token with a changed payload is rejected",
+			token:   parts[0] + "." + encodeSegment(t, map[string]interface{}{"sub": "user-2"}) + "." + parts[2],
+			wantErr: true,
+		},
+		{
+			name:    "A token for an unknown key is rejected",
+			token:   signToken(t, algorithmHS256, "other", claims),
+			wantErr: true,
+		},
+		{
+			name:    "A token which uses the wrong algorithm for its key is rejected",
+			token:   signToken(t, algorithmHS256, "rsa", claims),
+			wantErr: true,
+		},
+		{
+			name:    "An unsigned token is rejected",
+			token:   encodeSegment(t, map[string]string{"alg": "none", "kid": "hmac"}) + "." + parts[1] + ".",
+			wantErr: true,
+		},
+		{
+			name:    "Something which isn't a token is rejected",
+			token:   "not-a-token",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRes, gotErr := keys.verify(tt.token)
+
+			if tt.wantErr {
+				assert.True(t, errors.Is(gotErr, ErrorInvalidToken), "expected ErrorInvalidToken, got %v", gotErr)
+				return
+			}
+			assert.NoError(t, gotErr)
+			assert.Equal(t, "user-1", gotRes["sub"])
+		})
+	}
+}
+
+func TestVerifyWithoutKeyID(t *testing.T) {
+	token := signToken(t, algorithmHS256, "", map[string]interface{}{"sub": "user-1"})
+
+	single := keySet{"hmac": key{algorithm: algorithmHS256, secret: testSecret}}
+	_, err := single.verify(token)
+	assert.NoError(t, err)
+
+	several, err := parseKeySet([]byte(testJWKS()))
+	assert.NoError(t, err)
+	_, err = several.verify(token)
+	assert.True(t, errors.Is(err, ErrorInvalidToken), "expected ErrorInvalidToken, got %v", err)
+}
+
+func TestValidateClaims(t *testing.T) {
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	hourAgo := now.Add(-time.Hour).Unix()
+	inAnHour := now.Add(time.Hour).Unix()
+
+	tests := []struct {
+		name    string
+		claims  map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "A token in date from the expected issuer for the expected audience is valid",
+			claims: map[string]interface{}{"sub": "user-1", "exp": inAnHour, "nbf": hourAgo, "iss": "issuer", "aud": "audience"},
+		},
+		{
+			name:   "The audience can be one of a list",
+			claims: map[string]interface{}{"sub": "user-1", "exp": inAnHour, "iss": "issuer", "aud": []string{"other", "audience"}},
+		},
+		{
+			name:    "An expired token is rejected",
+			claims:  map[string]interface{}{"sub": "user-1", "exp": hourAgo, "iss": "issuer", "aud": "audience"},
+			wantErr: true,
+		},
+		{
+			name:    "A token without an expiry is rejected",
+			claims:  map[string]interface{}{"sub": "user-1", "iss": "issuer", "aud": "audience"},
+			wantErr: true,
+		},
+		{
+			name:    "A token which isn't valid yet is rejected",
+			claims:  map[string]interface{}{"sub": "user-1", "exp": inAnHour, "nbf": inAnHour, "iss": "issuer", "aud": "audience"},
+			wantErr: true,
+		},
+		{
+			name:    "A token from another issuer is rejected",
+			claims:  map[string]interface{}{"sub": "user-1", "exp": inAnHour, "iss": "someone", "aud": "audience"},
+			wantErr: true,
+		},
+		{
+			name:    "A token for another audience is rejected",
+			claims:  map[string]interface{}{"sub": "user-1", "exp": inAnHour, "iss": "issuer", "aud": []string{"other"}},
+			wantErr: true,
+		},
+		{
+			name:    "A token without a subject is rejected",
+			claims:  map[string]interface{}{"exp": inAnHour, "iss": "issuer", "aud": "audience"},
+			wantErr: true,
+		},
+	}
+
+	keys := keySet{"hmac": key{algorithm: algorithmHS256, secret: testSecret}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Round trip through a token so the claims are decoded exactly as they would be for real
+			claims, err := keys.verify(signToken(t, algorithmHS256, "hmac", tt.claims))
+			assert.NoError(t, err)
+
+			gotErr := validateClaims(claims, now, "issuer", "audience")
+
+			if tt.wantErr {
+				assert.True(t, errors.Is(gotErr, ErrorInvalidToken), "expected ErrorInvalidToken, got %v", gotErr)
+			} else {
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
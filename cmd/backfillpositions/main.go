@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/mount-joy/thelist-lambda/config"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/logging"
+)
+
+// main gives the items saved before items had a position one, see db.BackfillPositions
+func main() {
+	changed, err := db.BackfillPositions(context.Background(), config.GetConfiguration())
+	if err != nil {
+		logging.Default().Error("Backfilling positions failed, run it again to carry on", "error", err, "changed", changed)
+		os.Exit(1)
+	}
+	logging.Default().Info("Backfilled positions", "changed", changed)
+}
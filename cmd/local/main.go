@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/app"
+	"github.com/mount-joy/thelist-lambda/config"
+	"github.com/mount-joy/thelist-lambda/logging"
+)
+
+// doRequest is how the lambda handles a request, see app.Handler
+type doRequest func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error)
+
+// serve has each HTTP request handled by the lambda, translating it as API Gateway would
+func serve(do doRequest) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request, err := toRequest(r, time.Now())
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Unable to read the request", "error", err)
+			http.Error(w, `{"message":"Bad Request"}`, http.StatusBadRequest)
+			return
+		}
+
+		response, err := do(r.Context(), request)
+		if err != nil {
+			// API Gateway's response when a lambda fails
+			logging.FromContext(r.Context()).Error("The lambda failed", "error", err)
+			http.Error(w, `{"message":"Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		body, err := responseBody(response)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("The lambda's response body isn't valid base64", "error", err)
+			http.Error(w, `{"message":"Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		if err := writeResponse(w, response, body); err != nil {
+			logging.FromContext(r.Context()).Error("Unable to write the response", "error", err)
+		}
+	}
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:3000", "address to listen on")
+	flag.Parse()
+
+	h := app.New(config.GetConfiguration())
+	logging.Default().Info("Listening", "addr", *addr)
+	if err := http.ListenAndServe(*addr, serve(h.DoRequest)); err != nil {
+		logging.Default().Error("Server stopped", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+)
+
+// requestTimeFormat is how API Gateway formats RequestContext.Time
+const requestTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// toRequest builds the event API Gateway's HTTP API would send the lambda for r, using payload format 2.0
+func toRequest(r *http.Request, now time.Time) (events.APIGatewayV2HTTPRequest, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return events.APIGatewayV2HTTPRequest{}, err
+	}
+
+	request := events.APIGatewayV2HTTPRequest{
+		Version:               "2.0",
+		RouteKey:              "$default",
+		RawPath:               r.URL.EscapedPath(),
+		RawQueryString:        r.URL.RawQuery,
+		Cookies:               cookies(r.Header),
+		Headers:               headers(r),
+		QueryStringParameters: queryStringParameters(r),
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RouteKey:     "$default",
+			AccountID:    "local",
+			Stage:        "$default",
+			RequestID:    uuid.New().String(),
+			APIID:        "local",
+			DomainName:   r.Host,
+			DomainPrefix: strings.Split(r.Host, ".")[0],
+			Time:         now.Format(requestTimeFormat),
+			TimeEpoch:    now.UnixNano() / int64(time.Millisecond),
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Protocol:  r.Proto,
+				SourceIP:  sourceIP(r.RemoteAddr),
+				UserAgent: r.UserAgent(),
+			},
+		},
+	}
+
+	if len(body) > 0 {
+		if isText(r.Header.Get("Content-Type")) {
+			request.Body = string(body)
+		} else {
+			request.Body = base64.StdEncoding.EncodeToString(body)
+			request.IsBase64Encoded = true
+		}
+	}
+	return request, nil
+}
+
+// headers lower cases the names and joins repeated headers with commas, the cookies are sent separately
+func headers(r *http.Request) map[string]string {
+	h := map[string]string{}
+	for name, values := range r.Header {
+		if name == "Cookie" {
+			continue
+		}
+		h[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	// Go takes the Host header out of the headers and onto the request
+	if r.Host != "" {
+		h["host"] = r.Host
+	}
+	return h
+}
+
+// cookies returns each cookie as name=value, as API Gateway does
+func cookies(header http.Header) []string {
+	var c []string
+	for _, line := range header["Cookie"] {
+		for _, cookie := range strings.Split(line, ";") {
+			if cookie = strings.TrimSpace(cookie); cookie != "" {
+				c = append(c, cookie)
+			}
+		}
+	}
+	return c
+}
+
+// queryStringParameters joins repeated parameters with commas, there are none when there's no query string
+func queryStringParameters(r *http.Request) map[string]string {
+	query := r.URL.Query()
+	if len(query) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(query))
+	for name, values := range query {
+		params[name] = strings.Join(values, ",")
+	}
+	return params
+}
+
+func sourceIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// isText reports whether API Gateway would pass a body of contentType through as it is, rather than base64 encode it
+func isText(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-www-form-urlencoded":
+		return true
+	}
+	return false
+}
+
+// responseBody returns the body the lambda responded with, decoding it if it's base64
+func responseBody(response events.APIGatewayV2HTTPResponse) ([]byte, error) {
+	if response.IsBase64Encoded {
+		return base64.StdEncoding.DecodeString(response.Body)
+	}
+	return []byte(response.Body), nil
+}
+
+// writeResponse writes the lambda's response, with the body from responseBody, to w as API Gateway would
+func writeResponse(w http.ResponseWriter, response events.APIGatewayV2HTTPResponse, body []byte) error {
+	for name, value := range response.Headers {
+		w.Header().Set(name, value)
+	}
+	for name, values := range response.MultiValueHeaders {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	for _, cookie := range response.Cookies {
+		w.Header().Add("Set-Cookie", cookie)
+	}
+	if len(body) > 0 && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	w.WriteHeader(response.StatusCode)
+	_, err := w.Write(body)
+	return err
+}
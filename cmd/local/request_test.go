@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToRequest(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		method           string
+		target           string
+		body             []byte
+		headers          map[string][]string
+		expectedBody     string
+		expectedBase64   bool
+		expectedHeaders  map[string]string
+		expectedCookies  []string
+		expectedQuery    map[string]string
+		expectedRawQuery string
+		expectedPath     string
+		expectedRawPath  string
+	}{
+		{
+			name:            "Passes a JSON body through as it is",
+			method:          "POST",
+			target:          "/lists",
+			body:            []byte(`{"Name":"Shopping"}`),
+			headers:         map[string][]string{"Content-Type": {"application/json; charset=utf-8"}, "User-Agent": {"curl/7.64.1"}},
+			expectedBody:    `{"Name":"Shopping"}`,
+			expectedHeaders: map[string]string{"content-type": "application/json; charset=utf-8", "user-agent": "curl/7.64.1", "host": "localhost:3000"},
+			expectedPath:    "/lists",
+			expectedRawPath: "/lists",
+		},
+		{
+			name:            "Base64 encodes a binary body",
+			method:          "POST",
+			target:          "/lists",
+			body:            []byte{0xff, 0x00, 0x01},
+			headers:         map[string][]string{"Content-Type": {"application/octet-stream"}},
+			expectedBody:    "/wAB",
+			expectedBase64:  true,
+			expectedHeaders: map[string]string{"content-type": "application/octet-stream", "host": "localhost:3000"},
+			expectedPath:    "/lists",
+			expectedRawPath: "/lists",
+		},
+		{
+			name:             "Joins repeated query string parameters and headers with commas",
+			method:           "GET",
+			target:           "/hello?name=Joy&name=Bob&lang=en",
+			headers:          map[string][]string{"Accept": {"application/json", "text/plain"}},
+			expectedHeaders:  map[string]string{"accept": "application/json,text/plain", "host": "localhost:3000"},
+			expectedQuery:    map[string]string{"name": "Joy,Bob", "lang": "en"},
+			expectedRawQuery: "name=Joy&name=Bob&lang=en",
+			expectedPath:     "/hello",
+			expectedRawPath:  "/hello",
+		},
+		{
+			name:            "Sends cookies separately from the headers",
+			method:          "GET",
+			target:          "/lists",
+			headers:         map[string][]string{"Cookie": {"session=abc; theme=dark"}},
+			expectedHeaders: map[string]string{"host": "localhost:3000"},
+			expectedCookies: []string{"session=abc", "theme=dark"},
+			expectedPath:    "/lists",
+			expectedRawPath: "/lists",
+		},
+		{
+			name:            "Keeps the path decoded and the raw path escaped",
+			method:          "GET",
+			target:          "/lists/a%20b",
+			expectedHeaders: map[string]string{"host": "localhost:3000"},
+			expectedPath:    "/lists/a b",
+			expectedRawPath: "/lists/a%20b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "http://localhost:3000"+tt.target, bytes.NewReader(tt.body))
+			for name, values := range tt.headers {
+				r.Header[name] = values
+			}
+
+			gotReq, gotErr := toRequest(r, now)
+
+			assert.NoError(t, gotErr)
+			assert.Equal(t, "2.0", gotReq.Version)
+			assert.Equal(t, tt.expectedBody, gotReq.Body)
+			assert.Equal(t, tt.expectedBase64, gotReq.IsBase64Encoded)
+			assert.Equal(t, tt.expectedHeaders, gotReq.Headers)
+			assert.Equal(t, tt.expectedCookies, gotReq.Cookies)
+			assert.Equal(t, tt.expectedQuery, gotReq.QueryStringParameters)
+			assert.Equal(t, tt.expectedRawQuery, gotReq.RawQueryString)
+			assert.Equal(t, tt.expectedRawPath, gotReq.RawPath)
+
+			ctx := gotReq.RequestContext
+			assert.Equal(t, tt.method, ctx.HTTP.Method)
+			assert.Equal(t, tt.expectedPath, ctx.HTTP.Path)
+			assert.Equal(t, "HTTP/1.1", ctx.HTTP.Protocol)
+			assert.Equal(t, "192.0.2.1", ctx.HTTP.SourceIP)
+			assert.Equal(t, r.UserAgent(), ctx.HTTP.UserAgent)
+			assert.NotEmpty(t, ctx.RequestID)
+			assert.Equal(t, "localhost:3000", ctx.DomainName)
+			assert.Equal(t, "04/Mar/2021:05:06:07 +0000", ctx.Time)
+			assert.Equal(t, int64(1614834367000), ctx.TimeEpoch)
+		})
+	}
+}
+
+func TestIsText(t *testing.T) {
+	for contentType, expected := range map[string]bool{
+		"":                                  true,
+		"application/json":                  true,
+		"application/problem+json":          true,
+		"text/plain; charset=utf-8":         true,
+		"application/x-www-form-urlencoded": true,
+		"application/octet-stream":          false,
+		"image/png":                         false,
+		"not a type;;":                      false,
+	} {
+		assert.Equal(t, expected, isText(contentType), contentType)
+	}
+}
+
+func TestServe(t *testing.T) {
+	tests := []struct {
+		name            string
+		response        events.APIGatewayV2HTTPResponse
+		err             error
+		expectedStatus  int
+		expectedBody    string
+		expectedHeaders http.Header
+	}{
+		{
+			name: "Writes the lambda's response",
+			response: events.APIGatewayV2HTTPResponse{
+				StatusCode:        201,
+				Body:              `{"Id":"list-1"}`,
+				Headers:           map[string]string{"Etag": `"1"`},
+				MultiValueHeaders: map[string][]string{"Vary": {"Origin", "Accept"}},
+				Cookies:           []string{"session=abc; HttpOnly"},
+			},
+			expectedStatus: 201,
+			expectedBody:   `{"Id":"list-1"}`,
+			expectedHeaders: http.Header{
+				"Etag":         {`"1"`},
+				"Vary":         {"Origin", "Accept"},
+				"Set-Cookie":   {"session=abc; HttpOnly"},
+				"Content-Type": {"application/json"},
+			},
+		},
+		{
+			name:            "Decodes a base64 body",
+			response:        events.APIGatewayV2HTTPResponse{StatusCode: 200, Body: "aGVsbG8=", IsBase64Encoded: true, Headers: map[string]string{"Content-Type": "text/plain"}},
+			expectedStatus:  200,
+			expectedBody:    "hello",
+			expectedHeaders: http.Header{"Content-Type": {"text/plain"}},
+		},
+		{
+			name:            "Writes no body for a 204",
+			response:        events.APIGatewayV2HTTPResponse{StatusCode: 204},
+			expectedStatus:  204,
+			expectedHeaders: http.Header{},
+		},
+		{
+			name:           "Responds as API Gateway does when the lambda fails",
+			err:            errors.New("boom"),
+			expectedStatus: 500,
+			expectedBody:   "{\"message\":\"Internal Server Error\"}\n",
+		},
+		{
+			name:           "Fails when the body isn't valid base64",
+			response:       events.APIGatewayV2HTTPResponse{StatusCode: 200, Body: "{", IsBase64Encoded: true},
+			expectedStatus: 500,
+			expectedBody:   "{\"message\":\"Internal Server Error\"}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRequest events.APIGatewayV2HTTPRequest
+			do := func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+				gotRequest = request
+				return tt.response, tt.err
+			}
+			w := httptest.NewRecorder()
+
+			serve(do)(w, httptest.NewRequest("POST", "/lists?x=1", strings.NewReader(`{"Name":"Shopping"}`)))
+
+			body, _ := ioutil.ReadAll(w.Result().Body)
+			assert.Equal(t, "/lists", gotRequest.RequestContext.HTTP.Path)
+			assert.Equal(t, `{"Name":"Shopping"}`, gotRequest.Body)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedBody, string(body))
+			if tt.expectedHeaders != nil {
+				assert.Equal(t, tt.expectedHeaders, w.Header())
+			}
+		})
+	}
+}
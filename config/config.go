@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 )
 
 type conf struct {
@@ -21,13 +22,15 @@ func (c *conf) getConf() Config {
 		return c.getProdConfig()
 	case envNameDev:
 		return c.getDevConfig()
+	case envNameMemory:
+		return c.getMemoryConfig()
 	default:
 		panic(fmt.Sprintf("Unknown runtime enviroment: %s", env))
 	}
 }
 
 func (c *conf) getRuntimeEnvironment() string {
-	environments := map[string]bool{envNameDev: true, envNameProd: true}
+	environments := map[string]bool{envNameDev: true, envNameProd: true, envNameMemory: true}
 	environment := c.getEnv(envVarEnvironment)
 	if _, ok := environments[environment]; ok {
 		return environment
@@ -36,6 +39,56 @@ func (c *conf) getRuntimeEnvironment() string {
 	return envNameDev
 }
 
+// getAuthConfig is shared by every environment, the keys are never hardcoded
+func (c *conf) getAuthConfig() Auth {
+	return Auth{
+		JWKS:     c.getEnv(envVarJWKS),
+		JWKSFile: c.getEnv(envVarJWKSFile),
+		Issuer:   c.getEnv(envVarJWTIssuer),
+		Audience: c.getEnv(envVarJWTAudience),
+	}
+}
+
+// getMetricsConfig is shared by every environment, only the namespace can be overridden
+func (c *conf) getMetricsConfig() Metrics {
+	namespace := c.getEnv(envVarMetricsNamespace)
+	if namespace == "" {
+		namespace = defaultMetricsNamespace
+	}
+	return Metrics{
+		Namespace: namespace,
+		Names: MetricNames{
+			Requests:                 "Requests",
+			Latency:                  "Latency",
+			DynamoDBCalls:            "DynamoDBCalls",
+			DynamoDBLatency:          "DynamoDBLatency",
+			DynamoDBConsumedCapacity: "DynamoDBConsumedCapacity",
+		},
+	}
+}
+
+// getTracingConfig uses the exporter from the environment, or defaultExporter when it isn't set
+func (c *conf) getTracingConfig(defaultExporter TracingExporter) Tracing {
+	exporter := TracingExporter(c.getEnv(envVarTracingExporter))
+	if exporter == "" {
+		exporter = defaultExporter
+	}
+	return Tracing{Exporter: exporter}
+}
+
+// getTombstoneRetention is shared by every environment, it is a duration such as 720h
+func (c *conf) getTombstoneRetention() time.Duration {
+	value := c.getEnv(envVarTombstoneRetention)
+	if value == "" {
+		return defaultTombstoneRetention
+	}
+	retention, err := time.ParseDuration(value)
+	if err != nil || retention <= 0 {
+		panic(fmt.Sprintf("Invalid %s: %q", envVarTombstoneRetention, value))
+	}
+	return retention
+}
+
 var loadedConfig Config = newConfig().getConf()
 
 // GetConfiguration returns the cofiguration values required at runtime
@@ -3,11 +3,22 @@ package config
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestGetConf(t *testing.T) {
+	expectedMetrics := Metrics{
+		Namespace: "env_METRICS_NAMESPACE",
+		Names: MetricNames{
+			Requests:                 "Requests",
+			Latency:                  "Latency",
+			DynamoDBCalls:            "DynamoDBCalls",
+			DynamoDBLatency:          "DynamoDBLatency",
+			DynamoDBConsumedCapacity: "DynamoDBConsumedCapacity",
+		},
+	}
 	tests := []struct {
 		name        string
 		runtimeEnv  string
@@ -17,33 +28,92 @@ func TestGetConf(t *testing.T) {
 			name:       "When environment is dev then hardcoded values are used",
 			runtimeEnv: "DEV",
 			expectedRes: Config{
+				Auth: Auth{
+					JWKS:     "env_JWT_JWKS",
+					JWKSFile: "env_JWT_JWKS_FILE",
+					Issuer:   "env_JWT_ISSUER",
+					Audience: "env_JWT_AUDIENCE",
+				},
+				Database: DatabaseDynamoDB,
 				Endpoint: "http://localhost:8000",
+				Metrics:  expectedMetrics,
 				TableNames: TableNames{
-					Items: "items",
-					Lists: "lists",
+					Activity:    "activity",
+					Idempotency: "idempotency",
+					Items:       "items",
+					Lists:       "lists",
 				},
+				TombstoneRetention: 48 * time.Hour,
+				Tracing:            Tracing{Exporter: "env_TRACING_EXPORTER"},
 			},
 		},
 		{
 			name:       "When environment is prod then environment variables are used",
 			runtimeEnv: "PROD",
 			expectedRes: Config{
+				Auth: Auth{
+					JWKS:     "env_JWT_JWKS",
+					JWKSFile: "env_JWT_JWKS_FILE",
+					Issuer:   "env_JWT_ISSUER",
+					Audience: "env_JWT_AUDIENCE",
+				},
+				Database: DatabaseDynamoDB,
 				Endpoint: "",
+				Metrics:  expectedMetrics,
 				TableNames: TableNames{
-					Items: "env_TABLE_NAME_ITEMS",
-					Lists: "env_TABLE_NAME_LISTS",
+					Activity:    "env_TABLE_NAME_ACTIVITY",
+					Idempotency: "env_TABLE_NAME_IDEMPOTENCY",
+					Items:       "env_TABLE_NAME_ITEMS",
+					Lists:       "env_TABLE_NAME_LISTS",
 				},
+				TombstoneRetention: 48 * time.Hour,
+				Tracing:            Tracing{Exporter: "env_TRACING_EXPORTER"},
+			},
+		},
+		{
+			name:       "When environment is memory then the in memory database is used",
+			runtimeEnv: "MEMORY",
+			expectedRes: Config{
+				Auth: Auth{
+					JWKS:     "env_JWT_JWKS",
+					JWKSFile: "env_JWT_JWKS_FILE",
+					Issuer:   "env_JWT_ISSUER",
+					Audience: "env_JWT_AUDIENCE",
+				},
+				Database: DatabaseMemory,
+				Endpoint: "",
+				Metrics:  expectedMetrics,
+				TableNames: TableNames{
+					Activity:    "activity",
+					Idempotency: "idempotency",
+					Items:       "items",
+					Lists:       "lists",
+				},
+				TombstoneRetention: 48 * time.Hour,
+				Tracing:            Tracing{Exporter: "env_TRACING_EXPORTER"},
 			},
 		},
 		{
 			name:       "When environment is nonsense then fallsback to dev values",
 			runtimeEnv: "nonsense",
 			expectedRes: Config{
+				Auth: Auth{
+					JWKS:     "env_JWT_JWKS",
+					JWKSFile: "env_JWT_JWKS_FILE",
+					Issuer:   "env_JWT_ISSUER",
+					Audience: "env_JWT_AUDIENCE",
+				},
+				Database: DatabaseDynamoDB,
 				Endpoint: "http://localhost:8000",
+				Metrics:  expectedMetrics,
 				TableNames: TableNames{
-					Items: "items",
-					Lists: "lists",
+					Activity:    "activity",
+					Idempotency: "idempotency",
+					Items:       "items",
+					Lists:       "lists",
 				},
+				TombstoneRetention: 48 * time.Hour,
+				Tracing:            Tracing{Exporter: "env_TRACING_EXPORTER"},
 			},
 		},
 	}
@@ -55,6 +125,9 @@ func TestGetConf(t *testing.T) {
 					if key == "ENV" {
 						return tt.runtimeEnv
 					}
+					if key == "TOMBSTONE_RETENTION" {
+						return "48h"
+					}
 					return fmt.Sprintf("env_%s", key)
 				},
 			}
@@ -66,10 +139,52 @@ func TestGetConf(t *testing.T) {
 	}
 }
 
+func TestGetMetricsConfig(t *testing.T) {
+	c := &conf{getEnv: func(string) string { return "" }}
+
+	assert.Equal(t, "TheList", c.getMetricsConfig().Namespace)
+}
+
+func TestGetTracingConfig(t *testing.T) {
+	c := &conf{getEnv: func(string) string { return "" }}
+
+	assert.Equal(t, Tracing{Exporter: TracingExporterStdout}, c.getDevConfig().Tracing)
+	assert.Equal(t, Tracing{Exporter: TracingExporterStdout}, c.getMemoryConfig().Tracing)
+	assert.Equal(t, Tracing{Exporter: TracingExporterNone}, c.getProdConfig().Tracing)
+}
+
+func TestGetTombstoneRetention(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expectedRes time.Duration
+		wantPanic   bool
+	}{
+		{name: "Defaults to 30 days", value: "", expectedRes: 720 * time.Hour},
+		{name: "Uses the duration from the environment", value: "36h", expectedRes: 36 * time.Hour},
+		{name: "Panics when the duration is invalid", value: "a week", wantPanic: true},
+		{name: "Panics when the duration isn't positive", value: "-1h", wantPanic: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &conf{getEnv: func(string) string { return tt.value }}
+
+			if tt.wantPanic {
+				assert.Panics(t, func() { c.getTombstoneRetention() })
+				return
+			}
+			assert.Equal(t, tt.expectedRes, c.getTombstoneRetention())
+		})
+	}
+}
+
 func TestGetConfiguration(t *testing.T) {
 	conf := GetConfiguration()
 
 	assert.Greater(t, len(conf.Endpoint), 0)
+	assert.Greater(t, len(conf.TableNames.Activity), 0)
+	assert.Greater(t, len(conf.TableNames.Idempotency), 0)
 	assert.Greater(t, len(conf.TableNames.Items), 0)
 	assert.Greater(t, len(conf.TableNames.Lists), 0)
 }
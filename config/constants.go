@@ -1,8 +1,23 @@
 package config
 
+import "time"
+
 const envVarEnvironment string = "ENV"
 const envVarTableNameLists string = "TABLE_NAME_LISTS"
 const envVarTableNameItems string = "TABLE_NAME_ITEMS"
+const envVarTableNameActivity string = "TABLE_NAME_ACTIVITY"
+const envVarTableNameIdempotency string = "TABLE_NAME_IDEMPOTENCY"
+const envVarJWKS string = "JWT_JWKS"
+const envVarJWKSFile string = "JWT_JWKS_FILE"
+const envVarJWTIssuer string = "JWT_ISSUER"
+const envVarJWTAudience string = "JWT_AUDIENCE"
+const envVarMetricsNamespace string = "METRICS_NAMESPACE"
+const envVarTracingExporter string = "TRACING_EXPORTER"
+const envVarTombstoneRetention string = "TOMBSTONE_RETENTION"
+
+const defaultMetricsNamespace string = "TheList"
+const defaultTombstoneRetention = 30 * 24 * time.Hour
 
 const envNameDev string = "DEV"
 const envNameProd string = "PROD"
+const envNameMemory string = "MEMORY"
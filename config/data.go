@@ -1,13 +1,70 @@
 package config
 
+import "time"
+
+// Database names the implementation of db.DB to use
+type Database string
+
+// DatabaseDynamoDB stores data in dynamodb
+const DatabaseDynamoDB Database = "dynamodb"
+
+// DatabaseMemory stores data in memory, it is lost when the process exits
+const DatabaseMemory Database = "memory"
+
 // TableNames contains the dynamodb table names
 type TableNames struct {
-	Items string
-	Lists string
+	Activity    string
+	Idempotency string
+	Items       string
+	Lists       string
+}
+
+// Auth contains the settings used to verify the JWTs callers authenticate with
+// When neither JWKS nor JWKSFile is set tokens aren't checked, and the claims from API Gateway's authorizer are trusted
+type Auth struct {
+	JWKS     string
+	JWKSFile string
+	Issuer   string
+	Audience string
+}
+
+// Metrics contains the CloudWatch namespace and metric names the per request metrics are emitted with
+type Metrics struct {
+	Namespace string
+	Names     MetricNames
+}
+
+// MetricNames contains the name of each metric emitted for a request
+type MetricNames struct {
+	Requests                 string
+	Latency                  string
+	DynamoDBCalls            string
+	DynamoDBLatency          string
+	DynamoDBConsumedCapacity string
+}
+
+// TracingExporter names where spans are exported to
+type TracingExporter string
+
+// TracingExporterNone drops every span
+const TracingExporterNone TracingExporter = "none"
+
+// TracingExporterStdout writes each span to stdout as JSON, for local runs
+const TracingExporterStdout TracingExporter = "stdout"
+
+// Tracing contains the settings for the spans recorded for each request
+type Tracing struct {
+	Exporter TracingExporter
 }
 
 // Config contains the cofiguration values required at runtime
+// TombstoneRetention is how long a deleted item can be restored for, before its tombstone is purged
 type Config struct {
-	Endpoint   string
-	TableNames TableNames
+	Auth               Auth
+	Database           Database
+	Endpoint           string
+	Metrics            Metrics
+	TableNames         TableNames
+	TombstoneRetention time.Duration
+	Tracing            Tracing
 }
@@ -2,7 +2,12 @@ package config
 
 func (c *conf) getDevConfig() Config {
 	return Config{
-		Endpoint:   "http://localhost:8000",
-		TableNames: TableNames{Items: "items", Lists: "lists"},
+		Auth:               c.getAuthConfig(),
+		Database:           DatabaseDynamoDB,
+		Endpoint:           "http://localhost:8000",
+		Metrics:            c.getMetricsConfig(),
+		TableNames:         TableNames{Activity: "activity", Idempotency: "idempotency", Items: "items", Lists: "lists"},
+		TombstoneRetention: c.getTombstoneRetention(),
+		Tracing:            c.getTracingConfig(TracingExporterStdout),
 	}
 }
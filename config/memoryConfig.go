@@ -0,0 +1,13 @@
+package config
+
+func (c *conf) getMemoryConfig() Config {
+	return Config{
+		Auth:               c.getAuthConfig(),
+		Database:           DatabaseMemory,
+		Endpoint:           "",
+		Metrics:            c.getMetricsConfig(),
+		TableNames:         TableNames{Activity: "activity", Idempotency: "idempotency", Items: "items", Lists: "lists"},
+		TombstoneRetention: c.getTombstoneRetention(),
+		Tracing:            c.getTracingConfig(TracingExporterStdout),
+	}
+}
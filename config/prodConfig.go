@@ -2,10 +2,17 @@ package config
 
 func (c *conf) getProdConfig() Config {
 	return Config{
+		Auth:     c.getAuthConfig(),
+		Database: DatabaseDynamoDB,
 		Endpoint: "",
+		Metrics:  c.getMetricsConfig(),
 		TableNames: TableNames{
-			Items: c.getEnv(envVarTableNameItems),
-			Lists: c.getEnv(envVarTableNameLists),
+			Activity:    c.getEnv(envVarTableNameActivity),
+			Idempotency: c.getEnv(envVarTableNameIdempotency),
+			Items:       c.getEnv(envVarTableNameItems),
+			Lists:       c.getEnv(envVarTableNameLists),
 		},
+		TombstoneRetention: c.getTombstoneRetention(),
+		Tracing:            c.getTracingConfig(TracingExporterNone),
 	}
 }
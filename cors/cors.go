@@ -1,18 +1,22 @@
 package cors
 
 import (
-	"log"
+	"context"
+	"encoding/json"
 	"net/http"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/logging"
 )
 
 const accessControlMaxAge = "600" //10 minutes
 
 type OriginChecker interface {
-	Options(events.APIGatewayV2HTTPRequest) events.APIGatewayV2HTTPResponse
-	GetCorsHeaders(request events.APIGatewayV2HTTPRequest) map[string]string
+	Options(context.Context, events.APIGatewayV2HTTPRequest) events.APIGatewayV2HTTPResponse
+	GetCorsHeaders(context.Context, events.APIGatewayV2HTTPRequest) map[string]string
 }
 
 // Domains contains the allowed domains and the accepted methods for each one
@@ -23,20 +27,52 @@ type Domains struct {
 // NewOriginChecker returns the default Domains
 func NewOriginChecker() OriginChecker {
 	acceptedDomains := map[string]map[string]bool{
-		"thelist.app":    {http.MethodDelete: true, http.MethodGet: true, http.MethodPatch: true, http.MethodPost: true},
+		"thelist.app":     {http.MethodDelete: true, http.MethodGet: true, http.MethodPatch: true, http.MethodPost: true},
 		"dev.thelist.app": {http.MethodDelete: true, http.MethodGet: true, http.MethodPatch: true, http.MethodPost: true},
-		"localhost:3000": {http.MethodDelete: true, http.MethodGet: true, http.MethodPatch: true, http.MethodPost: true},
+		"localhost:3000":  {http.MethodDelete: true, http.MethodGet: true, http.MethodPatch: true, http.MethodPost: true},
 	}
 	return &Domains{Allowed: acceptedDomains}
 }
 
+// Middleware answers preflight requests itself and adds the CORS headers to the response of every other request
+func Middleware(checker OriginChecker) iface.Middleware {
+	return func(next iface.HandlerFunc) iface.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+			if IsOptionsRequest(request) {
+				response := checker.Options(ctx, request)
+				if response.StatusCode >= http.StatusBadRequest {
+					return &iface.Response{Body: optionsProblem(response), Headers: response.Headers}, response.StatusCode
+				}
+				var body interface{}
+				if response.Body != "" {
+					body = json.RawMessage(response.Body)
+				}
+				return &iface.Response{Body: body, Headers: response.Headers}, response.StatusCode
+			}
+
+			headers := checker.GetCorsHeaders(ctx, request)
+			result, statusCode := next(ctx, request, params)
+			return iface.WithHeaders(result, headers), statusCode
+		}
+	}
+}
+
+// optionsProblem turns an error from Options, which has a body of {"error": "..."}, into a problem like every other error
+func optionsProblem(response events.APIGatewayV2HTTPResponse) *problem.Problem {
+	var body struct {
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal([]byte(response.Body), &body)
+	return problem.ForStatus(response.StatusCode, body.Error)
+}
+
 func IsOptionsRequest(request events.APIGatewayV2HTTPRequest) bool {
 	return http.MethodOptions == request.RequestContext.HTTP.Method
 }
 
 // Options performs an options request
 // response details methods which are permitted to be performed from the domain in the Origin header
-func (d *Domains) Options(request events.APIGatewayV2HTTPRequest) events.APIGatewayV2HTTPResponse {
+func (d *Domains) Options(ctx context.Context, request events.APIGatewayV2HTTPRequest) events.APIGatewayV2HTTPResponse {
 	origin, ok := caseIncensitiveLookup(originHeader, request.Headers)
 	if !ok {
 		return events.APIGatewayV2HTTPResponse{
@@ -45,7 +81,7 @@ func (d *Domains) Options(request events.APIGatewayV2HTTPRequest) events.APIGate
 		}
 	}
 
-	allowedMethds := d.getAllowedMethodsForOrigin(origin)
+	allowedMethds := d.getAllowedMethodsForOrigin(ctx, origin)
 	if len(allowedMethds) == 0 {
 		return events.APIGatewayV2HTTPResponse{
 			StatusCode: http.StatusNoContent,
@@ -61,13 +97,13 @@ func (d *Domains) Options(request events.APIGatewayV2HTTPRequest) events.APIGate
 	}
 }
 
-func (d *Domains) GetCorsHeaders(request events.APIGatewayV2HTTPRequest) map[string]string {
+func (d *Domains) GetCorsHeaders(ctx context.Context, request events.APIGatewayV2HTTPRequest) map[string]string {
 	origin, ok := caseIncensitiveLookup(originHeader, request.Headers)
 	if !ok {
 		return nil
 	}
 
-	if !d.isOriginAllowedToPerformMethod(origin, request.RequestContext.HTTP.Method) {
+	if !d.isOriginAllowedToPerformMethod(ctx, origin, request.RequestContext.HTTP.Method) {
 		return nil
 	}
 
@@ -76,38 +112,39 @@ func (d *Domains) GetCorsHeaders(request events.APIGatewayV2HTTPRequest) map[str
 
 func headersForOrigin(origin string) map[string]string {
 	return map[string]string{
-		allowOriginHeader:  origin,
-		maxAgeHeader:       accessControlMaxAge,
-		allowHeadersHeader: "content-type",
+		allowOriginHeader:   origin,
+		maxAgeHeader:        accessControlMaxAge,
+		allowHeadersHeader:  "authorization, content-type, idempotency-key, if-match",
+		exposeHeadersHeader: "etag, idempotent-replayed, x-request-id",
 	}
 }
 
-func (d *Domains) isOriginAllowedToPerformMethod(origin string, method string) bool {
-	allowedMethods := d.getAllowedMethodsForOrigin(origin)
+func (d *Domains) isOriginAllowedToPerformMethod(ctx context.Context, origin string, method string) bool {
+	allowedMethods := d.getAllowedMethodsForOrigin(ctx, origin)
 	if len(allowedMethods) == 0 {
 		return false
 	}
 
 	allowed, ok := allowedMethods[method]
 	if !ok {
-		log.Printf("domain %q tried use %q method", origin, method)
+		logging.FromContext(ctx).Warn("Origin isn't allowed to use the method", "origin", origin, "method", method)
 		return false
 	}
 
 	return allowed
 }
 
-func (d *Domains) getAllowedMethodsForOrigin(origin string) map[string]bool {
+func (d *Domains) getAllowedMethodsForOrigin(ctx context.Context, origin string) map[string]bool {
 	trimmedOrigin := removePrefixAndSuffix(origin)
 
 	methods, ok := d.Allowed[trimmedOrigin]
 	if !ok {
-		log.Printf("%q tried to make request", trimmedOrigin)
+		logging.FromContext(ctx).Warn("Origin isn't allowed", "origin", trimmedOrigin)
 		return nil
 	}
 
 	if len(methods) == 0 {
-		log.Printf("No allowed methods for %q", trimmedOrigin)
+		logging.FromContext(ctx).Warn("Origin has no allowed methods", "origin", trimmedOrigin)
 		return nil
 	}
 
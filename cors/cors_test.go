@@ -1,6 +1,7 @@
 package cors
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -128,7 +129,7 @@ func TestDomains_getAllowedMethodsForOrigin(t *testing.T) {
 				Allowed: tt.allowedDomains,
 			}
 
-			got := d.getAllowedMethodsForOrigin(tt.origin)
+			got := d.getAllowedMethodsForOrigin(context.Background(), tt.origin)
 
 			assert.Equal(t, tt.want, got)
 		})
@@ -195,7 +196,7 @@ func TestDomains_IsOriginAllowedToPerformMethod(t *testing.T) {
 				Allowed: tt.allowedDomains,
 			}
 
-			got := d.isOriginAllowedToPerformMethod(tt.origin, tt.method)
+			got := d.isOriginAllowedToPerformMethod(context.Background(), tt.origin, tt.method)
 
 			assert.Equal(t, tt.want, got)
 		})
@@ -230,10 +231,11 @@ func TestDomains_Options(t *testing.T) {
 			want: events.APIGatewayV2HTTPResponse{
 				StatusCode: 204,
 				Headers: map[string]string{
-					"Access-Control-Allow-Origin":  "hello",
-					"Access-Control-Allow-Methods": "GET",
-					"Access-Control-Max-Age":       "600",
-					"Access-Control-Allow-Headers": "content-type",
+					"Access-Control-Allow-Origin":   "hello",
+					"Access-Control-Allow-Methods":  "GET",
+					"Access-Control-Max-Age":        "600",
+					"Access-Control-Allow-Headers":  "authorization, content-type, idempotency-key, if-match",
+					"Access-Control-Expose-Headers": "etag, idempotent-replayed, x-request-id",
 				},
 			},
 		},
@@ -246,10 +248,11 @@ func TestDomains_Options(t *testing.T) {
 			want: events.APIGatewayV2HTTPResponse{
 				StatusCode: 204,
 				Headers: map[string]string{
-					"Access-Control-Allow-Origin":  "hello",
-					"Access-Control-Allow-Methods": "GET",
-					"Access-Control-Max-Age":       "600",
-					"Access-Control-Allow-Headers": "content-type",
+					"Access-Control-Allow-Origin":   "hello",
+					"Access-Control-Allow-Methods":  "GET",
+					"Access-Control-Max-Age":        "600",
+					"Access-Control-Allow-Headers":  "authorization, content-type, idempotency-key, if-match",
+					"Access-Control-Expose-Headers": "etag, idempotent-replayed, x-request-id",
 				},
 			},
 		},
@@ -262,10 +265,11 @@ func TestDomains_Options(t *testing.T) {
 			want: events.APIGatewayV2HTTPResponse{
 				StatusCode: 204,
 				Headers: map[string]string{
-					"Access-Control-Allow-Origin":  "hello",
-					"Access-Control-Allow-Methods": "GET",
-					"Access-Control-Max-Age":       "600",
-					"Access-Control-Allow-Headers": "content-type",
+					"Access-Control-Allow-Origin":   "hello",
+					"Access-Control-Allow-Methods":  "GET",
+					"Access-Control-Max-Age":        "600",
+					"Access-Control-Allow-Headers":  "authorization, content-type, idempotency-key, if-match",
+					"Access-Control-Expose-Headers": "etag, idempotent-replayed, x-request-id",
 				},
 			},
 		},
@@ -288,10 +292,11 @@ func TestDomains_Options(t *testing.T) {
 			want: events.APIGatewayV2HTTPResponse{
 				StatusCode: 204,
 				Headers: map[string]string{
-					"Access-Control-Allow-Origin":  "https://hello",
-					"Access-Control-Allow-Methods": "GET",
-					"Access-Control-Max-Age":       "600",
-					"Access-Control-Allow-Headers": "content-type",
+					"Access-Control-Allow-Origin":   "https://hello",
+					"Access-Control-Allow-Methods":  "GET",
+					"Access-Control-Max-Age":        "600",
+					"Access-Control-Allow-Headers":  "authorization, content-type, idempotency-key, if-match",
+					"Access-Control-Expose-Headers": "etag, idempotent-replayed, x-request-id",
 				},
 			},
 		},
@@ -305,7 +310,7 @@ func TestDomains_Options(t *testing.T) {
 			request := testhelpers.CreateAPIGatewayV2HTTPRequest("does-not-matter", tt.method, "")
 			request.Headers = tt.headers
 
-			got := d.Options(request)
+			got := d.Options(context.Background(), request)
 
 			assert.Equal(t, tt.want, got)
 		})
@@ -332,9 +337,10 @@ func TestDomains_GetCorsHeaders(t *testing.T) {
 			origin: "our-origin",
 			method: "GET",
 			expectedHeaders: map[string]string{
-				"Access-Control-Allow-Origin":  "our-origin",
-				"Access-Control-Max-Age":       "600",
-				"Access-Control-Allow-Headers": "content-type",
+				"Access-Control-Allow-Origin":   "our-origin",
+				"Access-Control-Max-Age":        "600",
+				"Access-Control-Allow-Headers":  "authorization, content-type, idempotency-key, if-match",
+				"Access-Control-Expose-Headers": "etag, idempotent-replayed, x-request-id",
 			},
 		},
 		{
@@ -345,9 +351,10 @@ func TestDomains_GetCorsHeaders(t *testing.T) {
 			origin: "https://our-origin",
 			method: "GET",
 			expectedHeaders: map[string]string{
-				"Access-Control-Allow-Origin":  "https://our-origin",
-				"Access-Control-Max-Age":       "600",
-				"Access-Control-Allow-Headers": "content-type",
+				"Access-Control-Allow-Origin":   "https://our-origin",
+				"Access-Control-Max-Age":        "600",
+				"Access-Control-Allow-Headers":  "authorization, content-type, idempotency-key, if-match",
+				"Access-Control-Expose-Headers": "etag, idempotent-replayed, x-request-id",
 			},
 		},
 		{
@@ -372,7 +379,7 @@ func TestDomains_GetCorsHeaders(t *testing.T) {
 				request.Headers["Origin"] = tt.origin
 			}
 
-			gotHeaders := d.GetCorsHeaders(request)
+			gotHeaders := d.GetCorsHeaders(context.Background(), request)
 
 			assert.Equal(t, tt.expectedHeaders, gotHeaders)
 		})
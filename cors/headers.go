@@ -5,3 +5,4 @@ const allowMethodsHeader = "Access-Control-Allow-Methods"
 const allowOriginHeader = "Access-Control-Allow-Origin"
 const maxAgeHeader = "Access-Control-Max-Age"
 const allowHeadersHeader = "Access-Control-Allow-Headers"
+const exposeHeadersHeader = "Access-Control-Expose-Headers"
@@ -0,0 +1,143 @@
+package cors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockOriginChecker struct {
+	mock.Mock
+}
+
+func (mcd *mockOriginChecker) Options(ctx context.Context, request events.APIGatewayV2HTTPRequest) events.APIGatewayV2HTTPResponse {
+	args := mcd.Called(request)
+	return args.Get(0).(events.APIGatewayV2HTTPResponse)
+}
+
+func (mcd *mockOriginChecker) GetCorsHeaders(ctx context.Context, request events.APIGatewayV2HTTPRequest) map[string]string {
+	args := mcd.Called(request)
+	return args.Get(0).(map[string]string)
+}
+
+func TestMiddleware(t *testing.T) {
+	type mockNext struct {
+		body   interface{}
+		status int
+	}
+	tests := []struct {
+		name               string
+		method             string
+		mockOptions        *events.APIGatewayV2HTTPResponse
+		mockGetCorsHeaders map[string]string
+		mockNext           *mockNext
+		expectedRes        interface{}
+		expectedStatus     int
+	}{
+		{
+			name:               "Sets Access-Control-Allow-Origin when Origin Header is set",
+			method:             "GET",
+			mockGetCorsHeaders: map[string]string{"Access-Control-Allow-Origin": "test-place"},
+			mockNext:           &mockNext{body: map[string]string{"message": "huge success"}, status: 200},
+			expectedRes: &iface.Response{
+				Body:    map[string]string{"message": "huge success"},
+				Headers: map[string]string{"Access-Control-Allow-Origin": "test-place"},
+			},
+			expectedStatus: 200,
+		},
+		{
+			name:               "Keeps the headers set by the route",
+			method:             "GET",
+			mockGetCorsHeaders: map[string]string{"Access-Control-Allow-Origin": "test-place"},
+			mockNext: &mockNext{
+				body:   &iface.Response{Body: nil, Headers: map[string]string{"ETag": `"3"`}},
+				status: 200,
+			},
+			expectedRes: &iface.Response{
+				Body:    nil,
+				Headers: map[string]string{"Access-Control-Allow-Origin": "test-place", "ETag": `"3"`},
+			},
+			expectedStatus: 200,
+		},
+		{
+			name:               "If origin isn't allowed, don't add cors headers",
+			method:             "GET",
+			mockGetCorsHeaders: nil,
+			mockNext:           &mockNext{body: map[string]string{"message": "huge success"}, status: 200},
+			expectedRes:        map[string]string{"message": "huge success"},
+			expectedStatus:     200,
+		},
+		{
+			name:   "OPTIONS request for allowed domain returns methods without calling the route",
+			method: "OPTIONS",
+			mockOptions: &events.APIGatewayV2HTTPResponse{
+				Headers: map[string]string{
+					"Access-Control-Allow-Methods": "DELETE, GET, PATCH, POST",
+					"Access-Control-Allow-Origin":  "test-place",
+				},
+				StatusCode: 204,
+			},
+			expectedRes: &iface.Response{
+				Headers: map[string]string{
+					"Access-Control-Allow-Methods": "DELETE, GET, PATCH, POST",
+					"Access-Control-Allow-Origin":  "test-place",
+				},
+			},
+			expectedStatus: 204,
+		},
+		{
+			name:   "OPTIONS request without an origin returns the error",
+			method: "OPTIONS",
+			mockOptions: &events.APIGatewayV2HTTPResponse{
+				Body:       `{"error": "Origin header was not set on request"}`,
+				StatusCode: 400,
+			},
+			expectedRes:    &iface.Response{Body: problem.ForStatus(400, "Origin header was not set on request")},
+			expectedStatus: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := events.APIGatewayV2HTTPRequest{
+				Headers: map[string]string{"Origin": "test-place"},
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+						Path:   "/test",
+						Method: tt.method,
+					},
+				},
+			}
+
+			originChecker := &mockOriginChecker{}
+			originChecker.Test(t)
+			defer originChecker.AssertExpectations(t)
+			if tt.mockOptions != nil {
+				originChecker.On("Options", request).
+					Return(*tt.mockOptions).
+					Once()
+			} else {
+				originChecker.On("GetCorsHeaders", request).
+					Return(tt.mockGetCorsHeaders).
+					Once()
+			}
+
+			called := false
+			next := func(context.Context, events.APIGatewayV2HTTPRequest, iface.PathParams) (interface{}, int) {
+				called = true
+				return tt.mockNext.body, tt.mockNext.status
+			}
+
+			gotRes, gotStatus := Middleware(originChecker)(next)(context.Background(), request, iface.PathParams{})
+
+			assert.Equal(t, tt.expectedRes, gotRes)
+			assert.Equal(t, tt.expectedStatus, gotStatus)
+			assert.Equal(t, tt.mockNext != nil, called)
+		})
+	}
+}
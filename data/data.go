@@ -1,8 +1,9 @@
 package data
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 )
 
 // ListKey represents the primary key of a list
@@ -10,12 +11,22 @@ type ListKey struct {
 	ID string `json:"Id"`
 }
 
+// OwnerListKey represents the key of a list in the index of lists by owner
+type OwnerListKey struct {
+	ListKey
+	OwnerID          string `json:"OwnerId"`
+	UpdatedTimestamp string `json:"Updated"`
+}
+
 // List represents the data structure of a list
 type List struct {
 	ListKey
-	Name             string `json:"Name"`
-	CreatedTimestamp string `json:"Created"`
-	UpdatedTimestamp string `json:"Updated"`
+	Name             string   `json:"Name"`
+	OwnerID          string   `json:"OwnerId,omitempty"`
+	Collaborators    []string `json:"Collaborators,omitempty"`
+	Version          int64    `json:"Version"`
+	CreatedTimestamp string   `json:"Created"`
+	UpdatedTimestamp string   `json:"Updated"`
 }
 
 // ItemKey represents the primary key of an item
@@ -24,30 +35,211 @@ type ItemKey struct {
 	ListID string `json:"ListId"`
 }
 
+// ItemPositionKey represents the key of an item in the index of items by position
+type ItemPositionKey struct {
+	ItemKey
+	Position float64 `json:"Position"`
+}
+
 // Item represents the data structure of an item on a list
 type Item struct {
 	ItemKey
-	Name             string `json:"Name"`
-	IsCompleted      bool   `json:"IsCompleted"`
-	CreatedTimestamp string `json:"Created"`
-	UpdatedTimestamp string `json:"Updated"`
+	Name             string   `json:"Name"`
+	IsCompleted      bool     `json:"IsCompleted"`
+	Quantity         *float64 `json:"Quantity,omitempty"`
+	Unit             string   `json:"Unit,omitempty"`
+	Category         string   `json:"Category,omitempty"`
+	Position         float64  `json:"Position"`
+	Version          int64    `json:"Version"`
+	CreatedTimestamp string   `json:"Created"`
+	UpdatedTimestamp string   `json:"Updated"`
+	// DeletedTimestamp is set when the item is deleted, the item is kept as a tombstone so the deletion shows up in the changes feed
+	DeletedTimestamp string `json:"DeletedAt,omitempty"`
+	// ExpiresAt is when a tombstone is purged and can no longer be restored, in seconds since the epoch for DynamoDB's TTL
+	ExpiresAt int64 `json:"ExpiresAt,omitempty"`
+}
+
+// NewItem represents the fields which can be set when an item is created
+type NewItem struct {
+	Name     string   `json:"Name"`
+	Quantity *float64 `json:"Quantity"`
+	Unit     string   `json:"Unit"`
+	Category string   `json:"Category"`
+}
+
+// ItemUpdate represents the fields which can be changed on an existing item, empty fields are left as they are
+// The Clear fields remove the quantity, unit or category from the item, they're set when a request sends them as null
+type ItemUpdate struct {
+	Name          string   `json:"Name"`
+	IsCompleted   *bool    `json:"IsCompleted"`
+	Quantity      *float64 `json:"Quantity"`
+	Unit          string   `json:"Unit"`
+	Category      string   `json:"Category"`
+	ClearQuantity bool     `json:"-"`
+	ClearUnit     bool     `json:"-"`
+	ClearCategory bool     `json:"-"`
+}
+
+// Units are the units an item's quantity can be measured in
+var Units = []string{"pcs", "g", "kg", "ml", "l", "pack"}
+
+// Categories are the categories an item can be put in, in the order they are shown when grouping items
+var Categories = []string{"produce", "bakery", "dairy", "meat", "fish", "frozen", "pantry", "snacks", "drinks", "household", "other"}
+
+// ErrorInvalidQuantity is the error returned when a quantity isn't a positive number
+var ErrorInvalidQuantity = errors.New("must be greater than 0")
+
+// ErrorInvalidUnit is the error returned when a unit isn't one of Units
+var ErrorInvalidUnit = fmt.Errorf("must be one of %v", Units)
+
+// ErrorInvalidCategory is the error returned when a category isn't one of Categories
+var ErrorInvalidCategory = fmt.Errorf("must be one of %v", Categories)
+
+// ErrorUnitWithoutQuantity is the error returned when an item would have a unit but nothing to measure with it
+var ErrorUnitWithoutQuantity = errors.New("can only be set along with \"Quantity\"")
+
+// ErrorQuantityNeededByUnit is the error returned when a quantity is cleared from an item which keeps its unit
+var ErrorQuantityNeededByUnit = errors.New("can only be cleared along with \"Unit\"")
+
+// FieldError represents an invalid value in the field called Field
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+// FieldErrors is the error returned when a change would leave a record with invalid fields
+type FieldErrors []FieldError
+
+func (f FieldErrors) Error() string {
+	messages := make([]string, 0, len(f))
+	for _, fieldError := range f {
+		messages = append(messages, fmt.Sprintf("%s %s", fieldError.Field, fieldError.Err))
+	}
+	return "Invalid fields: " + strings.Join(messages, "; ")
+}
+
+// Validate checks the fields of a new item other than its name, which is checked along with the rest of the request
+func (n NewItem) Validate() []FieldError {
+	fieldErrors := validateFields(n.Quantity, n.Unit, n.Category)
+	if n.Unit != "" && n.Quantity == nil {
+		fieldErrors = append(fieldErrors, FieldError{Field: "Unit", Err: ErrorUnitWithoutQuantity})
+	}
+	return fieldErrors
 }
 
-// GetNameFieldInJson gets the value of "Name" from the passed in json
-func GetNameFieldInJson(jsonInput string) (string, error) {
-	type PostInput struct {
-		Name string `json:"Name"`
+// Validate checks the fields being changed on an item other than its name
+func (u ItemUpdate) Validate() []FieldError {
+	return validateFields(u.Quantity, u.Unit, u.Category)
+}
+
+// ValidateResult checks the item the update leaves, as a unit can't be set or kept without a quantity
+func (u ItemUpdate) ValidateResult(item Item) []FieldError {
+	if item.Unit == "" || item.Quantity != nil {
+		return nil
 	}
+	if u.ClearQuantity && !u.ClearUnit && u.Unit == "" {
+		return []FieldError{{Field: "Quantity", Err: ErrorQuantityNeededByUnit}}
+	}
+	return []FieldError{{Field: "Unit", Err: ErrorUnitWithoutQuantity}}
+}
 
-	var input PostInput
-	err := json.Unmarshal([]byte(jsonInput), &input)
-	if err != nil {
-		return "", err
+func validateFields(quantity *float64, unit string, category string) []FieldError {
+	var fieldErrors []FieldError
+	if quantity != nil && *quantity <= 0 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "Quantity", Err: ErrorInvalidQuantity})
 	}
+	if unit != "" && !contains(Units, unit) {
+		fieldErrors = append(fieldErrors, FieldError{Field: "Unit", Err: ErrorInvalidUnit})
+	}
+	if category != "" && !contains(Categories, category) {
+		fieldErrors = append(fieldErrors, FieldError{Field: "Category", Err: ErrorInvalidCategory})
+	}
+	return fieldErrors
+}
 
-	if input.Name == "" {
-		return "", fmt.Errorf("No \"Name\" field in the json")
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
+	return false
+}
+
+// BatchActionCreate creates a new item with the given name
+const BatchActionCreate = "create"
+
+// BatchActionDelete deletes the item with the given ID
+const BatchActionDelete = "delete"
+
+// BatchOperation represents a single create or delete in a batch request
+type BatchOperation struct {
+	Action string `json:"Action"`
+	ID     string `json:"Id,omitempty"`
+	Name   string `json:"Name,omitempty"`
+}
+
+// BatchResult represents the outcome of a BatchOperation
+type BatchResult struct {
+	Action    string `json:"Action"`
+	ID        string `json:"Id"`
+	Succeeded bool   `json:"Succeeded"`
+	Item      *Item  `json:"Item,omitempty"`
+	Error     string `json:"Error,omitempty"`
+}
+
+// ActivityCreateItem is recorded when an item is added to a list
+const ActivityCreateItem = "create_item"
+
+// ActivityUpdateItem is recorded when an item's fields are changed
+const ActivityUpdateItem = "update_item"
+
+// ActivityDeleteItem is recorded when an item is deleted
+const ActivityDeleteItem = "delete_item"
+
+// ActivityRestoreItem is recorded when a deleted item is restored
+const ActivityRestoreItem = "restore_item"
+
+// ActivityCreateList is recorded when a list is created
+const ActivityCreateList = "create_list"
+
+// ActivityUpdateList is recorded when a list is renamed
+const ActivityUpdateList = "update_list"
+
+// ActivityDeleteList is recorded when a list is deleted
+const ActivityDeleteList = "delete_list"
+
+// ActivityKey represents the primary key of an activity, IDs sort in the order the activities happened
+type ActivityKey struct {
+	ListID string `json:"ListId"`
+	ID     string `json:"Id"`
+}
+
+// Activity records who changed a list or one of its items, and how
+// Before and After hold the fields the change affected, as they were and as they became
+type Activity struct {
+	ActivityKey
+	Actor     string                 `json:"Actor"`
+	Action    string                 `json:"Action"`
+	ItemID    string                 `json:"ItemId,omitempty"`
+	Before    map[string]interface{} `json:"Before,omitempty"`
+	After     map[string]interface{} `json:"After,omitempty"`
+	Timestamp string                 `json:"Timestamp"`
+}
+
+// IdempotentResponse is the response to a request made with an Idempotency-Key, it's replayed when the request is repeated
+type IdempotentResponse struct {
+	StatusCode int               `json:"StatusCode"`
+	Headers    map[string]string `json:"Headers,omitempty"`
+	Body       string            `json:"Body,omitempty"`
+}
 
-	return input.Name, err
+// IdempotencyRecord holds an Idempotency-Key along with the fingerprint of the request made with it
+// Response is only set once that request has been handled
+type IdempotencyRecord struct {
+	Key              string              `json:"Id"`
+	Fingerprint      string              `json:"Fingerprint"`
+	Response         *IdempotentResponse `json:"Response,omitempty"`
+	CreatedTimestamp string              `json:"Created"`
+	ExpiresAt        int64               `json:"ExpiresAt"`
 }
@@ -6,54 +6,78 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestGetNameFieldInJson(t *testing.T) {
+func TestNewItemValidate(t *testing.T) {
+	quantity := 2.5
+	negative := -1.0
 	tests := []struct {
-		name         string
-		body         string
-		expectedName string
-		wantErr      bool
+		name        string
+		item        NewItem
+		expectedRes []FieldError
 	}{
 		{
-			name:         "Given json with name in it as a string all works",
-			body:         `{"Name": "bob"}`,
-			expectedName: "bob",
-			wantErr:      false,
+			name: "Given just a name all works",
+			item: NewItem{Name: "bread"},
 		},
 		{
-			name:         "Given json with name in it as a int, error",
-			body:         `{"Name": 74}`,
-			expectedName: "",
-			wantErr:      true,
+			name: "Given a quantity and unit all works",
+			item: NewItem{Name: "flour", Quantity: &quantity, Unit: "kg"},
 		},
 		{
-			name:         "Given bad json, error",
-			body:         `{"Name": "bob",}`,
-			expectedName: "",
-			wantErr:      true,
+			name: "Given a category all works",
+			item: NewItem{Name: "milk", Category: "dairy"},
 		},
 		{
-			name:         "Given extra fields, still works",
-			body:         `{"Name": "bob", "age": 55}`,
-			expectedName: "bob",
-			wantErr:      false,
+			name:        "Given a category which isn't known, error",
+			item:        NewItem{Name: "milk", Category: "cows"},
+			expectedRes: []FieldError{{Field: "Category", Err: ErrorInvalidCategory}},
 		},
 		{
-			name:         "no name field in json, return error",
-			body:         `{"age": 55}`,
-			expectedName: "",
-			wantErr:      true,
+			name:        "Given a unit which isn't known, error",
+			item:        NewItem{Name: "flour", Quantity: &quantity, Unit: "lb"},
+			expectedRes: []FieldError{{Field: "Unit", Err: ErrorInvalidUnit}},
+		},
+		{
+			name:        "Given a unit without a quantity, error",
+			item:        NewItem{Name: "flour", Unit: "kg"},
+			expectedRes: []FieldError{{Field: "Unit", Err: ErrorUnitWithoutQuantity}},
+		},
+		{
+			name:        "Given a negative quantity, error",
+			item:        NewItem{Name: "flour", Quantity: &negative},
+			expectedRes: []FieldError{{Field: "Quantity", Err: ErrorInvalidQuantity}},
+		},
+		{
+			name: "Given several invalid fields, an error for each",
+			item: NewItem{Name: "flour", Quantity: &negative, Unit: "lb", Category: "cows"},
+			expectedRes: []FieldError{
+				{Field: "Quantity", Err: ErrorInvalidQuantity},
+				{Field: "Unit", Err: ErrorInvalidUnit},
+				{Field: "Category", Err: ErrorInvalidCategory},
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotName, gotErr := GetNameFieldInJson(tt.body)
-
-			assert.Equal(t, tt.expectedName, gotName)
-			if tt.wantErr {
-				assert.Error(t, gotErr)
-			} else {
-				assert.NoError(t, gotErr)
-			}
+			assert.Equal(t, tt.expectedRes, tt.item.Validate())
 		})
 	}
 }
+
+func TestItemUpdateValidate(t *testing.T) {
+	zero := 0.0
+	assert.Empty(t, ItemUpdate{}.Validate())
+	assert.Empty(t, ItemUpdate{Unit: "ml"}.Validate())
+	assert.Equal(t, []FieldError{{Field: "Unit", Err: ErrorInvalidUnit}}, ItemUpdate{Unit: "cups"}.Validate())
+	assert.Equal(t, []FieldError{{Field: "Quantity", Err: ErrorInvalidQuantity}}, ItemUpdate{Quantity: &zero}.Validate())
+	assert.Empty(t, ItemUpdate{Category: "frozen"}.Validate())
+	assert.Equal(t, []FieldError{{Field: "Category", Err: ErrorInvalidCategory}}, ItemUpdate{Category: "toys"}.Validate())
+}
+
+func TestItemUpdateValidateResult(t *testing.T) {
+	quantity := 2.0
+	assert.Empty(t, ItemUpdate{Unit: "kg"}.ValidateResult(Item{Quantity: &quantity, Unit: "kg"}))
+	assert.Empty(t, ItemUpdate{ClearQuantity: true, ClearUnit: true}.ValidateResult(Item{}))
+	assert.Equal(t, []FieldError{{Field: "Unit", Err: ErrorUnitWithoutQuantity}}, ItemUpdate{Unit: "kg"}.ValidateResult(Item{Unit: "kg"}))
+	assert.Equal(t, []FieldError{{Field: "Unit", Err: ErrorUnitWithoutQuantity}}, ItemUpdate{Unit: "kg", ClearQuantity: true}.ValidateResult(Item{Unit: "kg"}))
+	assert.Equal(t, []FieldError{{Field: "Quantity", Err: ErrorQuantityNeededByUnit}}, ItemUpdate{ClearQuantity: true}.ValidateResult(Item{Unit: "kg"}))
+}
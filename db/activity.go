@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// activityTimeFormat has a fixed width so activity IDs sort in the order the activities happened,
+// which getTimestamp's format doesn't as it trims trailing zeros
+const activityTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// untrackedFields identify a record or change on every write, so they're left out of an activity's fields
+var untrackedFields = []string{"Id", "ListId", "Version", "Created", "Updated", "DeletedAt", "ExpiresAt"}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx which carries the ID of the caller making changes, so they're recorded in the activity
+func WithActor(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actorID)
+}
+
+// actorFromContext returns the caller carried by ctx, or an empty string if it doesn't carry one
+func actorFromContext(ctx context.Context) string {
+	actorID, _ := ctx.Value(actorKey{}).(string)
+	return actorID
+}
+
+// newActivity returns the activity for action being taken at timestamp by the actor in ctx
+// before and after are the record as it was and as it became, either can be nil, only the fields which changed are kept
+func newActivity(ctx context.Context, listID string, id string, action string, itemID string, before interface{}, after interface{}, timestamp string) (*data.Activity, error) {
+	beforeFields, err := trackedFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := trackedFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := &data.Activity{
+		ActivityKey: data.ActivityKey{ListID: listID, ID: activityID(timestamp, id)},
+		Actor:       actorFromContext(ctx),
+		Action:      action,
+		ItemID:      itemID,
+		Timestamp:   timestamp,
+	}
+	activity.Before, activity.After = changedFields(beforeFields, afterFields)
+	return activity, nil
+}
+
+// activityID puts the time first, so a list's activities sort by when they happened, and id after it to keep them unique
+func activityID(timestamp string, id string) string {
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return timestamp + "#" + id
+	}
+	return t.UTC().Format(activityTimeFormat) + "#" + id
+}
+
+// trackedFields returns the fields of record by their JSON names, without the untrackedFields
+func trackedFields(record interface{}) (map[string]interface{}, error) {
+	if record == nil || reflect.ValueOf(record).IsNil() {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	for _, name := range untrackedFields {
+		delete(fields, name)
+	}
+	return fields, nil
+}
+
+// changedFields returns the fields with different values before and after, a field missing from one side is left out of it
+func changedFields(before map[string]interface{}, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	var changedBefore, changedAfter map[string]interface{}
+	add := func(fields *map[string]interface{}, name string, value interface{}) {
+		if *fields == nil {
+			*fields = map[string]interface{}{}
+		}
+		(*fields)[name] = value
+	}
+
+	for name, value := range before {
+		if other, ok := after[name]; !ok || !reflect.DeepEqual(value, other) {
+			add(&changedBefore, name, value)
+		}
+	}
+	for name, value := range after {
+		if other, ok := before[name]; !ok || !reflect.DeepEqual(value, other) {
+			add(&changedAfter, name, value)
+		}
+	}
+	return changedBefore, changedAfter
+}
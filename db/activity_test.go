@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivityID(t *testing.T) {
+	tests := []struct {
+		name        string
+		timestamp   string
+		expectedRes string
+	}{
+		{
+			name:        "Trailing zeros are kept so IDs sort in time order",
+			timestamp:   "2020-01-23T09:59:14.9396531Z",
+			expectedRes: "2020-01-23T09:59:14.939653100Z#abc",
+		},
+		{
+			name:        "Whole seconds get a fraction too",
+			timestamp:   "2020-01-23T09:59:14Z",
+			expectedRes: "2020-01-23T09:59:14.000000000Z#abc",
+		},
+		{
+			name:        "A timestamp which can't be parsed is used as it is",
+			timestamp:   "yesterday",
+			expectedRes: "yesterday#abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedRes, activityID(tt.timestamp, "abc"))
+		})
+	}
+}
+
+func TestNewActivity(t *testing.T) {
+	timestamp := "2020-01-23T09:59:14Z"
+	quantity := 2.0
+	before := &data.Item{ItemKey: data.ItemKey{ID: "item", ListID: "list"}, Name: "Apples", Unit: "kg", Version: 1}
+	after := &data.Item{ItemKey: data.ItemKey{ID: "item", ListID: "list"}, Name: "Pears", Quantity: &quantity, Unit: "kg", Version: 2, UpdatedTimestamp: timestamp}
+
+	tests := []struct {
+		name        string
+		before      *data.Item
+		after       *data.Item
+		expectedRes *data.Activity
+	}{
+		{
+			name:   "Only the fields which changed are kept",
+			before: before,
+			after:  after,
+			expectedRes: &data.Activity{
+				ActivityKey: data.ActivityKey{ListID: "list", ID: "2020-01-23T09:59:14.000000000Z#id"},
+				Actor:       "user-1",
+				Action:      data.ActivityUpdateItem,
+				ItemID:      "item",
+				Before:      map[string]interface{}{"Name": "Apples"},
+				After:       map[string]interface{}{"Name": "Pears", "Quantity": quantity},
+				Timestamp:   timestamp,
+			},
+		},
+		{
+			name:  "Without a record before, every tracked field is kept after",
+			after: after,
+			expectedRes: &data.Activity{
+				ActivityKey: data.ActivityKey{ListID: "list", ID: "2020-01-23T09:59:14.000000000Z#id"},
+				Actor:       "user-1",
+				Action:      data.ActivityUpdateItem,
+				ItemID:      "item",
+				After:       map[string]interface{}{"Name": "Pears", "Quantity": quantity, "Unit": "kg", "IsCompleted": false, "Position": 0.0},
+				Timestamp:   timestamp,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRes, gotErr := newActivity(WithActor(context.Background(), "user-1"), "list", "id", data.ActivityUpdateItem, "item", tt.before, tt.after, timestamp)
+
+			assert.NoError(t, gotErr)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
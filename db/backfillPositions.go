@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/config"
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// BackfillPositions gives each item saved before items had a position one, so it's in ListPositionIndex
+// and on the pages of GET /lists/{listId}/items. It returns how many items it changed, and can be run
+// again to carry on if it stops part way through
+func BackfillPositions(ctx context.Context, conf config.Config) (int, error) {
+	return newDynamoDB(conf).(*dynamoDB).backfillPositions(ctx)
+}
+
+func (d *dynamoDB) backfillPositions(ctx context.Context) (int, error) {
+	missing, err := d.itemsWithoutPosition(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	listIDs := make([]string, 0, len(missing))
+	for listID := range missing {
+		listIDs = append(listIDs, listID)
+	}
+	sort.Strings(listIDs)
+
+	changed := 0
+	for _, listID := range listIDs {
+		n, err := d.backfillList(ctx, listID, missing[listID])
+		changed += n
+		if err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+// itemsWithoutPosition returns the IDs of the items, tombstones included, which have no Position, by list
+func (d *dynamoDB) itemsWithoutPosition(ctx context.Context) (map[string]map[string]bool, error) {
+	tableName := d.conf.TableNames.Items
+	if len(tableName) == 0 {
+		panic("Items table name not set")
+	}
+
+	missing := map[string]map[string]bool{}
+	var startKey map[string]*dynamodb.AttributeValue
+	for {
+		input := &dynamodb.ScanInput{
+			TableName:            aws.String(tableName),
+			FilterExpression:     aws.String("attribute_not_exists(Position)"),
+			ProjectionExpression: aws.String("ListId, Id"),
+			ExclusiveStartKey:    startKey,
+		}
+		result, err := d.session.ScanWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, i := range result.Items {
+			key := data.ItemKey{}
+			if err := dynamodbattribute.UnmarshalMap(i, &key); err != nil {
+				return nil, err
+			}
+			if missing[key.ListID] == nil {
+				missing[key.ListID] = map[string]bool{}
+			}
+			missing[key.ListID][key.ID] = true
+		}
+
+		startKey = result.LastEvaluatedKey
+		if len(startKey) == 0 {
+			return missing, nil
+		}
+	}
+}
+
+// backfillList gives the items on the list without a position the one they're already in, treated as 0
+// Items which share a position are spread out so the list keeps its order, as reordering it into that order would
+func (d *dynamoDB) backfillList(ctx context.Context, listID string, missing map[string]bool) (int, error) {
+	items, err := d.queryItems(ctx, listID, true)
+	if err != nil {
+		return 0, err
+	}
+
+	// The order GetItemsOnList returns them in
+	sort.SliceStable(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	sortByPosition(items)
+	itemIDs := make([]string, len(items))
+	for i, item := range items {
+		itemIDs[i] = item.ID
+	}
+	positions, err := planReorder(items, itemIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := d.getTimestamp()
+	changed := 0
+	for _, item := range items {
+		position, ok := positions[item.ID]
+		if !ok {
+			if !missing[item.ID] {
+				continue
+			}
+			position = item.Position
+		}
+
+		if err := d.setPosition(ctx, item, position, timestamp); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// setPosition moves item to position, as long as it hasn't changed since it was read
+// Nothing is recorded in the list's activity, as the list's order stays the same
+func (d *dynamoDB) setPosition(ctx context.Context, item data.Item, position float64, timestamp string) error {
+	key, err := dynamodbattribute.MarshalMap(&item.ItemKey)
+	if err != nil {
+		return err
+	}
+
+	condition, conditionValues := versionCondition(&item.Version)
+	input := &dynamodb.UpdateItemInput{
+		ExpressionAttributeValues: mergeValues(map[string]*dynamodb.AttributeValue{
+			":p":   {N: aws.String(strconv.FormatFloat(position, 'f', -1, 64))},
+			":t":   {S: &timestamp},
+			":one": {N: aws.String("1")},
+		}, conditionValues),
+		Key:                 key,
+		TableName:           aws.String(d.conf.TableNames.Items),
+		UpdateExpression:    aws.String("SET Position = :p, Updated = :t ADD Version :one"),
+		ConditionExpression: condition,
+	}
+
+	_, err = d.session.UpdateItemWithContext(ctx, input)
+	return err
+}
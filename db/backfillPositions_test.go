@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackfillPositions(t *testing.T) {
+	listID := "474c2Fff7"
+	timestamp := "2020-01-23T09:59:14.9396531Z"
+	scanInput := &dynamodb.ScanInput{
+		TableName:            aws.String("items-table"),
+		FilterExpression:     aws.String("attribute_not_exists(Position)"),
+		ProjectionExpression: aws.String("ListId, Id"),
+	}
+	key := func(id string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{"ListId": {S: aws.String(listID)}, "Id": {S: aws.String(id)}}
+	}
+	setPosition := func(id string, position string, version string) *dynamodb.UpdateItemInput {
+		return &dynamodb.UpdateItemInput{
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":p":   {N: aws.String(position)},
+				":t":   {S: aws.String(timestamp)},
+				":one": {N: aws.String("1")},
+				":v":   {N: aws.String(version)},
+			},
+			Key:                 key(id),
+			TableName:           aws.String("items-table"),
+			UpdateExpression:    aws.String("SET Position = :p, Updated = :t ADD Version :one"),
+			ConditionExpression: aws.String("attribute_exists(Id) AND Version = :v"),
+		}
+	}
+
+	tests := []struct {
+		name            string
+		scanOutput      *dynamodb.ScanOutput
+		expectQuery     bool
+		expectedUpdates []*dynamodb.UpdateItemInput
+		updateErr       error
+		expectedRes     int
+		expectedErr     error
+	}{
+		{
+			name:        "Items without a position are given one which keeps the list in the same order",
+			scanOutput:  &dynamodb.ScanOutput{Items: []map[string]*dynamodb.AttributeValue{key("a"), key("b")}},
+			expectQuery: true,
+			expectedUpdates: []*dynamodb.UpdateItemInput{
+				setPosition("a", "-1024", "1"),
+				setPosition("b", "0", "1"),
+			},
+			expectedRes: 2,
+		},
+		{
+			name:        "When every item has a position, nothing is changed",
+			scanOutput:  &dynamodb.ScanOutput{Items: []map[string]*dynamodb.AttributeValue{}},
+			expectedRes: 0,
+		},
+		{
+			name:            "When setting a position fails, the error is returned with how many items were changed",
+			scanOutput:      &dynamodb.ScanOutput{Items: []map[string]*dynamodb.AttributeValue{key("a"), key("b")}},
+			expectQuery:     true,
+			expectedUpdates: []*dynamodb.UpdateItemInput{setPosition("a", "-1024", "1")},
+			updateErr:       errors.New("Something went wrong"),
+			expectedRes:     0,
+			expectedErr:     errors.New("Something went wrong"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			dbMocked.On("Scan", scanInput).Return(tt.scanOutput, nil).Once()
+			if tt.expectQuery {
+				dbMocked.
+					On("Query", &dynamodb.QueryInput{
+						ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":id": {S: aws.String(listID)}},
+						KeyConditionExpression:    aws.String("ListId = :id"),
+						TableName:                 aws.String("items-table"),
+					}).
+					Return(&dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{
+						{"ListId": {S: aws.String(listID)}, "Id": {S: aws.String("a")}, "Version": {N: aws.String("1")}},
+						{"ListId": {S: aws.String(listID)}, "Id": {S: aws.String("b")}, "Version": {N: aws.String("1")}},
+						{"ListId": {S: aws.String(listID)}, "Id": {S: aws.String("c")}, "Position": {N: aws.String("4096")}, "Version": {N: aws.String("2")}},
+					}}, nil).
+					Once()
+			}
+			for _, update := range tt.expectedUpdates {
+				dbMocked.On("UpdateItem", update).Return(&dynamodb.UpdateItemOutput{}, tt.updateErr).Once()
+			}
+
+			d := dynamoDB{session: dbMocked, conf: testConfig, getTimestamp: func() string { return timestamp }}
+			gotRes, gotErr := d.backfillPositions(context.Background())
+
+			assert.Equal(t, tt.expectedErr, gotErr)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// maxBatchWriteSize is the maximum number of requests dynamodb accepts in a single BatchWriteItem call
+const maxBatchWriteSize = 25
+
+// maxBatchWriteAttempts is the number of times a chunk is sent before giving up on its unprocessed items
+const maxBatchWriteAttempts = 5
+
+// batchWriteBackoff is how long to wait before the first retry, it doubles on each attempt after that
+const batchWriteBackoff = 50 * time.Millisecond
+
+// batchWrite writes all of the requests, returning an error if any of them could not be processed
+func (d *dynamoDB) batchWrite(ctx context.Context, tableName string, requests []*dynamodb.WriteRequest) error {
+	for _, chunk := range chunkWriteRequests(requests) {
+		unprocessed, err := d.writeChunk(ctx, map[string][]*dynamodb.WriteRequest{tableName: chunk})
+		if err != nil {
+			return err
+		}
+		if len(unprocessed[tableName]) > 0 {
+			return fmt.Errorf("%d write requests were left unprocessed", len(unprocessed[tableName]))
+		}
+	}
+
+	return nil
+}
+
+// writeChunk sends up to maxBatchWriteSize requests, by table name, retrying unprocessed items with exponential backoff
+// Any requests still unprocessed after maxBatchWriteAttempts are returned
+func (d *dynamoDB) writeChunk(ctx context.Context, chunk map[string][]*dynamodb.WriteRequest) (map[string][]*dynamodb.WriteRequest, error) {
+	pending := chunk
+	for attempt := 1; len(pending) > 0; attempt++ {
+		if attempt > maxBatchWriteAttempts {
+			return pending, nil
+		}
+		if attempt > 1 {
+			d.sleep(batchWriteBackoff << (attempt - 2))
+		}
+
+		input := &dynamodb.BatchWriteItemInput{
+			RequestItems: pending,
+		}
+		output, err := d.session.BatchWriteItemWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		pending = output.UnprocessedItems
+	}
+
+	return nil, nil
+}
+
+func chunkWriteRequests(requests []*dynamodb.WriteRequest) [][]*dynamodb.WriteRequest {
+	chunks := [][]*dynamodb.WriteRequest{}
+	for start := 0; start < len(requests); start += maxBatchWriteSize {
+		end := start + maxBatchWriteSize
+		if end > len(requests) {
+			end = len(requests)
+		}
+		chunks = append(chunks, requests[start:end])
+	}
+	return chunks
+}
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// operationWrite holds the requests made for an operation in a batch
+type operationWrite struct {
+	index    int
+	item     *dynamodb.WriteRequest
+	activity *dynamodb.WriteRequest
+}
+
+// BatchWriteItems creates and deletes items, deleted items are replaced with their tombstones
+// Each operation succeeds or fails on its own. Its activity is written in the same batch, but as a batch
+// isn't a transaction that is best-effort, an operation still succeeds if its activity is left unprocessed
+func (d *dynamoDB) BatchWriteItems(ctx context.Context, listID string, operations []data.BatchOperation) ([]data.BatchResult, error) {
+	tableName := d.conf.TableNames.Items
+	if len(tableName) == 0 {
+		panic("Items table name not set")
+	}
+	activityTableName := d.conf.TableNames.Activity
+	if len(activityTableName) == 0 {
+		panic("Activity table name not set")
+	}
+
+	existing, err := d.itemsToDelete(ctx, listID, operations)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := d.getTimestamp()
+	position := newItemPosition(timestamp)
+
+	results := make([]data.BatchResult, len(operations))
+	writes := []operationWrite{}
+	for i, operation := range operations {
+		var toWrite *data.Item
+		var activity *data.Activity
+		switch operation.Action {
+		case data.BatchActionCreate:
+			// Keep the items in the order they were sent by giving each one a slightly later position
+			toWrite = newItem(listID, d.generateID(), data.NewItem{Name: operation.Name}, position+float64(i), timestamp)
+			results[i] = data.BatchResult{Action: operation.Action, ID: toWrite.ID, Item: toWrite}
+			activity, err = newActivity(ctx, listID, d.generateID(), data.ActivityCreateItem, toWrite.ID, nil, toWrite, timestamp)
+		case data.BatchActionDelete:
+			results[i] = data.BatchResult{Action: operation.Action, ID: operation.ID}
+			item, ok := existing[operation.ID]
+			if !ok {
+				// Deleting is idempotent, there's nothing to write for an item which is already gone
+				results[i].Succeeded = true
+				continue
+			}
+			deleted := tombstone(item, timestamp, d.conf.TombstoneRetention)
+			toWrite = &deleted
+			activity, err = newActivity(ctx, listID, d.generateID(), data.ActivityDeleteItem, item.ID, &item, nil, timestamp)
+		default:
+			return nil, fmt.Errorf("%w: unknown action %q", ErrorBadRequest, operation.Action)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		itemRequest, err := putRequest(toWrite)
+		if err != nil {
+			return nil, err
+		}
+		activityRequest, err := putRequest(activity)
+		if err != nil {
+			return nil, err
+		}
+		writes = append(writes, operationWrite{index: i, item: itemRequest, activity: activityRequest})
+	}
+
+	// Each operation makes two requests, so half as many operations as requests fit in a chunk
+	perChunk := maxBatchWriteSize / 2
+	for start := 0; start < len(writes); start += perChunk {
+		end := start + perChunk
+		if end > len(writes) {
+			end = len(writes)
+		}
+		chunk := writes[start:end]
+
+		requests := map[string][]*dynamodb.WriteRequest{}
+		for _, write := range chunk {
+			requests[tableName] = append(requests[tableName], write.item)
+			requests[activityTableName] = append(requests[activityTableName], write.activity)
+		}
+
+		failed := map[string]string{}
+		unprocessed, err := d.writeChunk(ctx, requests)
+		if err != nil {
+			for _, write := range chunk {
+				failed[results[write.index].ID] = err.Error()
+			}
+		}
+		for _, request := range unprocessed[tableName] {
+			failed[writeRequestID(request)] = "Not processed, try again later"
+		}
+
+		for _, write := range chunk {
+			result := &results[write.index]
+			if message, ok := failed[result.ID]; ok {
+				result.Error = message
+				result.Item = nil
+				continue
+			}
+			result.Succeeded = true
+		}
+	}
+
+	return results, nil
+}
+
+// itemsToDelete returns the items on the list which operations delete by ID, it only reads the list if there are any
+func (d *dynamoDB) itemsToDelete(ctx context.Context, listID string, operations []data.BatchOperation) (map[string]data.Item, error) {
+	toDelete := map[string]data.Item{}
+	for _, operation := range operations {
+		if operation.Action == data.BatchActionDelete {
+			toDelete[operation.ID] = data.Item{}
+		}
+	}
+	if len(toDelete) == 0 {
+		return toDelete, nil
+	}
+
+	items, err := d.GetItemsOnList(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]data.Item{}
+	for _, item := range *items {
+		if _, ok := toDelete[item.ID]; ok {
+			existing[item.ID] = item
+		}
+	}
+	return existing, nil
+}
+
+// putRequest returns the request which puts record in a batch
+func putRequest(record interface{}) (*dynamodb.WriteRequest, error) {
+	item, err := dynamodbattribute.MarshalMap(record)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: item}}, nil
+}
+
+func writeRequestID(request *dynamodb.WriteRequest) string {
+	var attributes map[string]*dynamodb.AttributeValue
+	if request.PutRequest != nil {
+		attributes = request.PutRequest.Item
+	} else if request.DeleteRequest != nil {
+		attributes = request.DeleteRequest.Key
+	}
+
+	if id, ok := attributes["Id"]; ok && id.S != nil {
+		return *id.S
+	}
+	return ""
+}
@@ -0,0 +1,246 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchWriteItems(t *testing.T) {
+	listID := "474c2Fff7"
+	timestamp := "2020-01-23T09:59:14.9396531Z"
+	activityPrefix := "2020-01-23T09:59:14.939653100Z#"
+	operations := []data.BatchOperation{
+		{Action: data.BatchActionCreate, Name: "Milk"},
+		{Action: data.BatchActionCreate, Name: "Eggs"},
+		{Action: data.BatchActionDelete, ID: "old-item"},
+	}
+	oldItem := map[string]*dynamodb.AttributeValue{
+		"Id":      {S: aws.String("old-item")},
+		"ListId":  {S: aws.String(listID)},
+		"Name":    {S: aws.String("Bread")},
+		"Version": {N: aws.String("2")},
+	}
+	oldItemTombstone, _ := dynamodbattribute.MarshalMap(data.Item{
+		ItemKey:          data.ItemKey{ID: "old-item", ListID: listID},
+		Name:             "Bread",
+		Version:          3,
+		UpdatedTimestamp: timestamp,
+		DeletedTimestamp: timestamp,
+		ExpiresAt:        1579859954,
+	})
+	activityRequest := func(activity data.Activity) *dynamodb.WriteRequest {
+		item, _ := dynamodbattribute.MarshalMap(activity)
+		return &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: item}}
+	}
+	createActivity := func(activityID string, itemID string, name string, position float64) *dynamodb.WriteRequest {
+		return activityRequest(data.Activity{
+			ActivityKey: data.ActivityKey{ListID: listID, ID: activityPrefix + activityID},
+			Actor:       testActor,
+			Action:      data.ActivityCreateItem,
+			ItemID:      itemID,
+			After:       map[string]interface{}{"Name": name, "IsCompleted": false, "Position": position},
+			Timestamp:   timestamp,
+		})
+	}
+	itemRequests := []*dynamodb.WriteRequest{
+		{PutRequest: &dynamodb.PutRequest{Item: createExpectedInput("id-1", listID, "Milk", false, "1579773554939", timestamp)}},
+		{PutRequest: &dynamodb.PutRequest{Item: createExpectedInput("id-3", listID, "Eggs", false, "1579773554940", timestamp)}},
+		{PutRequest: &dynamodb.PutRequest{Item: oldItemTombstone}},
+	}
+	activityRequests := []*dynamodb.WriteRequest{
+		createActivity("id-2", "id-1", "Milk", 1579773554939),
+		createActivity("id-4", "id-3", "Eggs", 1579773554940),
+		activityRequest(data.Activity{
+			ActivityKey: data.ActivityKey{ListID: listID, ID: activityPrefix + "id-5"},
+			Actor:       testActor,
+			Action:      data.ActivityDeleteItem,
+			ItemID:      "old-item",
+			Before:      map[string]interface{}{"Name": "Bread", "IsCompleted": false, "Position": 0.0},
+			Timestamp:   timestamp,
+		}),
+	}
+	requests := map[string][]*dynamodb.WriteRequest{"items-table": itemRequests, "activity-table": activityRequests}
+	milk := &data.Item{ItemKey: data.ItemKey{ID: "id-1", ListID: listID}, Name: "Milk", Position: 1579773554939, Version: 1, CreatedTimestamp: timestamp, UpdatedTimestamp: timestamp}
+	eggs := &data.Item{ItemKey: data.ItemKey{ID: "id-3", ListID: listID}, Name: "Eggs", Position: 1579773554940, Version: 1, CreatedTimestamp: timestamp, UpdatedTimestamp: timestamp}
+
+	manyOperations := []data.BatchOperation{}
+	manyRequests := []map[string][]*dynamodb.WriteRequest{{}, {}}
+	manyResults := []data.BatchResult{}
+	for i := 0; i < 13; i++ {
+		name := fmt.Sprintf("Item %d", i)
+		itemID := fmt.Sprintf("id-%d", 2*i+1)
+		position := 1579773554939 + float64(i)
+		manyOperations = append(manyOperations, data.BatchOperation{Action: data.BatchActionCreate, Name: name})
+		chunk := manyRequests[i/12]
+		chunk["items-table"] = append(chunk["items-table"], &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{
+			Item: createExpectedInput(itemID, listID, name, false, fmt.Sprint(int64(position)), timestamp),
+		}})
+		chunk["activity-table"] = append(chunk["activity-table"], createActivity(fmt.Sprintf("id-%d", 2*i+2), itemID, name, position))
+		manyResults = append(manyResults, data.BatchResult{Action: data.BatchActionCreate, ID: itemID, Succeeded: true, Item: &data.Item{
+			ItemKey: data.ItemKey{ID: itemID, ListID: listID}, Name: name, Position: position, Version: 1, CreatedTimestamp: timestamp, UpdatedTimestamp: timestamp,
+		}})
+	}
+
+	tests := []struct {
+		name          string
+		operations    []data.BatchOperation
+		existingItems []map[string]*dynamodb.AttributeValue
+		requests      []map[string][]*dynamodb.WriteRequest
+		mockResponses []*dynamodb.BatchWriteItemOutput
+		mockErr       error
+		expectedRes   []data.BatchResult
+		expectedErr   error
+	}{
+		{
+			name:          "When every operation is processed, they all succeed and their activity is written with them",
+			operations:    operations,
+			existingItems: []map[string]*dynamodb.AttributeValue{oldItem},
+			requests:      []map[string][]*dynamodb.WriteRequest{requests},
+			mockResponses: []*dynamodb.BatchWriteItemOutput{{}},
+			expectedRes: []data.BatchResult{
+				{Action: data.BatchActionCreate, ID: "id-1", Succeeded: true, Item: milk},
+				{Action: data.BatchActionCreate, ID: "id-3", Succeeded: true, Item: eggs},
+				{Action: data.BatchActionDelete, ID: "old-item", Succeeded: true},
+			},
+		},
+		{
+			name:          "Unprocessed items are retried",
+			operations:    operations,
+			existingItems: []map[string]*dynamodb.AttributeValue{oldItem},
+			requests: []map[string][]*dynamodb.WriteRequest{
+				requests,
+				{"items-table": itemRequests[1:2], "activity-table": activityRequests[1:2]},
+			},
+			mockResponses: []*dynamodb.BatchWriteItemOutput{
+				{UnprocessedItems: map[string][]*dynamodb.WriteRequest{"items-table": itemRequests[1:2], "activity-table": activityRequests[1:2]}},
+				{},
+			},
+			expectedRes: []data.BatchResult{
+				{Action: data.BatchActionCreate, ID: "id-1", Succeeded: true, Item: milk},
+				{Action: data.BatchActionCreate, ID: "id-3", Succeeded: true, Item: eggs},
+				{Action: data.BatchActionDelete, ID: "old-item", Succeeded: true},
+			},
+		},
+		{
+			name:          "Only the items which are never processed are reported as failures",
+			operations:    operations,
+			existingItems: []map[string]*dynamodb.AttributeValue{oldItem},
+			requests: []map[string][]*dynamodb.WriteRequest{
+				requests, {"items-table": itemRequests[2:]}, {"items-table": itemRequests[2:]}, {"items-table": itemRequests[2:]}, {"items-table": itemRequests[2:]},
+			},
+			mockResponses: []*dynamodb.BatchWriteItemOutput{
+				batchOutput(itemRequests[2:]), batchOutput(itemRequests[2:]), batchOutput(itemRequests[2:]), batchOutput(itemRequests[2:]), batchOutput(itemRequests[2:]),
+			},
+			expectedRes: []data.BatchResult{
+				{Action: data.BatchActionCreate, ID: "id-1", Succeeded: true, Item: milk},
+				{Action: data.BatchActionCreate, ID: "id-3", Succeeded: true, Item: eggs},
+				{Action: data.BatchActionDelete, ID: "old-item", Succeeded: false, Error: "Not processed, try again later"},
+			},
+		},
+		{
+			name:          "An operation whose activity is never processed still succeeds",
+			operations:    operations[:1],
+			existingItems: nil,
+			requests: []map[string][]*dynamodb.WriteRequest{
+				{"items-table": itemRequests[:1], "activity-table": activityRequests[:1]},
+				{"activity-table": activityRequests[:1]}, {"activity-table": activityRequests[:1]}, {"activity-table": activityRequests[:1]}, {"activity-table": activityRequests[:1]},
+			},
+			mockResponses: []*dynamodb.BatchWriteItemOutput{
+				{UnprocessedItems: map[string][]*dynamodb.WriteRequest{"activity-table": activityRequests[:1]}},
+				{UnprocessedItems: map[string][]*dynamodb.WriteRequest{"activity-table": activityRequests[:1]}},
+				{UnprocessedItems: map[string][]*dynamodb.WriteRequest{"activity-table": activityRequests[:1]}},
+				{UnprocessedItems: map[string][]*dynamodb.WriteRequest{"activity-table": activityRequests[:1]}},
+				{UnprocessedItems: map[string][]*dynamodb.WriteRequest{"activity-table": activityRequests[:1]}},
+			},
+			expectedRes: []data.BatchResult{
+				{Action: data.BatchActionCreate, ID: "id-1", Succeeded: true, Item: milk},
+			},
+		},
+		{
+			name:          "When db returns an error, every operation in the chunk fails",
+			operations:    operations,
+			existingItems: []map[string]*dynamodb.AttributeValue{oldItem},
+			requests:      []map[string][]*dynamodb.WriteRequest{requests},
+			mockResponses: []*dynamodb.BatchWriteItemOutput{{}},
+			mockErr:       errors.New("Something went wrong"),
+			expectedRes: []data.BatchResult{
+				{Action: data.BatchActionCreate, ID: "id-1", Succeeded: false, Error: "Something went wrong"},
+				{Action: data.BatchActionCreate, ID: "id-3", Succeeded: false, Error: "Something went wrong"},
+				{Action: data.BatchActionDelete, ID: "old-item", Succeeded: false, Error: "Something went wrong"},
+			},
+		},
+		{
+			name:          "Deleting an item which is already gone succeeds without writing anything for it",
+			operations:    operations,
+			existingItems: []map[string]*dynamodb.AttributeValue{},
+			requests:      []map[string][]*dynamodb.WriteRequest{{"items-table": itemRequests[:2], "activity-table": activityRequests[:2]}},
+			mockResponses: []*dynamodb.BatchWriteItemOutput{{}},
+			expectedRes: []data.BatchResult{
+				{Action: data.BatchActionCreate, ID: "id-1", Succeeded: true, Item: milk},
+				{Action: data.BatchActionCreate, ID: "id-3", Succeeded: true, Item: eggs},
+				{Action: data.BatchActionDelete, ID: "old-item", Succeeded: true},
+			},
+		},
+		{
+			name:          "Operations are written 12 at a time, as each one makes two requests",
+			operations:    manyOperations,
+			requests:      manyRequests,
+			mockResponses: []*dynamodb.BatchWriteItemOutput{{}, {}},
+			expectedRes:   manyResults,
+		},
+		{
+			name:        "Unknown actions are rejected",
+			operations:  []data.BatchOperation{{Action: "update", ID: "old-item"}},
+			expectedErr: ErrorBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			if tt.existingItems != nil {
+				dbMocked.
+					On("Query", &dynamodb.QueryInput{
+						ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":id": {S: aws.String(listID)}},
+						KeyConditionExpression:    aws.String("ListId = :id"),
+						FilterExpression:          aws.String("attribute_not_exists(DeletedAt)"),
+						TableName:                 aws.String("items-table"),
+					}).
+					Return(&dynamodb.QueryOutput{Items: tt.existingItems}, nil).
+					Once()
+			}
+
+			for i, response := range tt.mockResponses {
+				dbMocked.
+					On("BatchWriteItem", &dynamodb.BatchWriteItemInput{RequestItems: tt.requests[i]}).
+					Return(response, tt.mockErr).
+					Once()
+			}
+
+			nextID := 0
+			d := dynamoDB{
+				session:      dbMocked,
+				conf:         testConfig,
+				generateID:   func() string { nextID++; return fmt.Sprintf("id-%d", nextID) },
+				getTimestamp: func() string { return timestamp },
+				sleep:        func(time.Duration) {},
+			}
+			gotRes, gotErr := d.BatchWriteItems(WithActor(context.Background(), testActor), listID, tt.operations)
+
+			assert.True(t, errors.Is(gotErr, tt.expectedErr), "expected %v, got %v", tt.expectedErr, gotErr)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func deleteRequests(n int) []*dynamodb.WriteRequest {
+	requests := make([]*dynamodb.WriteRequest, n)
+	for i := range requests {
+		requests[i] = &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{
+			Key: map[string]*dynamodb.AttributeValue{"Id": {S: aws.String(fmt.Sprint(i))}},
+		}}
+	}
+	return requests
+}
+
+func batchInput(requests []*dynamodb.WriteRequest) *dynamodb.BatchWriteItemInput {
+	return &dynamodb.BatchWriteItemInput{
+		RequestItems: map[string][]*dynamodb.WriteRequest{"items-table": requests},
+	}
+}
+
+func batchOutput(unprocessed []*dynamodb.WriteRequest) *dynamodb.BatchWriteItemOutput {
+	if len(unprocessed) == 0 {
+		return &dynamodb.BatchWriteItemOutput{}
+	}
+	return &dynamodb.BatchWriteItemOutput{
+		UnprocessedItems: map[string][]*dynamodb.WriteRequest{"items-table": unprocessed},
+	}
+}
+
+func TestBatchWrite(t *testing.T) {
+	t.Run("Requests are split into chunks of 25", func(t *testing.T) {
+		requests := deleteRequests(30)
+
+		dbMocked := &mockDB{}
+		dbMocked.Test(t)
+		defer dbMocked.AssertExpectations(t)
+		dbMocked.On("BatchWriteItem", batchInput(requests[:25])).Return(batchOutput(nil), nil).Once()
+		dbMocked.On("BatchWriteItem", batchInput(requests[25:])).Return(batchOutput(nil), nil).Once()
+
+		d := dynamoDB{session: dbMocked, conf: testConfig}
+		gotErr := d.batchWrite(context.Background(), "items-table", requests)
+
+		assert.NoError(t, gotErr)
+	})
+
+	t.Run("Unprocessed items are retried", func(t *testing.T) {
+		requests := deleteRequests(3)
+
+		dbMocked := &mockDB{}
+		dbMocked.Test(t)
+		defer dbMocked.AssertExpectations(t)
+		dbMocked.On("BatchWriteItem", batchInput(requests)).Return(batchOutput(requests[1:]), nil).Once()
+		dbMocked.On("BatchWriteItem", batchInput(requests[1:])).Return(batchOutput(requests[2:]), nil).Once()
+		dbMocked.On("BatchWriteItem", batchInput(requests[2:])).Return(batchOutput(nil), nil).Once()
+
+		sleeps := []time.Duration{}
+		d := dynamoDB{session: dbMocked, conf: testConfig, sleep: func(d time.Duration) { sleeps = append(sleeps, d) }}
+		gotErr := d.batchWrite(context.Background(), "items-table", requests)
+
+		assert.NoError(t, gotErr)
+		assert.Equal(t, []time.Duration{50 * time.Millisecond, 100 * time.Millisecond}, sleeps)
+	})
+
+	t.Run("Gives up when items are never processed", func(t *testing.T) {
+		requests := deleteRequests(2)
+
+		dbMocked := &mockDB{}
+		dbMocked.Test(t)
+		defer dbMocked.AssertExpectations(t)
+		dbMocked.On("BatchWriteItem", batchInput(requests)).Return(batchOutput(requests), nil).Times(maxBatchWriteAttempts)
+
+		d := dynamoDB{session: dbMocked, conf: testConfig, sleep: func(time.Duration) {}}
+		gotErr := d.batchWrite(context.Background(), "items-table", requests)
+
+		assert.Equal(t, errors.New("2 write requests were left unprocessed"), gotErr)
+	})
+
+	t.Run("When db returns an error, that error is returned", func(t *testing.T) {
+		requests := deleteRequests(1)
+
+		dbMocked := &mockDB{}
+		dbMocked.Test(t)
+		defer dbMocked.AssertExpectations(t)
+		dbMocked.On("BatchWriteItem", batchInput(requests)).Return(batchOutput(nil), errors.New("Something went wrong")).Once()
+
+		d := dynamoDB{session: dbMocked, conf: testConfig}
+		gotErr := d.batchWrite(context.Background(), "items-table", requests)
+
+		assert.Equal(t, errors.New("Something went wrong"), gotErr)
+	})
+}
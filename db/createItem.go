@@ -1,56 +1,76 @@
 package db
 
 import (
+	"context"
+
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/mount-joy/thelist-lambda/data"
 )
 
-func (d *dynamoDB) CreateItem(listID string, name string) (*data.Item, error) {
+// CreateItem adds an item to the list, recording it in the list's activity
+func (d *dynamoDB) CreateItem(ctx context.Context, listID string, fields data.NewItem) (*data.Item, error) {
 	itemID := d.generateID()
 	timestamp := d.getTimestamp()
 
-	item := &data.Item{
-		ItemKey: data.ItemKey{
-			ListID: listID,
-			ID:     itemID,
-		},
-		Name:             name,
-		IsCompleted:      false,
-		CreatedTimestamp: timestamp,
-		UpdatedTimestamp: timestamp,
+	item := newItem(listID, itemID, fields, newItemPosition(timestamp), timestamp)
+	change, err := d.createChange(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.transactWrite(ctx, change.activity, change.write)
+	if err == errConditionFailed {
+		return nil, ErrorIDExists
 	}
-	itemToInsert, err := dynamodbattribute.MarshalMap(item)
 	if err != nil {
 		return nil, err
 	}
 
+	return item, nil
+}
+
+// createChange returns the change which puts the new item, as long as there isn't an item with its ID already
+func (d *dynamoDB) createChange(ctx context.Context, item *data.Item) (change, error) {
 	tableName := d.conf.TableNames.Items
 	if len(tableName) == 0 {
 		panic("Items table name not set")
 	}
 
-	input := &dynamodb.PutItemInput{
-		Item:                itemToInsert,
-		TableName:           aws.String(tableName),
-		ConditionExpression: aws.String("attribute_not_exists(Id)"),
+	itemToInsert, err := dynamodbattribute.MarshalMap(item)
+	if err != nil {
+		return change{}, err
+	}
+
+	activity, err := newActivity(ctx, item.ListID, d.generateID(), data.ActivityCreateItem, item.ID, nil, item, item.CreatedTimestamp)
+	if err != nil {
+		return change{}, err
 	}
 
-	_, err = d.session.PutItem(input)
+	return change{activity: activity, write: &dynamodb.TransactWriteItem{
+		Put: &dynamodb.Put{
+			Item:                itemToInsert,
+			TableName:           aws.String(tableName),
+			ConditionExpression: aws.String("attribute_not_exists(Id)"),
+		},
+	}}, nil
+}
 
-	switch e := err.(type) {
-	case nil:
-		break
-	case awserr.Error:
-		if e.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
-			return nil, ErrorIDExists
-		}
-		return nil, err
-	default:
-		return nil, err
+func newItem(listID string, itemID string, fields data.NewItem, position float64, timestamp string) *data.Item {
+	return &data.Item{
+		ItemKey: data.ItemKey{
+			ListID: listID,
+			ID:     itemID,
+		},
+		Name:             fields.Name,
+		IsCompleted:      false,
+		Quantity:         fields.Quantity,
+		Unit:             fields.Unit,
+		Category:         fields.Category,
+		Position:         position,
+		Version:          1,
+		CreatedTimestamp: timestamp,
+		UpdatedTimestamp: timestamp,
 	}
-
-	return item, nil
 }
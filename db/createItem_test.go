@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -15,36 +16,58 @@ func TestCreateItem(t *testing.T) {
 	itemID := "b6cf642d"
 	itemName := "Peaches"
 	timestamp := "2020-01-23T09:59:14.9396531Z"
+	quantity := 1.5
+	position := 1579773554939.0
+	activityID := "2020-01-23T09:59:14.939653100Z#" + itemID
 
 	tests := []struct {
 		name           string
+		fields         data.NewItem
 		item           map[string]*dynamodb.AttributeValue
+		after          map[string]interface{}
 		mockOutputErr  error
 		expectedOutput *data.Item
 		expectedErr    error
 	}{
 		{
 			name:           "If the ID does not exists it creates the item",
-			item:           createExpectedInput(itemID, listID, itemName, false, timestamp),
+			fields:         data.NewItem{Name: itemName},
+			item:           createExpectedInput(itemID, listID, itemName, false, "1579773554939", timestamp),
+			after:          map[string]interface{}{"Name": itemName, "IsCompleted": false, "Position": position},
 			mockOutputErr:  nil,
-			expectedOutput: &data.Item{ItemKey: data.ItemKey{ID: itemID, ListID: listID}, Name: itemName, IsCompleted: false, UpdatedTimestamp: timestamp, CreatedTimestamp: timestamp},
+			expectedOutput: &data.Item{ItemKey: data.ItemKey{ID: itemID, ListID: listID}, Name: itemName, IsCompleted: false, Position: position, Version: 1, UpdatedTimestamp: timestamp, CreatedTimestamp: timestamp},
+			expectedErr:    nil,
+		},
+		{
+			name:           "The quantity, unit and category are stored with the item",
+			fields:         data.NewItem{Name: itemName, Quantity: &quantity, Unit: "kg", Category: "produce"},
+			item:           withCategory(withQuantity(createExpectedInput(itemID, listID, itemName, false, "1579773554939", timestamp), "1.5", "kg"), "produce"),
+			after:          map[string]interface{}{"Name": itemName, "IsCompleted": false, "Position": position, "Quantity": quantity, "Unit": "kg", "Category": "produce"},
+			mockOutputErr:  nil,
+			expectedOutput: &data.Item{ItemKey: data.ItemKey{ID: itemID, ListID: listID}, Name: itemName, Quantity: &quantity, Unit: "kg", Category: "produce", Position: position, Version: 1, UpdatedTimestamp: timestamp, CreatedTimestamp: timestamp},
 			expectedErr:    nil,
 		},
 		{
 			name:          "When db returns an error, that error is returned",
-			item:          createExpectedInput(itemID, listID, itemName, false, timestamp),
+			fields:        data.NewItem{Name: itemName},
+			item:          createExpectedInput(itemID, listID, itemName, false, "1579773554939", timestamp),
+			after:         map[string]interface{}{"Name": itemName, "IsCompleted": false, "Position": position},
 			mockOutputErr: errors.New("Something went wrong"),
 			expectedErr:   errors.New("Something went wrong"),
 		},
 		{
-			name:          "When DB returns condition not match error, not found error is returned",
-			item:          createExpectedInput(itemID, listID, itemName, false, timestamp),
-			mockOutputErr: awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "Bad", errors.New("Oh dear")),
+			name:          "When the ID already exists, ID exists is returned",
+			fields:        data.NewItem{Name: itemName},
+			item:          createExpectedInput(itemID, listID, itemName, false, "1579773554939", timestamp),
+			after:         map[string]interface{}{"Name": itemName, "IsCompleted": false, "Position": position},
+			mockOutputErr: transactionCanceled(),
 			expectedErr:   ErrorIDExists,
 		},
 		{
 			name:          "When DB unrecognised awserr, passon the error",
-			item:          createExpectedInput(itemID, listID, itemName, false, timestamp),
+			fields:        data.NewItem{Name: itemName},
+			item:          createExpectedInput(itemID, listID, itemName, false, "1579773554939", timestamp),
+			after:         map[string]interface{}{"Name": itemName, "IsCompleted": false, "Position": position},
 			mockOutputErr: awserr.New("uh oh", "whoops", errors.New("Oh dear")),
 			expectedErr:   awserr.New("uh oh", "whoops", errors.New("Oh dear")),
 		},
@@ -56,14 +79,28 @@ func TestCreateItem(t *testing.T) {
 			dbMocked.Test(t)
 			defer dbMocked.AssertExpectations(t)
 
-			input := dynamodb.PutItemInput{
-				Item:                tt.item,
-				TableName:           stringToPointer("items-table"),
-				ConditionExpression: stringToPointer("attribute_not_exists(Id)"),
+			input := dynamodb.TransactWriteItemsInput{
+				TransactItems: []*dynamodb.TransactWriteItem{
+					{
+						Put: &dynamodb.Put{
+							Item:                tt.item,
+							TableName:           stringToPointer("items-table"),
+							ConditionExpression: stringToPointer("attribute_not_exists(Id)"),
+						},
+					},
+					activityWrite(data.Activity{
+						ActivityKey: data.ActivityKey{ListID: listID, ID: activityID},
+						Actor:       testActor,
+						Action:      data.ActivityCreateItem,
+						ItemID:      itemID,
+						After:       tt.after,
+						Timestamp:   timestamp,
+					}),
+				},
 			}
 			dbMocked.
-				On("PutItem", &input).
-				Return(&dynamodb.PutItemOutput{}, tt.mockOutputErr).
+				On("TransactWriteItems", &input).
+				Return(&dynamodb.TransactWriteItemsOutput{}, tt.mockOutputErr).
 				Once()
 
 			d := dynamoDB{
@@ -72,7 +109,7 @@ func TestCreateItem(t *testing.T) {
 				generateID:   func() string { return itemID },
 				getTimestamp: func() string { return timestamp },
 			}
-			gotRes, gotErr := d.CreateItem(listID, itemName)
+			gotRes, gotErr := d.CreateItem(WithActor(context.Background(), testActor), listID, tt.fields)
 
 			assert.Equal(t, tt.expectedOutput, gotRes)
 			assert.Equal(t, tt.expectedErr, gotErr)
@@ -80,13 +117,26 @@ func TestCreateItem(t *testing.T) {
 	}
 }
 
-func createExpectedInput(itemID string, listID string, itemName string, isCompleted bool, timestamp string) map[string]*dynamodb.AttributeValue {
+func createExpectedInput(itemID string, listID string, itemName string, isCompleted bool, position string, timestamp string) map[string]*dynamodb.AttributeValue {
 	return map[string]*dynamodb.AttributeValue{
 		"Id":          {S: &itemID},
 		"ListId":      {S: &listID},
 		"Name":        {S: &itemName},
 		"IsCompleted": {BOOL: &isCompleted},
+		"Position":    {N: &position},
+		"Version":     {N: stringToPointer("1")},
 		"Created":     {S: &timestamp},
 		"Updated":     {S: &timestamp},
 	}
 }
+
+func withQuantity(item map[string]*dynamodb.AttributeValue, quantity string, unit string) map[string]*dynamodb.AttributeValue {
+	item["Quantity"] = &dynamodb.AttributeValue{N: &quantity}
+	item["Unit"] = &dynamodb.AttributeValue{S: &unit}
+	return item
+}
+
+func withCategory(item map[string]*dynamodb.AttributeValue, category string) map[string]*dynamodb.AttributeValue {
+	item["Category"] = &dynamodb.AttributeValue{S: &category}
+	return item
+}
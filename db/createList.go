@@ -1,14 +1,16 @@
 package db
 
 import (
+	"context"
+
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/mount-joy/thelist-lambda/data"
 )
 
-func (d *dynamoDB) CreateList(listName string) (*data.List, error) {
+// CreateList adds a list belonging to ownerID, recording its owner as the actor in the list's activity
+func (d *dynamoDB) CreateList(ctx context.Context, listName string, ownerID string) (*data.List, error) {
 	timestamp := d.getTimestamp()
 
 	list := &data.List{
@@ -16,6 +18,8 @@ func (d *dynamoDB) CreateList(listName string) (*data.List, error) {
 			ID: d.generateID(),
 		},
 		Name:             listName,
+		OwnerID:          ownerID,
+		Version:          1,
 		CreatedTimestamp: timestamp,
 		UpdatedTimestamp: timestamp,
 	}
@@ -29,23 +33,23 @@ func (d *dynamoDB) CreateList(listName string) (*data.List, error) {
 	if len(tableName) == 0 {
 		panic("Lists table name not set")
 	}
-	input := &dynamodb.PutItemInput{
-		TableName:           aws.String(tableName),
-		Item:                listToInsert,
-		ConditionExpression: aws.String("attribute_not_exists(Id)"),
-	}
-
-	_, err = d.session.PutItem(input)
 
-	switch e := err.(type) {
-	case nil:
-		break
-	case awserr.Error:
-		if e.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
-			return nil, ErrorIDExists
-		}
+	activity, err := newActivity(WithActor(ctx, ownerID), list.ID, d.generateID(), data.ActivityCreateList, "", nil, list, timestamp)
+	if err != nil {
 		return nil, err
-	default:
+	}
+
+	err = d.transactWrite(ctx, activity, &dynamodb.TransactWriteItem{
+		Put: &dynamodb.Put{
+			TableName:           aws.String(tableName),
+			Item:                listToInsert,
+			ConditionExpression: aws.String("attribute_not_exists(Id)"),
+		},
+	})
+	if err == errConditionFailed {
+		return nil, ErrorIDExists
+	}
+	if err != nil {
 		return nil, err
 	}
 
@@ -1,11 +1,10 @@
 package db
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"testing"
 
-	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/mount-joy/thelist-lambda/data"
 	"github.com/stretchr/testify/assert"
@@ -13,6 +12,7 @@ import (
 
 func TestCreateList(t *testing.T) {
 	listID := "1234"
+	ownerID := "user-1"
 	timestamp := "2020-01-23T09:59:14.9396531Z"
 
 	tests := []struct {
@@ -26,7 +26,7 @@ func TestCreateList(t *testing.T) {
 			name:           "If dynamodb passes, creates the list",
 			listName:       "my-list",
 			mockOutputErr:  nil,
-			expectedOutput: &data.List{ListKey: data.ListKey{ID: listID}, Name: "my-list", CreatedTimestamp: timestamp, UpdatedTimestamp: timestamp},
+			expectedOutput: &data.List{ListKey: data.ListKey{ID: listID}, Name: "my-list", OwnerID: ownerID, Version: 1, CreatedTimestamp: timestamp, UpdatedTimestamp: timestamp},
 			expectedErr:    nil,
 		},
 		{
@@ -39,7 +39,7 @@ func TestCreateList(t *testing.T) {
 		{
 			name:           "If there is a clash in dynamodb, return ErrorIDExists",
 			listName:       "my-list",
-			mockOutputErr:  awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "Bad", errors.New("Oh dear")),
+			mockOutputErr:  transactionCanceled(),
 			expectedOutput: nil,
 			expectedErr:    ErrorIDExists,
 		},
@@ -54,17 +54,32 @@ func TestCreateList(t *testing.T) {
 			item := map[string]*dynamodb.AttributeValue{
 				"Id":      {S: &listID},
 				"Name":    {S: &tt.listName},
+				"OwnerId": {S: &ownerID},
+				"Version": {N: stringToPointer("1")},
 				"Created": {S: &timestamp},
 				"Updated": {S: &timestamp},
 			}
-			input := dynamodb.PutItemInput{
-				Item:                item,
-				TableName:           stringToPointer("lists-table"),
-				ConditionExpression: stringToPointer("attribute_not_exists(Id)"),
+			input := dynamodb.TransactWriteItemsInput{
+				TransactItems: []*dynamodb.TransactWriteItem{
+					{
+						Put: &dynamodb.Put{
+							Item:                item,
+							TableName:           stringToPointer("lists-table"),
+							ConditionExpression: stringToPointer("attribute_not_exists(Id)"),
+						},
+					},
+					activityWrite(data.Activity{
+						ActivityKey: data.ActivityKey{ListID: listID, ID: "2020-01-23T09:59:14.939653100Z#" + listID},
+						Actor:       ownerID,
+						Action:      data.ActivityCreateList,
+						After:       map[string]interface{}{"Name": tt.listName, "OwnerId": ownerID},
+						Timestamp:   timestamp,
+					}),
+				},
 			}
 			dbMocked.
-				On("PutItem", &input).
-				Return(&dynamodb.PutItemOutput{}, tt.mockOutputErr).
+				On("TransactWriteItems", &input).
+				Return(&dynamodb.TransactWriteItemsOutput{}, tt.mockOutputErr).
 				Once()
 
 			d := dynamoDB{
@@ -74,7 +89,7 @@ func TestCreateList(t *testing.T) {
 				getTimestamp: func() string { return timestamp },
 			}
 
-			gotRes, gotErr := d.CreateList(tt.listName)
+			gotRes, gotErr := d.CreateList(context.Background(), tt.listName, ownerID)
 
 			assert.Equal(t, tt.expectedOutput, gotRes)
 			assert.Equal(t, tt.expectedErr, gotErr)
@@ -1,16 +1,49 @@
 package db
 
 import (
+	"context"
+
+	"github.com/mount-joy/thelist-lambda/config"
 	"github.com/mount-joy/thelist-lambda/data"
 )
 
 // DB - interface for talking to the database
 type DB interface {
-	CreateItem(listID string, name string) (*data.Item, error)
-	CreateList(listName string) (*data.List, error)
-	DeleteItem(string, string) error
-	GetItem(listID string, itemID string) (*data.Item, error)
-	GetItemsOnList(string) (*[]data.Item, error)
-	GetList(listID string) (*data.List, error)
-	UpdateItem(string, string, string, *bool) (*data.Item, error)
+	BatchWriteItems(ctx context.Context, listID string, operations []data.BatchOperation) ([]data.BatchResult, error)
+	CompleteIdempotentRequest(ctx context.Context, claim data.IdempotencyRecord, response data.IdempotentResponse) error
+	CreateItem(ctx context.Context, listID string, fields data.NewItem) (*data.Item, error)
+	CreateList(ctx context.Context, listName string, ownerID string) (*data.List, error)
+	DeleteCompletedItems(ctx context.Context, listID string) (int, error)
+	DeleteItem(ctx context.Context, listID string, itemID string, expectedVersion *int64) error
+	DeleteList(ctx context.Context, listID string, expectedVersion *int64) error
+	GetActivity(ctx context.Context, listID string, limit int64, startKey *data.ActivityKey) (*[]data.Activity, *data.ActivityKey, error)
+	GetItem(ctx context.Context, listID string, itemID string) (*data.Item, error)
+	GetItemChanges(ctx context.Context, listID string, since string) (*[]data.Item, error)
+	GetItemsOnList(ctx context.Context, listID string) (*[]data.Item, error)
+	GetItemsOnListPage(ctx context.Context, listID string, limit int64, startKey *data.ItemPositionKey) (*[]data.Item, *data.ItemPositionKey, error)
+	GetList(ctx context.Context, listID string) (*data.List, error)
+	GetListsForOwner(ctx context.Context, ownerID string, limit int64, startKey *data.OwnerListKey) (*[]data.List, *data.OwnerListKey, error)
+	ReleaseIdempotencyKey(ctx context.Context, claim data.IdempotencyRecord) error
+	ReorderItems(ctx context.Context, listID string, itemIDs []string) (*[]data.Item, error)
+	RestoreItem(ctx context.Context, listID string, itemID string) (*data.Item, error)
+	StartIdempotentRequest(ctx context.Context, key string, fingerprint string) (*data.IdempotencyRecord, error)
+	UpdateItem(ctx context.Context, listID string, itemID string, update data.ItemUpdate, expectedVersion *int64) (*data.Item, error)
+	UpdateList(ctx context.Context, listID string, newName string, expectedVersion *int64) (*data.List, error)
+}
+
+func createInstance() DB {
+	conf := config.GetConfiguration()
+	switch conf.Database {
+	case config.DatabaseMemory:
+		return newMemoryDB(conf.TombstoneRetention)
+	default:
+		return newDynamoDB(conf)
+	}
+}
+
+var instance DB = createInstance()
+
+// Database returns the database selected by the runtime configuration
+func Database() DB {
+	return instance
 }
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+)
+
+// DeleteCompletedItems replaces each completed item with its tombstone, recording each one in the list's activity
+func (d *dynamoDB) DeleteCompletedItems(ctx context.Context, listID string) (int, error) {
+	deleted := 0
+	err := retryConditionFailed(nil, func() error {
+		n, err := d.deleteCompletedItems(ctx, listID)
+		deleted += n
+		return err
+	})
+	return deleted, err
+}
+
+// deleteCompletedItems returns how many items it deleted, even when some of them couldn't be
+func (d *dynamoDB) deleteCompletedItems(ctx context.Context, listID string) (int, error) {
+	tableName := d.conf.TableNames.Items
+	if len(tableName) == 0 {
+		panic("Items table name not set")
+	}
+
+	items, err := d.GetItemsOnList(ctx, listID)
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := d.getTimestamp()
+	changes := []change{}
+	for _, item := range *items {
+		if !item.IsCompleted {
+			continue
+		}
+		c, err := d.deleteChange(ctx, item, timestamp)
+		if err != nil {
+			return 0, err
+		}
+		changes = append(changes, c)
+	}
+
+	deleted := 0
+	var firstErr error
+	for _, err := range d.transactChanges(ctx, changes) {
+		if err == nil {
+			deleted++
+		} else if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return deleted, firstErr
+}
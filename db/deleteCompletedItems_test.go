@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteCompletedItems(t *testing.T) {
+	listID := "474c2Fff7"
+	completed := func(id string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{
+			"ListId": {S: aws.String(listID)}, "Id": {S: aws.String(id)}, "IsCompleted": {BOOL: aws.Bool(true)}, "Version": {N: aws.String("4")},
+		}
+	}
+	notCompleted := func(id string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{
+			"ListId": {S: aws.String(listID)}, "Id": {S: aws.String(id)}, "IsCompleted": {BOOL: aws.Bool(false)}, "Version": {N: aws.String("4")},
+		}
+	}
+	timestamp := "2020-01-23T09:59:14.9396531Z"
+	deleted := func(id string) []*dynamodb.TransactWriteItem {
+		return []*dynamodb.TransactWriteItem{
+			deleteWrite(listID, id, "4", timestamp, "1579859954"),
+			activityWrite(data.Activity{
+				ActivityKey: data.ActivityKey{ListID: listID, ID: "2020-01-23T09:59:14.939653100Z#activity-id"},
+				Actor:       testActor,
+				Action:      data.ActivityDeleteItem,
+				ItemID:      id,
+				Before:      map[string]interface{}{"Name": "", "IsCompleted": true, "Position": 0.0},
+				Timestamp:   timestamp,
+			}),
+		}
+	}
+
+	tests := []struct {
+		name            string
+		mockQueryOutput *dynamodb.QueryOutput
+		mockQueryErr    error
+		transactions    [][]*dynamodb.TransactWriteItem
+		transactErrs    []error
+		expectedRes     int
+		expectedErr     error
+	}{
+		{
+			name: "Only the completed items are replaced with tombstones, and their deletions are recorded",
+			mockQueryOutput: &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{
+				completed("1c2fa0a1"), notCompleted("bb0d5e8e"), completed("f00dcafe"),
+			}},
+			transactions: [][]*dynamodb.TransactWriteItem{append(deleted("1c2fa0a1"), deleted("f00dcafe")...)},
+			transactErrs: []error{nil},
+			expectedRes:  2,
+		},
+		{
+			name: "If nothing is completed, nothing is deleted",
+			mockQueryOutput: &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{
+				notCompleted("bb0d5e8e"),
+			}},
+			expectedRes: 0,
+		},
+		{
+			name:            "When fetching the items returns an error, that error is returned",
+			mockQueryOutput: &dynamodb.QueryOutput{},
+			mockQueryErr:    errors.New("Something went wrong"),
+			expectedErr:     errors.New("Something went wrong"),
+		},
+		{
+			name: "When deleting the items returns an error, that error is returned",
+			mockQueryOutput: &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{
+				completed("1c2fa0a1"),
+			}},
+			transactions: [][]*dynamodb.TransactWriteItem{deleted("1c2fa0a1")},
+			transactErrs: []error{errors.New("Something went wrong")},
+			expectedErr:  errors.New("Something went wrong"),
+		},
+		{
+			name: "When an item changes while it's deleted, the items are read again",
+			mockQueryOutput: &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{
+				completed("1c2fa0a1"),
+			}},
+			transactions: [][]*dynamodb.TransactWriteItem{deleted("1c2fa0a1"), deleted("1c2fa0a1")},
+			transactErrs: []error{transactionCanceled(), nil},
+			expectedRes:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			queryInput := dynamodb.QueryInput{
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":id": {S: &listID}},
+				KeyConditionExpression:    aws.String("ListId = :id"),
+				FilterExpression:          aws.String("attribute_not_exists(DeletedAt)"),
+				TableName:                 aws.String("items-table"),
+			}
+			reads := len(tt.transactions)
+			if reads == 0 {
+				reads = 1
+			}
+			dbMocked.
+				On("Query", &queryInput).
+				Return(tt.mockQueryOutput, tt.mockQueryErr).
+				Times(reads)
+
+			for i, transaction := range tt.transactions {
+				dbMocked.
+					On("TransactWriteItems", &dynamodb.TransactWriteItemsInput{TransactItems: transaction}).
+					Return(&dynamodb.TransactWriteItemsOutput{}, tt.transactErrs[i]).
+					Once()
+			}
+
+			d := dynamoDB{
+				session:      dbMocked,
+				conf:         testConfig,
+				generateID:   func() string { return "activity-id" },
+				getTimestamp: func() string { return timestamp },
+			}
+			gotRes, gotErr := d.DeleteCompletedItems(WithActor(context.Background(), testActor), listID)
+
+			assert.Equal(t, tt.expectedErr, gotErr)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
@@ -1,37 +1,67 @@
 package db
 
 import (
+	"context"
+	"strconv"
+
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mount-joy/thelist-lambda/data"
 )
 
-func (d *dynamoDB) DeleteItem(listID string, itemID string) error {
+// DeleteItem leaves a tombstone in place of the item, so the deletion shows up in the changes feed
+// The item can be restored until the tombstone expires and DynamoDB's TTL purges it
+func (d *dynamoDB) DeleteItem(ctx context.Context, listID string, itemID string, expectedVersion *int64) error {
+	return retryConditionFailed(expectedVersion, func() error {
+		return d.deleteItem(ctx, listID, itemID, expectedVersion)
+	})
+}
+
+func (d *dynamoDB) deleteItem(ctx context.Context, listID string, itemID string, expectedVersion *int64) error {
 	tableName := d.conf.TableNames.Items
 	if len(tableName) == 0 {
 		panic("Items table name not set")
 	}
 
-	input := &dynamodb.DeleteItemInput{
-		Key: map[string]*dynamodb.AttributeValue{
-			"ListId": {S: &listID},
-			"Id":     {S: &itemID},
-		},
-		TableName: aws.String(tableName),
+	item, err := d.getItemToChange(ctx, listID, itemID, expectedVersion)
+	// Deleting is idempotent unless the caller asked for a particular version to be deleted
+	if err == ErrorNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
-	_, err := d.session.DeleteItem(input)
-
-	switch e := err.(type) {
-	case nil:
-		break
-	case awserr.Error:
-		if e.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
-			return nil
-		}
-	default:
+	change, err := d.deleteChange(ctx, *item, d.getTimestamp())
+	if err != nil {
 		return err
 	}
+	return d.transactWrite(ctx, change.activity, change.write)
+}
 
-	return nil
+// deleteChange returns the change which replaces item with its tombstone, as long as item hasn't changed since it was read
+func (d *dynamoDB) deleteChange(ctx context.Context, item data.Item, timestamp string) (change, error) {
+	activity, err := newActivity(ctx, item.ListID, d.generateID(), data.ActivityDeleteItem, item.ID, &item, nil, timestamp)
+	if err != nil {
+		return change{}, err
+	}
+
+	expires := strconv.FormatInt(expiresAt(timestamp, d.conf.TombstoneRetention), 10)
+	condition, conditionValues := itemCondition(&item.Version)
+	return change{activity: activity, write: &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			Key: map[string]*dynamodb.AttributeValue{
+				"ListId": {S: aws.String(item.ListID)},
+				"Id":     {S: aws.String(item.ID)},
+			},
+			TableName:        aws.String(d.conf.TableNames.Items),
+			UpdateExpression: aws.String("SET DeletedAt = :t, Updated = :t, ExpiresAt = :expires ADD Version :one"),
+			ExpressionAttributeValues: mergeValues(map[string]*dynamodb.AttributeValue{
+				":t":       {S: &timestamp},
+				":expires": {N: &expires},
+				":one":     {N: aws.String("1")},
+			}, conditionValues),
+			ConditionExpression: condition,
+		},
+	}}, nil
 }
@@ -1,37 +1,89 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestDeleteItem(t *testing.T) {
 	listID := "474c2Fff7"
 	itemID := "b6cf642d"
+	version := int64(3)
+	otherVersion := int64(2)
+	timestamp := "2020-01-23T09:59:14.9396531Z"
+	existing := &data.Item{ItemKey: data.ItemKey{ID: itemID, ListID: listID}, Name: "Milk", Position: 5, Version: version}
 	tests := []struct {
 		name            string
-		mockResponseErr error
+		expectedVersion *int64
+		existing        *data.Item
+		transactErrs    []error
 		expectedErr     error
 	}{
 		{
-			name:            "If the item exists it is deleted",
-			mockResponseErr: nil,
-			expectedErr:     nil,
+			name:         "If the item exists it is replaced with a tombstone and the deletion is recorded",
+			existing:     existing,
+			transactErrs: []error{nil},
+			expectedErr:  nil,
+		},
+		{
+			name:         "When db returns an error, that error is returned",
+			existing:     existing,
+			transactErrs: []error{errors.New("Something went wrong")},
+			expectedErr:  errors.New("Something went wrong"),
+		},
+		{
+			name:         "When db returns an aws error, that error is returned",
+			existing:     existing,
+			transactErrs: []error{awserr.New("ValidationException", "Bad", errors.New("Oh dear"))},
+			expectedErr:  awserr.New("ValidationException", "Bad", errors.New("Oh dear")),
 		},
 		{
-			name:            "When db returns an error, that error is returned",
-			mockResponseErr: errors.New("Something went wrong"),
-			expectedErr:     errors.New("Something went wrong"),
+			name:        "When the item doesn't exist, then succeeds",
+			expectedErr: nil,
 		},
 		{
-			name:            "When Query returns condition not match error, then succeeds",
-			mockResponseErr: awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "Bad", errors.New("Oh dear")),
+			name:        "When the item is already deleted, then succeeds",
+			existing:    &data.Item{ItemKey: existing.ItemKey, Name: "Milk", Version: version, DeletedTimestamp: timestamp},
+			expectedErr: nil,
+		},
+		{
+			name:         "When the item changes after it's read, it is read again",
+			existing:     existing,
+			transactErrs: []error{transactionCanceled(), nil},
+			expectedErr:  nil,
+		},
+		{
+			name:            "If the item is at the expected version it is deleted",
+			expectedVersion: &version,
+			existing:        existing,
+			transactErrs:    []error{nil},
 			expectedErr:     nil,
 		},
+		{
+			name:            "If the item is not at the expected version, precondition failed is returned",
+			expectedVersion: &otherVersion,
+			existing:        existing,
+			expectedErr:     ErrorPreconditionFailed,
+		},
+		{
+			name:            "If the item changes after it's read when a version is expected, precondition failed is returned",
+			expectedVersion: &version,
+			existing:        existing,
+			transactErrs:    []error{transactionCanceled()},
+			expectedErr:     ErrorPreconditionFailed,
+		},
+		{
+			name:            "If the item doesn't exist when a version is expected, precondition failed is returned",
+			expectedVersion: &version,
+			expectedErr:     ErrorPreconditionFailed,
+		},
 	}
 
 	for _, tt := range tests {
@@ -40,20 +92,63 @@ func TestDeleteItem(t *testing.T) {
 			dbMocked.Test(t)
 			defer dbMocked.AssertExpectations(t)
 
-			input := dynamodb.DeleteItemInput{
-				Key: map[string]*dynamodb.AttributeValue{
-					"Id":     {S: &itemID},
-					"ListId": {S: &listID},
-				},
-				TableName: stringToPointer("items-table"),
+			key := map[string]*dynamodb.AttributeValue{
+				"Id":     {S: &itemID},
+				"ListId": {S: &listID},
+			}
+			getOutput := &dynamodb.GetItemOutput{}
+			if tt.existing != nil {
+				getOutput.Item, _ = dynamodbattribute.MarshalMap(tt.existing)
+			}
+			reads := len(tt.transactErrs)
+			if reads == 0 {
+				reads = 1
 			}
 			dbMocked.
-				On("DeleteItem", &input).
-				Return(&dynamodb.DeleteItemOutput{}, tt.mockResponseErr).
-				Once()
+				On("GetItem", &dynamodb.GetItemInput{Key: key, TableName: stringToPointer("items-table"), ConsistentRead: boolToPointer(true)}).
+				Return(getOutput, nil).
+				Times(reads)
 
-			d := dynamoDB{session: dbMocked, conf: testConfig}
-			gotErr := d.DeleteItem(listID, itemID)
+			for _, transactErr := range tt.transactErrs {
+				input := dynamodb.TransactWriteItemsInput{
+					TransactItems: []*dynamodb.TransactWriteItem{
+						{
+							Update: &dynamodb.Update{
+								Key:              key,
+								TableName:        stringToPointer("items-table"),
+								UpdateExpression: stringToPointer("SET DeletedAt = :t, Updated = :t, ExpiresAt = :expires ADD Version :one"),
+								ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+									":t":       {S: &timestamp},
+									":expires": {N: stringToPointer("1579859954")},
+									":one":     {N: stringToPointer("1")},
+									":v":       {N: stringToPointer("3")},
+								},
+								ConditionExpression: stringToPointer("attribute_exists(Id) AND Version = :v AND attribute_not_exists(DeletedAt)"),
+							},
+						},
+						activityWrite(data.Activity{
+							ActivityKey: data.ActivityKey{ListID: listID, ID: "2020-01-23T09:59:14.939653100Z#activity-id"},
+							Actor:       testActor,
+							Action:      data.ActivityDeleteItem,
+							ItemID:      itemID,
+							Before:      map[string]interface{}{"Name": "Milk", "IsCompleted": false, "Position": 5.0},
+							Timestamp:   timestamp,
+						}),
+					},
+				}
+				dbMocked.
+					On("TransactWriteItems", &input).
+					Return(&dynamodb.TransactWriteItemsOutput{}, transactErr).
+					Once()
+			}
+
+			d := dynamoDB{
+				session:      dbMocked,
+				conf:         testConfig,
+				generateID:   func() string { return "activity-id" },
+				getTimestamp: func() string { return timestamp },
+			}
+			gotErr := d.DeleteItem(WithActor(context.Background(), testActor), listID, itemID, tt.expectedVersion)
 
 			assert.Equal(t, tt.expectedErr, gotErr)
 		})
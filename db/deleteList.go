@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// DeleteList removes every item on the list and then the list itself, recording it in the list's activity
+func (d *dynamoDB) DeleteList(ctx context.Context, listID string, expectedVersion *int64) error {
+	return retryConditionFailed(expectedVersion, func() error {
+		return d.deleteList(ctx, listID, expectedVersion)
+	})
+}
+
+func (d *dynamoDB) deleteList(ctx context.Context, listID string, expectedVersion *int64) error {
+	tableName := d.conf.TableNames.Lists
+	if len(tableName) == 0 {
+		panic("Lists table name not set")
+	}
+
+	list, err := d.getListToChange(ctx, listID, expectedVersion)
+	if err != nil {
+		return err
+	}
+
+	// The items go first, so if removing them fails the list is still there to be deleted again
+	err = d.deleteItemsOnList(ctx, listID)
+	if err != nil {
+		return err
+	}
+
+	activity, err := newActivity(ctx, listID, d.generateID(), data.ActivityDeleteList, "", list, nil, d.getTimestamp())
+	if err != nil {
+		return err
+	}
+
+	condition, conditionValues := versionCondition(&list.Version)
+	return d.transactWrite(ctx, activity, &dynamodb.TransactWriteItem{
+		Delete: &dynamodb.Delete{
+			Key: map[string]*dynamodb.AttributeValue{
+				"Id": {S: &listID},
+			},
+			TableName:                 aws.String(tableName),
+			ConditionExpression:       condition,
+			ExpressionAttributeValues: conditionValues,
+		},
+	})
+}
+
+// deleteItemsOnList removes every item on the list for good, tombstones included
+func (d *dynamoDB) deleteItemsOnList(ctx context.Context, listID string) error {
+	items, err := d.queryItems(ctx, listID, true)
+	if err != nil {
+		return err
+	}
+
+	requests := make([]*dynamodb.WriteRequest, 0, len(items))
+	for _, item := range items {
+		key, err := dynamodbattribute.MarshalMap(item.ItemKey)
+		if err != nil {
+			return err
+		}
+		requests = append(requests, &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{Key: key}})
+	}
+
+	return d.batchWrite(ctx, d.conf.TableNames.Items, requests)
+}
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteList(t *testing.T) {
+	listID := "474c2Fff7"
+	timestamp := "2020-01-23T09:59:14.9396531Z"
+	version := int64(2)
+	otherVersion := int64(1)
+	existing := &data.List{ListKey: data.ListKey{ID: listID}, Name: "Shopping", OwnerID: "user-1", Version: version}
+	tests := []struct {
+		name             string
+		expectedVersion  *int64
+		existing         *data.List
+		getErr           error
+		transactErrs     []error
+		mockQueryOutput  *dynamodb.QueryOutput
+		mockQueryErr     error
+		expectBatchWrite bool
+		mockBatchErr     error
+		expectedErr      error
+	}{
+		{
+			name:            "If the list exists and has no items, only the list is deleted",
+			existing:        existing,
+			transactErrs:    []error{nil},
+			mockQueryOutput: &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{}},
+			expectedErr:     nil,
+		},
+		{
+			name:         "If the list has items, they are deleted too",
+			existing:     existing,
+			transactErrs: []error{nil},
+			mockQueryOutput: &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{
+				{"ListId": {S: aws.String(listID)}, "Id": {S: aws.String("1c2fa0a1")}},
+				{"ListId": {S: aws.String(listID)}, "Id": {S: aws.String("bb0d5e8e")}},
+			}},
+			expectBatchWrite: true,
+			expectedErr:      nil,
+		},
+		{
+			name:        "If the list doesn't exist, not found error is returned",
+			expectedErr: ErrorNotFound,
+		},
+		{
+			name:        "When reading the list returns an error, that error is returned",
+			getErr:      errors.New("Something went wrong"),
+			expectedErr: errors.New("Something went wrong"),
+		},
+		{
+			name:            "When deleting the list returns an error, that error is returned",
+			existing:        existing,
+			transactErrs:    []error{errors.New("Something went wrong")},
+			mockQueryOutput: &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{}},
+			expectedErr:     errors.New("Something went wrong"),
+		},
+		{
+			name:            "If the list is at the expected version it is deleted",
+			expectedVersion: &version,
+			existing:        existing,
+			transactErrs:    []error{nil},
+			mockQueryOutput: &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{}},
+			expectedErr:     nil,
+		},
+		{
+			name:            "If the list isn't at the expected version, PreconditionFailed is returned",
+			expectedVersion: &otherVersion,
+			existing:        existing,
+			expectedErr:     ErrorPreconditionFailed,
+		},
+		{
+			name:            "If the list changes after it's read, it and its items are read again",
+			existing:        existing,
+			transactErrs:    []error{transactionCanceled(), nil},
+			mockQueryOutput: &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{}},
+			expectedErr:     nil,
+		},
+		{
+			name:            "If the list changes after it's read when a version is expected, PreconditionFailed is returned",
+			expectedVersion: &version,
+			existing:        existing,
+			transactErrs:    []error{transactionCanceled()},
+			mockQueryOutput: &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{}},
+			expectedErr:     ErrorPreconditionFailed,
+		},
+		{
+			name:            "When fetching the items returns an error, that error is returned and the list is kept",
+			existing:        existing,
+			mockQueryOutput: &dynamodb.QueryOutput{},
+			mockQueryErr:    errors.New("Something went wrong"),
+			expectedErr:     errors.New("Something went wrong"),
+		},
+		{
+			name: "When deleting the items returns an error, that error is returned and the list is kept",
+			mockQueryOutput: &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{
+				{"ListId": {S: aws.String(listID)}, "Id": {S: aws.String("1c2fa0a1")}},
+			}},
+			existing:         existing,
+			expectBatchWrite: true,
+			mockBatchErr:     errors.New("Something went wrong"),
+			expectedErr:      errors.New("Something went wrong"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			key := map[string]*dynamodb.AttributeValue{"Id": {S: &listID}}
+			getOutput := &dynamodb.GetItemOutput{}
+			if tt.existing != nil {
+				getOutput.Item, _ = dynamodbattribute.MarshalMap(tt.existing)
+			}
+			reads := len(tt.transactErrs)
+			if reads == 0 {
+				reads = 1
+			}
+			dbMocked.
+				On("GetItem", &dynamodb.GetItemInput{Key: key, TableName: stringToPointer("lists-table"), ConsistentRead: boolToPointer(true)}).
+				Return(getOutput, tt.getErr).
+				Times(reads)
+
+			for _, transactErr := range tt.transactErrs {
+				input := dynamodb.TransactWriteItemsInput{
+					TransactItems: []*dynamodb.TransactWriteItem{
+						{
+							Delete: &dynamodb.Delete{
+								Key:                       key,
+								TableName:                 stringToPointer("lists-table"),
+								ConditionExpression:       stringToPointer("attribute_exists(Id) AND Version = :v"),
+								ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":v": {N: stringToPointer("2")}},
+							},
+						},
+						activityWrite(data.Activity{
+							ActivityKey: data.ActivityKey{ListID: listID, ID: "2020-01-23T09:59:14.939653100Z#activity-id"},
+							Actor:       testActor,
+							Action:      data.ActivityDeleteList,
+							Before:      map[string]interface{}{"Name": "Shopping", "OwnerId": "user-1"},
+							Timestamp:   timestamp,
+						}),
+					},
+				}
+				dbMocked.
+					On("TransactWriteItems", &input).
+					Return(&dynamodb.TransactWriteItemsOutput{}, transactErr).
+					Once()
+			}
+
+			if tt.mockQueryOutput != nil {
+				queryInput := dynamodb.QueryInput{
+					ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":id": {S: &listID}},
+					KeyConditionExpression:    aws.String("ListId = :id"),
+					TableName:                 aws.String("items-table"),
+				}
+				dbMocked.
+					On("Query", &queryInput).
+					Return(tt.mockQueryOutput, tt.mockQueryErr).
+					Times(reads)
+			}
+
+			if tt.expectBatchWrite {
+				requests := []*dynamodb.WriteRequest{}
+				for _, item := range tt.mockQueryOutput.Items {
+					requests = append(requests, &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{Key: item}})
+				}
+				batchInput := dynamodb.BatchWriteItemInput{
+					RequestItems: map[string][]*dynamodb.WriteRequest{"items-table": requests},
+				}
+				dbMocked.
+					On("BatchWriteItem", &batchInput).
+					Return(&dynamodb.BatchWriteItemOutput{}, tt.mockBatchErr).
+					Once()
+			}
+
+			d := dynamoDB{
+				session:      dbMocked,
+				conf:         testConfig,
+				generateID:   func() string { return "activity-id" },
+				getTimestamp: func() string { return timestamp },
+			}
+			gotErr := d.DeleteList(WithActor(context.Background(), testActor), listID, tt.expectedVersion)
+
+			assert.Equal(t, tt.expectedErr, gotErr)
+		})
+	}
+}
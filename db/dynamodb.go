@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -15,26 +16,23 @@ type dynamoDB struct {
 	conf         config.Config
 	generateID   func() string
 	getTimestamp func() string
+	sleep        func(time.Duration)
 }
 
-func createInstance() DB {
-	conf := config.GetConfiguration()
+func newDynamoDB(conf config.Config) DB {
 	config := aws.Config{Endpoint: aws.String(conf.Endpoint)}
 	session, err := session.NewSession(&config)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to create dynamodb session: %s", err.Error()))
 	}
+	client := dynamodb.New(session)
+	instrument(&client.Handlers)
+	traceCalls(&client.Handlers)
 	return &dynamoDB{
-		session:      dynamodb.New(session),
+		session:      client,
 		conf:         conf,
 		generateID:   func() string { return generateID() },
 		getTimestamp: func() string { return getTimestamp() },
+		sleep:        time.Sleep,
 	}
 }
-
-var instance DB = createInstance()
-
-// DynamoDB returns a databse session using dynamodb
-func DynamoDB() DB {
-	return instance
-}
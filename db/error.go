@@ -10,3 +10,6 @@ var ErrorBadRequest = errors.New("Bad Request")
 
 // ErrorIDExists is the error returned when an item could not created because it already exists
 var ErrorIDExists = errors.New("ID Already Exists")
+
+// ErrorPreconditionFailed is the error returned when a record is no longer at the version the caller expected
+var ErrorPreconditionFailed = errors.New("Precondition Failed")
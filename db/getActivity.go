@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// GetActivity returns up to limit of the list's activities, newest first, continuing from startKey if it's set
+// The key of the last activity is returned when there may be more
+func (d *dynamoDB) GetActivity(ctx context.Context, listID string, limit int64, startKey *data.ActivityKey) (*[]data.Activity, *data.ActivityKey, error) {
+	tableName := d.conf.TableNames.Activity
+	if len(tableName) == 0 {
+		panic("Activity table name not set")
+	}
+
+	input := &dynamodb.QueryInput{
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":id": {S: &listID},
+		},
+		KeyConditionExpression: aws.String("ListId = :id"),
+		ScanIndexForward:       aws.Bool(false),
+		TableName:              aws.String(tableName),
+	}
+	if limit > 0 {
+		input.Limit = aws.Int64(limit)
+	}
+	if startKey != nil {
+		key, err := dynamodbattribute.MarshalMap(startKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		input.ExclusiveStartKey = key
+	}
+
+	result, err := d.session.QueryWithContext(ctx, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if result == nil || result.Items == nil {
+		return nil, nil, errors.New("Failed to fetch activity")
+	}
+
+	activities := []data.Activity{}
+	for _, i := range result.Items {
+		activity := new(data.Activity)
+		err = dynamodbattribute.UnmarshalMap(i, &activity)
+		if err != nil {
+			return nil, nil, err
+		}
+		activities = append(activities, *activity)
+	}
+
+	if len(result.LastEvaluatedKey) == 0 {
+		return &activities, nil, nil
+	}
+
+	nextKey := new(data.ActivityKey)
+	err = dynamodbattribute.UnmarshalMap(result.LastEvaluatedKey, nextKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &activities, nextKey, nil
+}
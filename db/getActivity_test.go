@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetActivity(t *testing.T) {
+	listID := "474c2Fff7"
+	activityID := "2020-01-23T09:59:14.939653100Z#1c2fa0a1"
+	tests := []struct {
+		name            string
+		limit           int64
+		startKey        *data.ActivityKey
+		expectedLimit   *int64
+		expectedStart   map[string]*dynamodb.AttributeValue
+		output          *dynamodb.QueryOutput
+		outputErr       error
+		expectedRes     *[]data.Activity
+		expectedNextKey *data.ActivityKey
+		expectedErr     error
+	}{
+		{
+			name:        "When there is no limit or start key, the first page is returned",
+			output:      &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{}},
+			expectedRes: &[]data.Activity{},
+		},
+		{
+			name:          "When there are more activities, the key to continue from is returned",
+			limit:         1,
+			expectedLimit: aws.Int64(1),
+			output: &dynamodb.QueryOutput{
+				Items: []map[string]*dynamodb.AttributeValue{
+					{
+						"ListId": {S: aws.String(listID)},
+						"Id":     {S: aws.String(activityID)},
+						"Actor":  {S: aws.String("user-1")},
+						"Action": {S: aws.String(data.ActivityUpdateItem)},
+						"ItemId": {S: aws.String("bb0d5e8e")},
+						"Before": {M: map[string]*dynamodb.AttributeValue{"Name": {S: aws.String("Apples")}}},
+						"After":  {M: map[string]*dynamodb.AttributeValue{"Name": {S: aws.String("Oranges")}}},
+					},
+				},
+				LastEvaluatedKey: map[string]*dynamodb.AttributeValue{
+					"ListId": {S: aws.String(listID)},
+					"Id":     {S: aws.String(activityID)},
+				},
+			},
+			expectedRes: &[]data.Activity{{
+				ActivityKey: data.ActivityKey{ListID: listID, ID: activityID},
+				Actor:       "user-1",
+				Action:      data.ActivityUpdateItem,
+				ItemID:      "bb0d5e8e",
+				Before:      map[string]interface{}{"Name": "Apples"},
+				After:       map[string]interface{}{"Name": "Oranges"},
+			}},
+			expectedNextKey: &data.ActivityKey{ListID: listID, ID: activityID},
+		},
+		{
+			name:          "When a start key is given, the query continues from it",
+			limit:         1,
+			startKey:      &data.ActivityKey{ListID: listID, ID: activityID},
+			expectedLimit: aws.Int64(1),
+			expectedStart: map[string]*dynamodb.AttributeValue{
+				"ListId": {S: aws.String(listID)},
+				"Id":     {S: aws.String(activityID)},
+			},
+			output:      &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{}},
+			expectedRes: &[]data.Activity{},
+		},
+		{
+			name:        "When Query returns an error, that error is returned",
+			output:      &dynamodb.QueryOutput{},
+			outputErr:   errors.New("Something went wrong"),
+			expectedErr: errors.New("Something went wrong"),
+		},
+		{
+			name:        "When Query returns an nil, an error is returned",
+			output:      nil,
+			expectedErr: errors.New("Failed to fetch activity"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			input := dynamodb.QueryInput{
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":id": {S: &listID}},
+				KeyConditionExpression:    aws.String("ListId = :id"),
+				ScanIndexForward:          aws.Bool(false),
+				TableName:                 aws.String("activity-table"),
+				Limit:                     tt.expectedLimit,
+				ExclusiveStartKey:         tt.expectedStart,
+			}
+			dbMocked.
+				On("Query", &input).
+				Return(tt.output, tt.outputErr).
+				Once()
+
+			d := dynamoDB{session: dbMocked, conf: testConfig}
+
+			gotRes, gotNextKey, gotErr := d.GetActivity(context.Background(), listID, tt.limit, tt.startKey)
+
+			assert.Equal(t, tt.expectedErr, gotErr)
+			assert.Equal(t, tt.expectedRes, gotRes)
+			assert.Equal(t, tt.expectedNextKey, gotNextKey)
+		})
+	}
+}
@@ -1,13 +1,27 @@
 package db
 
 import (
+	"context"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/mount-joy/thelist-lambda/data"
 )
 
-func (d *dynamoDB) GetItem(listID string, itemID string) (*data.Item, error) {
+func (d *dynamoDB) GetItem(ctx context.Context, listID string, itemID string) (*data.Item, error) {
+	item, err := d.getItem(ctx, listID, itemID, false)
+	if err != nil {
+		return nil, err
+	}
+	if item.DeletedTimestamp != "" {
+		return nil, ErrorNotFound
+	}
+	return item, nil
+}
+
+// getItem reads an item even if it's a tombstone, a consistent read is used before changing it
+func (d *dynamoDB) getItem(ctx context.Context, listID string, itemID string, consistent bool) (*data.Item, error) {
 	tableName := d.conf.TableNames.Items
 	if len(tableName) == 0 {
 		panic("Items table name not set")
@@ -20,14 +34,23 @@ func (d *dynamoDB) GetItem(listID string, itemID string) (*data.Item, error) {
 		},
 		TableName: aws.String(tableName),
 	}
+	if consistent {
+		input.ConsistentRead = aws.Bool(true)
+	}
 
-	res, err := d.session.GetItem(input)
+	res, err := d.session.GetItemWithContext(ctx, input)
 
 	if err != nil {
 		return nil, err
 	}
+	if len(res.Item) == 0 {
+		return nil, ErrorNotFound
+	}
 
 	item := new(data.Item)
 	err = dynamodbattribute.UnmarshalMap(res.Item, &item)
-	return item, err
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
 }
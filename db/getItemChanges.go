@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// listUpdatedIndexName is the global secondary index on the items table keyed by ListId and sorted by Updated
+const listUpdatedIndexName = "ListUpdatedIndex"
+
+// GetItemChanges returns the items on a list, tombstones included, which were updated after since, oldest first
+// Every item on the list is returned when since is empty
+func (d *dynamoDB) GetItemChanges(ctx context.Context, listID string, since string) (*[]data.Item, error) {
+	tableName := d.conf.TableNames.Items
+	if len(tableName) == 0 {
+		panic("Items table name not set")
+	}
+
+	values := map[string]*dynamodb.AttributeValue{
+		":id": {S: &listID},
+	}
+	keyCondition := "ListId = :id"
+	if since != "" {
+		values[":since"] = &dynamodb.AttributeValue{S: &since}
+		keyCondition += " AND Updated > :since"
+	}
+
+	items := []data.Item{}
+	var startKey map[string]*dynamodb.AttributeValue
+	for {
+		input := &dynamodb.QueryInput{
+			ExpressionAttributeValues: values,
+			KeyConditionExpression:    aws.String(keyCondition),
+			IndexName:                 aws.String(listUpdatedIndexName),
+			TableName:                 aws.String(tableName),
+			ExclusiveStartKey:         startKey,
+		}
+
+		result, err := d.session.QueryWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil || result.Items == nil {
+			return nil, errors.New("Failed to fetch items")
+		}
+
+		for _, i := range result.Items {
+			item := new(data.Item)
+			err = dynamodbattribute.UnmarshalMap(i, &item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, *item)
+		}
+
+		// Results are split into pages of at most 1MB, keep going until there are no more
+		startKey = result.LastEvaluatedKey
+		if len(startKey) == 0 {
+			break
+		}
+	}
+
+	return &items, nil
+}
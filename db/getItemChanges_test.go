@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetItemChanges(t *testing.T) {
+	listID := "474c2Fff7"
+	since := "2020-01-23T09:59:14.9396531Z"
+	lastKey := map[string]*dynamodb.AttributeValue{
+		"ListId":  {S: aws.String(listID)},
+		"Id":      {S: aws.String("1c2fa0a1")},
+		"Updated": {S: aws.String("2020-01-23T10:00:00Z")},
+	}
+
+	tests := []struct {
+		name           string
+		since          string
+		expectedValues map[string]*dynamodb.AttributeValue
+		expectedKey    string
+		outputs        []*dynamodb.QueryOutput
+		outputErr      error
+		expectedRes    *[]data.Item
+		expectedErr    error
+	}{
+		{
+			name:  "Returns the items updated since the timestamp, tombstones included, following pages",
+			since: since,
+			expectedValues: map[string]*dynamodb.AttributeValue{
+				":id":    {S: &listID},
+				":since": {S: &since},
+			},
+			expectedKey: "ListId = :id AND Updated > :since",
+			outputs: []*dynamodb.QueryOutput{
+				{
+					Items: []map[string]*dynamodb.AttributeValue{
+						{"ListId": {S: aws.String(listID)}, "Id": {S: aws.String("1c2fa0a1")}, "Name": {S: aws.String("Oranges")}, "Updated": {S: aws.String("2020-01-23T10:00:00Z")}},
+					},
+					LastEvaluatedKey: lastKey,
+				},
+				{
+					Items: []map[string]*dynamodb.AttributeValue{
+						{"ListId": {S: aws.String(listID)}, "Id": {S: aws.String("bb0d5e8e")}, "Name": {S: aws.String("Apples")}, "Updated": {S: aws.String("2020-01-23T10:00:01Z")}, "DeletedAt": {S: aws.String("2020-01-23T10:00:01Z")}},
+					},
+				},
+			},
+			expectedRes: &[]data.Item{
+				{ItemKey: data.ItemKey{ID: "1c2fa0a1", ListID: listID}, Name: "Oranges", UpdatedTimestamp: "2020-01-23T10:00:00Z"},
+				{ItemKey: data.ItemKey{ID: "bb0d5e8e", ListID: listID}, Name: "Apples", UpdatedTimestamp: "2020-01-23T10:00:01Z", DeletedTimestamp: "2020-01-23T10:00:01Z"},
+			},
+		},
+		{
+			name:           "Returns every item when there is no timestamp",
+			expectedValues: map[string]*dynamodb.AttributeValue{":id": {S: &listID}},
+			expectedKey:    "ListId = :id",
+			outputs:        []*dynamodb.QueryOutput{{Items: []map[string]*dynamodb.AttributeValue{}}},
+			expectedRes:    &[]data.Item{},
+		},
+		{
+			name:           "When Query returns an error, that error is returned",
+			expectedValues: map[string]*dynamodb.AttributeValue{":id": {S: &listID}},
+			expectedKey:    "ListId = :id",
+			outputs:        []*dynamodb.QueryOutput{{}},
+			outputErr:      errors.New("Something went wrong"),
+			expectedErr:    errors.New("Something went wrong"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			var startKey map[string]*dynamodb.AttributeValue
+			for _, output := range tt.outputs {
+				dbMocked.
+					On("Query", &dynamodb.QueryInput{
+						ExpressionAttributeValues: tt.expectedValues,
+						KeyConditionExpression:    aws.String(tt.expectedKey),
+						IndexName:                 aws.String("ListUpdatedIndex"),
+						TableName:                 aws.String("items-table"),
+						ExclusiveStartKey:         startKey,
+					}).
+					Return(output, tt.outputErr).
+					Once()
+				startKey = output.LastEvaluatedKey
+			}
+
+			d := dynamoDB{session: dbMocked, conf: testConfig}
+			gotRes, gotErr := d.GetItemChanges(context.Background(), listID, tt.since)
+
+			assert.Equal(t, tt.expectedErr, gotErr)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -33,6 +34,27 @@ func TestGetItem(t *testing.T) {
 			expectedRes: &data.Item{ItemKey: data.ItemKey{ID: itemID, ListID: listID}, Name: name},
 			expectedErr: nil,
 		},
+		{
+			name:          "If the item doesn't exist ErrorNotFound is returned",
+			mockOutputErr: nil,
+			mockOutput:    &dynamodb.GetItemOutput{},
+			expectedRes:   nil,
+			expectedErr:   ErrorNotFound,
+		},
+		{
+			name:          "If the item has been deleted ErrorNotFound is returned",
+			mockOutputErr: nil,
+			mockOutput: &dynamodb.GetItemOutput{
+				Item: map[string]*dynamodb.AttributeValue{
+					"Id":        {S: &itemID},
+					"ListId":    {S: &listID},
+					"Name":      {S: &name},
+					"DeletedAt": {S: stringToPointer("2020-01-23T09:59:14.9396531Z")},
+				},
+			},
+			expectedRes: nil,
+			expectedErr: ErrorNotFound,
+		},
 		{
 			name:          "When db returns an error, that error is returned",
 			mockOutputErr: errors.New("Something went wrong"),
@@ -61,7 +83,7 @@ func TestGetItem(t *testing.T) {
 				Once()
 
 			d := dynamoDB{session: dbMocked, conf: testConfig}
-			gotRes, gotErr := d.GetItem(listID, itemID)
+			gotRes, gotErr := d.GetItem(context.Background(), listID, itemID)
 
 			assert.Equal(t, tt.expectedErr, gotErr)
 			assert.Equal(t, tt.expectedRes, gotRes)
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"errors"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -9,37 +10,64 @@ import (
 	"github.com/mount-joy/thelist-lambda/data"
 )
 
-func (d *dynamoDB) GetItemsOnList(listID string) (*[]data.Item, error) {
+func (d *dynamoDB) GetItemsOnList(ctx context.Context, listID string) (*[]data.Item, error) {
+	items, err := d.queryItems(ctx, listID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	sortByPosition(items)
+	return &items, nil
+}
+
+// queryItems returns every item on a list, along with the tombstones of deleted items when withTombstones is set
+func (d *dynamoDB) queryItems(ctx context.Context, listID string, withTombstones bool) ([]data.Item, error) {
 	tableName := d.conf.TableNames.Items
 	if len(tableName) == 0 {
 		panic("Items table name not set")
 	}
 
-	input := &dynamodb.QueryInput{
-		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
-			":id": {S: &listID},
-		},
-		KeyConditionExpression: aws.String("ListId = :id"),
-		TableName:              aws.String(tableName),
-	}
-
-	result, err := d.session.Query(input)
-	if err != nil {
-		return nil, err
-	}
-	if result == nil || result.Items == nil {
-		return nil, errors.New("Failed to fetch items")
+	var filter *string
+	if !withTombstones {
+		filter = aws.String(notDeleted)
 	}
 
 	items := []data.Item{}
-	for _, i := range result.Items {
-		item := new(data.Item)
-		err = dynamodbattribute.UnmarshalMap(i, &item)
+	var startKey map[string]*dynamodb.AttributeValue
+	for {
+		input := &dynamodb.QueryInput{
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":id": {S: &listID},
+			},
+			KeyConditionExpression: aws.String("ListId = :id"),
+			FilterExpression:       filter,
+			TableName:              aws.String(tableName),
+			ExclusiveStartKey:      startKey,
+		}
+
+		result, err := d.session.QueryWithContext(ctx, input)
 		if err != nil {
 			return nil, err
 		}
-		items = append(items, *item)
+		if result == nil || result.Items == nil {
+			return nil, errors.New("Failed to fetch items")
+		}
+
+		for _, i := range result.Items {
+			item := new(data.Item)
+			err = dynamodbattribute.UnmarshalMap(i, &item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, *item)
+		}
+
+		// Results are split into pages of at most 1MB, keep going until there are no more
+		startKey = result.LastEvaluatedKey
+		if len(startKey) == 0 {
+			break
+		}
 	}
 
-	return &items, nil
+	return items, nil
 }
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// listPositionIndexName is the global secondary index on the items table keyed by ListId and sorted by Position
+const listPositionIndexName = "ListPositionIndex"
+
+// GetItemsOnListPage returns up to limit items on the list in position order, continuing from startKey, along with the key to continue from next
+// Query's limit applies before tombstones are filtered out, so it queries again until it has limit items or has read the whole list
+func (d *dynamoDB) GetItemsOnListPage(ctx context.Context, listID string, limit int64, startKey *data.ItemPositionKey) (*[]data.Item, *data.ItemPositionKey, error) {
+	tableName := d.conf.TableNames.Items
+	if len(tableName) == 0 {
+		panic("Items table name not set")
+	}
+
+	input := &dynamodb.QueryInput{
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":id": {S: &listID},
+		},
+		KeyConditionExpression: aws.String("ListId = :id"),
+		FilterExpression:       aws.String(notDeleted),
+		IndexName:              aws.String(listPositionIndexName),
+		TableName:              aws.String(tableName),
+	}
+	if startKey != nil {
+		key, err := dynamodbattribute.MarshalMap(startKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		input.ExclusiveStartKey = key
+	}
+
+	items := []data.Item{}
+	for {
+		// Only as many as are still needed are read, so the last key read is where the next page starts
+		if limit > 0 {
+			input.Limit = aws.Int64(limit - int64(len(items)))
+		}
+
+		result, err := d.session.QueryWithContext(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		if result == nil || result.Items == nil {
+			return nil, nil, errors.New("Failed to fetch items")
+		}
+
+		for _, i := range result.Items {
+			item := new(data.Item)
+			err = dynamodbattribute.UnmarshalMap(i, &item)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, *item)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return &items, nil, nil
+		}
+		if limit > 0 && int64(len(items)) >= limit {
+			nextKey := new(data.ItemPositionKey)
+			err = dynamodbattribute.UnmarshalMap(result.LastEvaluatedKey, nextKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			return &items, nextKey, nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetItemsOnListPage(t *testing.T) {
+	listID := "474c2Fff7"
+	tests := []struct {
+		name            string
+		limit           int64
+		startKey        *data.ItemPositionKey
+		expectedLimit   *int64
+		expectedStart   map[string]*dynamodb.AttributeValue
+		output          *dynamodb.QueryOutput
+		outputErr       error
+		expectedRes     *[]data.Item
+		expectedNextKey *data.ItemPositionKey
+		expectedErr     error
+	}{
+		{
+			name:        "When there is no limit or start key, the first page is returned",
+			output:      &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{}},
+			expectedRes: &[]data.Item{},
+		},
+		{
+			name:          "When there are more items, the key to continue from is returned",
+			limit:         1,
+			expectedLimit: aws.Int64(1),
+			output: &dynamodb.QueryOutput{
+				Items: []map[string]*dynamodb.AttributeValue{
+					{"ListId": {S: aws.String(listID)}, "Name": {S: aws.String("Oranges")}, "Id": {S: aws.String("1c2fa0a1")}},
+				},
+				LastEvaluatedKey: map[string]*dynamodb.AttributeValue{
+					"ListId":   {S: aws.String(listID)},
+					"Id":       {S: aws.String("1c2fa0a1")},
+					"Position": {N: aws.String("1024")},
+				},
+			},
+			expectedRes:     &[]data.Item{{Name: "Oranges", ItemKey: data.ItemKey{ID: "1c2fa0a1", ListID: listID}}},
+			expectedNextKey: &data.ItemPositionKey{ItemKey: data.ItemKey{ID: "1c2fa0a1", ListID: listID}, Position: 1024},
+		},
+		{
+			name:          "When a start key is given, the query continues from it",
+			limit:         1,
+			startKey:      &data.ItemPositionKey{ItemKey: data.ItemKey{ID: "1c2fa0a1", ListID: listID}, Position: 1024},
+			expectedLimit: aws.Int64(1),
+			expectedStart: map[string]*dynamodb.AttributeValue{
+				"ListId":   {S: aws.String(listID)},
+				"Id":       {S: aws.String("1c2fa0a1")},
+				"Position": {N: aws.String("1024")},
+			},
+			output: &dynamodb.QueryOutput{
+				Items: []map[string]*dynamodb.AttributeValue{
+					{"ListId": {S: aws.String(listID)}, "Name": {S: aws.String("Apples")}, "Id": {S: aws.String("bb0d5e8e")}},
+				},
+			},
+			expectedRes: &[]data.Item{{Name: "Apples", ItemKey: data.ItemKey{ID: "bb0d5e8e", ListID: listID}}},
+		},
+		{
+			name:        "When Query returns an error, that error is returned",
+			output:      &dynamodb.QueryOutput{},
+			outputErr:   errors.New("Something went wrong"),
+			expectedErr: errors.New("Something went wrong"),
+		},
+		{
+			name:        "When Query returns an nil, an error is returned",
+			output:      nil,
+			expectedErr: errors.New("Failed to fetch items"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			input := dynamodb.QueryInput{
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":id": {S: &listID}},
+				KeyConditionExpression:    aws.String("ListId = :id"),
+				FilterExpression:          aws.String("attribute_not_exists(DeletedAt)"),
+				IndexName:                 aws.String("ListPositionIndex"),
+				TableName:                 aws.String("items-table"),
+				Limit:                     tt.expectedLimit,
+				ExclusiveStartKey:         tt.expectedStart,
+			}
+			dbMocked.
+				On("Query", &input).
+				Return(tt.output, tt.outputErr).
+				Once()
+
+			d := dynamoDB{session: dbMocked, conf: testConfig}
+
+			gotRes, gotNextKey, gotErr := d.GetItemsOnListPage(context.Background(), listID, tt.limit, tt.startKey)
+
+			assert.Equal(t, tt.expectedErr, gotErr)
+			assert.Equal(t, tt.expectedRes, gotRes)
+			assert.Equal(t, tt.expectedNextKey, gotNextKey)
+		})
+	}
+}
+
+func TestGetItemsOnListPageQueriesAgainForShortPages(t *testing.T) {
+	listID := "474c2Fff7"
+	query := func(limit int64, start map[string]*dynamodb.AttributeValue) *dynamodb.QueryInput {
+		return &dynamodb.QueryInput{
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":id": {S: &listID}},
+			KeyConditionExpression:    aws.String("ListId = :id"),
+			FilterExpression:          aws.String("attribute_not_exists(DeletedAt)"),
+			IndexName:                 aws.String("ListPositionIndex"),
+			TableName:                 aws.String("items-table"),
+			Limit:                     aws.Int64(limit),
+			ExclusiveStartKey:         start,
+		}
+	}
+	key := func(id string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{"ListId": {S: aws.String(listID)}, "Id": {S: aws.String(id)}, "Position": {N: aws.String("1024")}}
+	}
+	item := func(id string, name string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{"ListId": {S: aws.String(listID)}, "Name": {S: aws.String(name)}, "Id": {S: aws.String(id)}}
+	}
+
+	tests := []struct {
+		name            string
+		outputs         []*dynamodb.QueryOutput
+		expectedNames   []string
+		expectedNextKey *data.ItemPositionKey
+	}{
+		{
+			name: "When tombstones are filtered out, it queries again until the page is full",
+			outputs: []*dynamodb.QueryOutput{
+				{Items: []map[string]*dynamodb.AttributeValue{item("a", "Apples")}, LastEvaluatedKey: key("c")},
+				{Items: []map[string]*dynamodb.AttributeValue{item("d", "Dates")}, LastEvaluatedKey: key("d")},
+			},
+			expectedNames:   []string{"Apples", "Dates"},
+			expectedNextKey: &data.ItemPositionKey{ItemKey: data.ItemKey{ID: "d", ListID: listID}, Position: 1024},
+		},
+		{
+			name: "When the list runs out before the page is full, there is no key to continue from",
+			outputs: []*dynamodb.QueryOutput{
+				{Items: []map[string]*dynamodb.AttributeValue{}, LastEvaluatedKey: key("c")},
+				{Items: []map[string]*dynamodb.AttributeValue{item("d", "Dates")}},
+			},
+			expectedNames: []string{"Dates"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			var start map[string]*dynamodb.AttributeValue
+			remaining := int64(2)
+			for _, output := range tt.outputs {
+				dbMocked.On("Query", query(remaining, start)).Return(output, nil).Once()
+				remaining -= int64(len(output.Items))
+				start = output.LastEvaluatedKey
+			}
+
+			d := dynamoDB{session: dbMocked, conf: testConfig}
+
+			gotRes, gotNextKey, gotErr := d.GetItemsOnListPage(context.Background(), listID, 2, nil)
+
+			assert.NoError(t, gotErr)
+			assert.Equal(t, tt.expectedNames, names(*gotRes))
+			assert.Equal(t, tt.expectedNextKey, gotNextKey)
+		})
+	}
+}
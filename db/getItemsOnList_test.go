@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -94,6 +95,7 @@ func TestGetItemsOnList(t *testing.T) {
 			input := dynamodb.QueryInput{
 				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":id": {S: &listID}},
 				KeyConditionExpression:    aws.String("ListId = :id"),
+				FilterExpression:          aws.String("attribute_not_exists(DeletedAt)"),
 				TableName:                 aws.String("items-table"),
 			}
 			dbMocked.
@@ -103,10 +105,59 @@ func TestGetItemsOnList(t *testing.T) {
 
 			d := dynamoDB{session: dbMocked, conf: testConfig}
 
-			gotRes, gotErr := d.GetItemsOnList(listID)
+			gotRes, gotErr := d.GetItemsOnList(context.Background(), listID)
 
 			assert.Equal(t, tt.expectedErr, gotErr)
 			assert.Equal(t, tt.expectedRes, gotRes)
 		})
 	}
 }
+
+func TestGetItemsOnListFollowsPages(t *testing.T) {
+	listID := "474c2Fff7"
+	lastKey := map[string]*dynamodb.AttributeValue{
+		"ListId": {S: aws.String(listID)},
+		"Id":     {S: aws.String("1c2fa0a1")},
+	}
+
+	dbMocked := &mockDB{}
+	dbMocked.Test(t)
+	defer dbMocked.AssertExpectations(t)
+
+	firstPage := dynamodb.QueryInput{
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":id": {S: &listID}},
+		KeyConditionExpression:    aws.String("ListId = :id"),
+		FilterExpression:          aws.String("attribute_not_exists(DeletedAt)"),
+		TableName:                 aws.String("items-table"),
+	}
+	dbMocked.
+		On("Query", &firstPage).
+		Return(&dynamodb.QueryOutput{
+			Items: []map[string]*dynamodb.AttributeValue{
+				{"ListId": {S: aws.String(listID)}, "Name": {S: aws.String("Oranges")}, "Id": {S: aws.String("1c2fa0a1")}},
+			},
+			LastEvaluatedKey: lastKey,
+		}, nil).
+		Once()
+
+	secondPage := firstPage
+	secondPage.ExclusiveStartKey = lastKey
+	dbMocked.
+		On("Query", &secondPage).
+		Return(&dynamodb.QueryOutput{
+			Items: []map[string]*dynamodb.AttributeValue{
+				{"ListId": {S: aws.String(listID)}, "Name": {S: aws.String("Apples")}, "Id": {S: aws.String("bb0d5e8e")}},
+			},
+		}, nil).
+		Once()
+
+	d := dynamoDB{session: dbMocked, conf: testConfig}
+
+	gotRes, gotErr := d.GetItemsOnList(context.Background(), listID)
+
+	assert.NoError(t, gotErr)
+	assert.Equal(t, &[]data.Item{
+		{Name: "Oranges", ItemKey: data.ItemKey{ID: "1c2fa0a1", ListID: listID}},
+		{Name: "Apples", ItemKey: data.ItemKey{ID: "bb0d5e8e", ListID: listID}},
+	}, gotRes)
+}
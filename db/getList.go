@@ -1,13 +1,20 @@
 package db
 
 import (
+	"context"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/mount-joy/thelist-lambda/data"
 )
 
-func (d *dynamoDB) GetList(listID string) (*data.List, error) {
+func (d *dynamoDB) GetList(ctx context.Context, listID string) (*data.List, error) {
+	return d.getList(ctx, listID, false)
+}
+
+// getList reads a list, a consistent read is used before changing it
+func (d *dynamoDB) getList(ctx context.Context, listID string, consistent bool) (*data.List, error) {
 	tableName := d.conf.TableNames.Lists
 	if len(tableName) == 0 {
 		panic("Items table name not set")
@@ -23,11 +30,17 @@ func (d *dynamoDB) GetList(listID string) (*data.List, error) {
 		Key:       key,
 		TableName: aws.String(tableName),
 	}
-	res, err := d.session.GetItem(input)
+	if consistent {
+		input.ConsistentRead = aws.Bool(true)
+	}
+	res, err := d.session.GetItemWithContext(ctx, input)
 
 	if err != nil {
 		return nil, err
 	}
+	if len(res.Item) == 0 {
+		return nil, ErrorNotFound
+	}
 
 	item := new(data.List)
 	err = dynamodbattribute.UnmarshalMap(res.Item, &item)
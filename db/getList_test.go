@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -31,6 +32,13 @@ func TestGetList(t *testing.T) {
 			expectedRes: &data.List{ListKey: data.ListKey{ID: listID}, Name: name},
 			expectedErr: nil,
 		},
+		{
+			name:          "If the item doesn't exist ErrorNotFound is returned",
+			mockOutputErr: nil,
+			mockOutput:    &dynamodb.GetItemOutput{},
+			expectedRes:   nil,
+			expectedErr:   ErrorNotFound,
+		},
 		{
 			name:          "When db returns an error, that error is returned",
 			mockOutputErr: errors.New("Something went wrong"),
@@ -58,7 +66,7 @@ func TestGetList(t *testing.T) {
 				Once()
 
 			d := dynamoDB{session: dbMocked, conf: testConfig}
-			gotRes, gotErr := d.GetList(listID)
+			gotRes, gotErr := d.GetList(context.Background(), listID)
 
 			assert.Equal(t, tt.expectedErr, gotErr)
 			assert.Equal(t, tt.expectedRes, gotRes)
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// ownerIndexName is the global secondary index on the lists table keyed by OwnerId and sorted by Updated
+const ownerIndexName = "OwnerIndex"
+
+func (d *dynamoDB) GetListsForOwner(ctx context.Context, ownerID string, limit int64, startKey *data.OwnerListKey) (*[]data.List, *data.OwnerListKey, error) {
+	tableName := d.conf.TableNames.Lists
+	if len(tableName) == 0 {
+		panic("Lists table name not set")
+	}
+
+	input := &dynamodb.QueryInput{
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":o": {S: &ownerID},
+		},
+		KeyConditionExpression: aws.String("OwnerId = :o"),
+		IndexName:              aws.String(ownerIndexName),
+		ScanIndexForward:       aws.Bool(false),
+		TableName:              aws.String(tableName),
+	}
+	if limit > 0 {
+		input.Limit = aws.Int64(limit)
+	}
+	if startKey != nil {
+		key, err := dynamodbattribute.MarshalMap(startKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		input.ExclusiveStartKey = key
+	}
+
+	result, err := d.session.QueryWithContext(ctx, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if result == nil || result.Items == nil {
+		return nil, nil, errors.New("Failed to fetch lists")
+	}
+
+	lists := []data.List{}
+	for _, i := range result.Items {
+		list := new(data.List)
+		err = dynamodbattribute.UnmarshalMap(i, &list)
+		if err != nil {
+			return nil, nil, err
+		}
+		lists = append(lists, *list)
+	}
+
+	if len(result.LastEvaluatedKey) == 0 {
+		return &lists, nil, nil
+	}
+
+	nextKey := new(data.OwnerListKey)
+	err = dynamodbattribute.UnmarshalMap(result.LastEvaluatedKey, nextKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &lists, nextKey, nil
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetListsForOwner(t *testing.T) {
+	ownerID := "user-1"
+	timestamp := "2020-01-23T09:59:14.9396531Z"
+	key := data.OwnerListKey{ListKey: data.ListKey{ID: "474c2Fff7"}, OwnerID: ownerID, UpdatedTimestamp: timestamp}
+	attributeKey := map[string]*dynamodb.AttributeValue{
+		"Id":      {S: aws.String("474c2Fff7")},
+		"OwnerId": {S: aws.String(ownerID)},
+		"Updated": {S: aws.String(timestamp)},
+	}
+
+	tests := []struct {
+		name            string
+		limit           int64
+		startKey        *data.OwnerListKey
+		expectedLimit   *int64
+		expectedStart   map[string]*dynamodb.AttributeValue
+		output          *dynamodb.QueryOutput
+		outputErr       error
+		expectedRes     *[]data.List
+		expectedNextKey *data.OwnerListKey
+		expectedErr     error
+	}{
+		{
+			name:        "When the owner has no lists, an empty page is returned",
+			output:      &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{}},
+			expectedRes: &[]data.List{},
+		},
+		{
+			name:          "When there are more lists, the key to continue from is returned",
+			limit:         1,
+			expectedLimit: aws.Int64(1),
+			output: &dynamodb.QueryOutput{
+				Items: []map[string]*dynamodb.AttributeValue{
+					{"Id": {S: aws.String("474c2Fff7")}, "Name": {S: aws.String("Groceries")}, "OwnerId": {S: aws.String(ownerID)}, "Updated": {S: aws.String(timestamp)}},
+				},
+				LastEvaluatedKey: attributeKey,
+			},
+			expectedRes:     &[]data.List{{ListKey: data.ListKey{ID: "474c2Fff7"}, Name: "Groceries", OwnerID: ownerID, UpdatedTimestamp: timestamp}},
+			expectedNextKey: &key,
+		},
+		{
+			name:          "When a start key is given, the query continues from it",
+			limit:         1,
+			startKey:      &key,
+			expectedLimit: aws.Int64(1),
+			expectedStart: attributeKey,
+			output: &dynamodb.QueryOutput{
+				Items: []map[string]*dynamodb.AttributeValue{
+					{"Id": {S: aws.String("bb0d5e8e")}, "Name": {S: aws.String("DIY")}, "OwnerId": {S: aws.String(ownerID)}},
+				},
+			},
+			expectedRes: &[]data.List{{ListKey: data.ListKey{ID: "bb0d5e8e"}, Name: "DIY", OwnerID: ownerID}},
+		},
+		{
+			name:        "When Query returns an error, that error is returned",
+			output:      &dynamodb.QueryOutput{},
+			outputErr:   errors.New("Something went wrong"),
+			expectedErr: errors.New("Something went wrong"),
+		},
+		{
+			name:        "When Query returns an nil, an error is returned",
+			output:      nil,
+			expectedErr: errors.New("Failed to fetch lists"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			input := dynamodb.QueryInput{
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":o": {S: &ownerID}},
+				KeyConditionExpression:    aws.String("OwnerId = :o"),
+				IndexName:                 aws.String("OwnerIndex"),
+				ScanIndexForward:          aws.Bool(false),
+				TableName:                 aws.String("lists-table"),
+				Limit:                     tt.expectedLimit,
+				ExclusiveStartKey:         tt.expectedStart,
+			}
+			dbMocked.
+				On("Query", &input).
+				Return(tt.output, tt.outputErr).
+				Once()
+
+			d := dynamoDB{session: dbMocked, conf: testConfig}
+
+			gotRes, gotNextKey, gotErr := d.GetListsForOwner(context.Background(), ownerID, tt.limit, tt.startKey)
+
+			assert.Equal(t, tt.expectedErr, gotErr)
+			assert.Equal(t, tt.expectedRes, gotRes)
+			assert.Equal(t, tt.expectedNextKey, gotNextKey)
+		})
+	}
+}
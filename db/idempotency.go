@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// idempotencyRetention is how long a key is held for once its response is stored, clients retry well within it
+const idempotencyRetention = 24 * time.Hour
+
+// idempotencyPendingTimeout is how long a key is held for before its response is stored, when the request has no deadline
+const idempotencyPendingTimeout = time.Minute
+
+// idempotencyDeadlineMargin is how long a key is held for after the deadline of the request which claimed it,
+// so the key can't be claimed again while that request could still be storing its response
+const idempotencyDeadlineMargin = 5 * time.Second
+
+// pendingExpiresAt returns when a key claimed at timestamp stops being held if its response is never stored
+// Lambda sets the context's deadline from the function's timeout, so the key is held for as long as the request can run
+func pendingExpiresAt(ctx context.Context, timestamp string) int64 {
+	if deadline, ok := ctx.Deadline(); ok {
+		// Rounded up, as ExpiresAt is in whole seconds
+		return deadline.Add(idempotencyDeadlineMargin).Unix() + 1
+	}
+	return expiresAt(timestamp, idempotencyPendingTimeout)
+}
+
+// StartIdempotentRequest claims key for the request with fingerprint, so only that request is handled
+// The claim is returned, to complete or release the key with. When the key is already held its record is returned
+// along with ErrorIDExists instead, the record is nil if it went in between
+func (d *dynamoDB) StartIdempotentRequest(ctx context.Context, key string, fingerprint string) (*data.IdempotencyRecord, error) {
+	tableName := d.conf.TableNames.Idempotency
+	if len(tableName) == 0 {
+		panic("Idempotency table name not set")
+	}
+
+	timestamp := d.getTimestamp()
+	record := &data.IdempotencyRecord{
+		Key:              key,
+		Fingerprint:      fingerprint,
+		CreatedTimestamp: timestamp,
+		ExpiresAt:        pendingExpiresAt(ctx, timestamp),
+	}
+	recordToInsert, err := dynamodbattribute.MarshalMap(record)
+	if err != nil {
+		return nil, err
+	}
+
+	// DynamoDB's TTL can take a while to purge a record, a key which has expired can be claimed again
+	now := strconv.FormatInt(unixSeconds(timestamp), 10)
+	input := &dynamodb.PutItemInput{
+		TableName:                 aws.String(tableName),
+		Item:                      recordToInsert,
+		ConditionExpression:       aws.String("attribute_not_exists(Id) OR ExpiresAt <= :now"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":now": {N: &now}},
+	}
+
+	_, err = d.session.PutItemWithContext(ctx, input)
+
+	switch e := err.(type) {
+	case nil:
+		return record, nil
+	case awserr.Error:
+		if e.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return d.getIdempotencyRecord(ctx, key)
+		}
+		return nil, err
+	default:
+		return nil, err
+	}
+}
+
+// getIdempotencyRecord reads the record holding key with a consistent read, as it was only just written
+func (d *dynamoDB) getIdempotencyRecord(ctx context.Context, key string) (*data.IdempotencyRecord, error) {
+	input := &dynamodb.GetItemInput{
+		Key:            map[string]*dynamodb.AttributeValue{"Id": {S: &key}},
+		TableName:      aws.String(d.conf.TableNames.Idempotency),
+		ConsistentRead: aws.Bool(true),
+	}
+	res, err := d.session.GetItemWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Item) == 0 {
+		return nil, ErrorIDExists
+	}
+
+	record := new(data.IdempotencyRecord)
+	if err := dynamodbattribute.UnmarshalMap(res.Item, &record); err != nil {
+		return nil, err
+	}
+	return record, ErrorIDExists
+}
+
+// claimCondition only matches the record of claim, so once the key expires and is claimed again that claim is left alone
+func claimCondition(claim data.IdempotencyRecord) (*string, map[string]*string, map[string]*dynamodb.AttributeValue) {
+	return aws.String("Fingerprint = :f AND #c = :c"),
+		map[string]*string{"#c": aws.String("Created")},
+		map[string]*dynamodb.AttributeValue{
+			":f": {S: aws.String(claim.Fingerprint)},
+			":c": {S: aws.String(claim.CreatedTimestamp)},
+		}
+}
+
+// CompleteIdempotentRequest stores the response to the request which made claim, so it can be replayed until the key expires
+func (d *dynamoDB) CompleteIdempotentRequest(ctx context.Context, claim data.IdempotencyRecord, response data.IdempotentResponse) error {
+	tableName := d.conf.TableNames.Idempotency
+	if len(tableName) == 0 {
+		panic("Idempotency table name not set")
+	}
+
+	responseToInsert, err := dynamodbattribute.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	expires := strconv.FormatInt(expiresAt(d.getTimestamp(), idempotencyRetention), 10)
+	condition, names, values := claimCondition(claim)
+	names["#r"] = aws.String("Response")
+	values[":r"] = responseToInsert
+	values[":expires"] = &dynamodb.AttributeValue{N: &expires}
+	input := &dynamodb.UpdateItemInput{
+		Key:                       map[string]*dynamodb.AttributeValue{"Id": {S: aws.String(claim.Key)}},
+		TableName:                 aws.String(tableName),
+		UpdateExpression:          aws.String("SET #r = :r, ExpiresAt = :expires"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ConditionExpression:       condition,
+	}
+
+	_, err = d.session.UpdateItemWithContext(ctx, input)
+	return claimError(err)
+}
+
+// ReleaseIdempotencyKey gives up the key held by claim, when its request failed it can be made again
+func (d *dynamoDB) ReleaseIdempotencyKey(ctx context.Context, claim data.IdempotencyRecord) error {
+	tableName := d.conf.TableNames.Idempotency
+	if len(tableName) == 0 {
+		panic("Idempotency table name not set")
+	}
+
+	condition, names, values := claimCondition(claim)
+	input := &dynamodb.DeleteItemInput{
+		Key:                       map[string]*dynamodb.AttributeValue{"Id": {S: aws.String(claim.Key)}},
+		TableName:                 aws.String(tableName),
+		ConditionExpression:       condition,
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}
+	_, err := d.session.DeleteItemWithContext(ctx, input)
+	return claimError(err)
+}
+
+// claimError is ErrorNotFound when the claim no longer holds its key, otherwise err
+func claimError(err error) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case awserr.Error:
+		if e.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return ErrorNotFound
+		}
+		return err
+	default:
+		return err
+	}
+}
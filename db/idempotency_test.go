@@ -0,0 +1,240 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartIdempotentRequest(t *testing.T) {
+	key := "user-1 POST /lists abc"
+	timestamp := "2020-01-23T09:59:14.9396531Z"
+	pendingExpiresAt := int64(1579773614)
+	existing := &data.IdempotencyRecord{
+		Key:              key,
+		Fingerprint:      "other",
+		Response:         &data.IdempotentResponse{StatusCode: 200, Body: `{"Id":"1"}`},
+		CreatedTimestamp: timestamp,
+		ExpiresAt:        1579859954,
+	}
+
+	tests := []struct {
+		name        string
+		deadline    time.Time
+		expiresAt   int64
+		putErr      error
+		getOutput   *dynamodb.GetItemOutput
+		getErr      error
+		expectedRes *data.IdempotencyRecord
+		expectedErr error
+	}{
+		{
+			name:        "If the key isn't held, it is claimed",
+			expectedRes: &data.IdempotencyRecord{Key: key, Fingerprint: "fingerprint", CreatedTimestamp: timestamp, ExpiresAt: pendingExpiresAt},
+			expectedErr: nil,
+		},
+		{
+			name:        "The key is held until after the request's deadline",
+			deadline:    time.Date(2020, 1, 23, 10, 0, 14, 500000000, time.UTC),
+			expiresAt:   1579773620,
+			expectedRes: &data.IdempotencyRecord{Key: key, Fingerprint: "fingerprint", CreatedTimestamp: timestamp, ExpiresAt: 1579773620},
+			expectedErr: nil,
+		},
+		{
+			name:        "If the key is held, its record is returned",
+			putErr:      awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "Bad", errors.New("Oh dear")),
+			getOutput:   &dynamodb.GetItemOutput{},
+			expectedRes: existing,
+			expectedErr: ErrorIDExists,
+		},
+		{
+			name:        "If the key is released before its record is read, no record is returned",
+			putErr:      awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "Bad", errors.New("Oh dear")),
+			getOutput:   &dynamodb.GetItemOutput{},
+			expectedRes: nil,
+			expectedErr: ErrorIDExists,
+		},
+		{
+			name:        "When reading the record returns an error, that error is returned",
+			putErr:      awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "Bad", errors.New("Oh dear")),
+			getOutput:   &dynamodb.GetItemOutput{},
+			getErr:      errors.New("Something went wrong"),
+			expectedErr: errors.New("Something went wrong"),
+		},
+		{
+			name:        "When db returns an error, that error is returned",
+			putErr:      errors.New("Something went wrong"),
+			expectedErr: errors.New("Something went wrong"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			expires := pendingExpiresAt
+			if tt.expiresAt != 0 {
+				expires = tt.expiresAt
+			}
+			item, _ := dynamodbattribute.MarshalMap(data.IdempotencyRecord{Key: key, Fingerprint: "fingerprint", CreatedTimestamp: timestamp, ExpiresAt: expires})
+			dbMocked.
+				On("PutItem", &dynamodb.PutItemInput{
+					TableName:                 stringToPointer("idempotency-table"),
+					Item:                      item,
+					ConditionExpression:       stringToPointer("attribute_not_exists(Id) OR ExpiresAt <= :now"),
+					ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":now": {N: stringToPointer("1579773554")}},
+				}).
+				Return(&dynamodb.PutItemOutput{}, tt.putErr).
+				Once()
+
+			if tt.getOutput != nil {
+				if tt.expectedRes != nil && tt.getErr == nil {
+					tt.getOutput.Item, _ = dynamodbattribute.MarshalMap(tt.expectedRes)
+				}
+				dbMocked.
+					On("GetItem", &dynamodb.GetItemInput{
+						Key:            map[string]*dynamodb.AttributeValue{"Id": {S: &key}},
+						TableName:      stringToPointer("idempotency-table"),
+						ConsistentRead: boolToPointer(true),
+					}).
+					Return(tt.getOutput, tt.getErr).
+					Once()
+			}
+
+			ctx := context.Background()
+			if !tt.deadline.IsZero() {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, tt.deadline)
+				defer cancel()
+			}
+
+			d := dynamoDB{session: dbMocked, conf: testConfig, getTimestamp: func() string { return timestamp }}
+			gotRes, gotErr := d.StartIdempotentRequest(ctx, key, "fingerprint")
+
+			assert.Equal(t, tt.expectedErr, gotErr)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
+
+func TestCompleteIdempotentRequest(t *testing.T) {
+	key := "user-1 POST /lists abc"
+	timestamp := "2020-01-23T09:59:14.9396531Z"
+	claim := data.IdempotencyRecord{Key: key, Fingerprint: "fingerprint", CreatedTimestamp: timestamp, ExpiresAt: 1579773614}
+	response := data.IdempotentResponse{StatusCode: 200, Headers: map[string]string{"ETag": `"1"`}, Body: `{"Id":"1"}`}
+
+	tests := []struct {
+		name        string
+		updateErr   error
+		expectedErr error
+	}{
+		{
+			name:        "The response is stored with the key",
+			expectedErr: nil,
+		},
+		{
+			name:        "If the key is no longer held by the claim, not found error is returned",
+			updateErr:   awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "Bad", errors.New("Oh dear")),
+			expectedErr: ErrorNotFound,
+		},
+		{
+			name:        "When db returns an error, that error is returned",
+			updateErr:   errors.New("Something went wrong"),
+			expectedErr: errors.New("Something went wrong"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			value, _ := dynamodbattribute.Marshal(response)
+			dbMocked.
+				On("UpdateItem", &dynamodb.UpdateItemInput{
+					Key:                      map[string]*dynamodb.AttributeValue{"Id": {S: &key}},
+					TableName:                stringToPointer("idempotency-table"),
+					UpdateExpression:         stringToPointer("SET #r = :r, ExpiresAt = :expires"),
+					ExpressionAttributeNames: map[string]*string{"#c": stringToPointer("Created"), "#r": stringToPointer("Response")},
+					ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+						":f":       {S: stringToPointer("fingerprint")},
+						":c":       {S: &timestamp},
+						":r":       value,
+						":expires": {N: stringToPointer("1579859954")},
+					},
+					ConditionExpression: stringToPointer("Fingerprint = :f AND #c = :c"),
+				}).
+				Return(&dynamodb.UpdateItemOutput{}, tt.updateErr).
+				Once()
+
+			d := dynamoDB{session: dbMocked, conf: testConfig, getTimestamp: func() string { return timestamp }}
+			gotErr := d.CompleteIdempotentRequest(context.Background(), claim, response)
+
+			assert.Equal(t, tt.expectedErr, gotErr)
+		})
+	}
+}
+
+func TestReleaseIdempotencyKey(t *testing.T) {
+	key := "user-1 POST /lists abc"
+	timestamp := "2020-01-23T09:59:14.9396531Z"
+	claim := data.IdempotencyRecord{Key: key, Fingerprint: "fingerprint", CreatedTimestamp: timestamp, ExpiresAt: 1579773614}
+
+	tests := []struct {
+		name        string
+		deleteErr   error
+		expectedErr error
+	}{
+		{
+			name:        "The key is released",
+			expectedErr: nil,
+		},
+		{
+			name:        "If the key is no longer held by the claim, not found error is returned",
+			deleteErr:   awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "Bad", errors.New("Oh dear")),
+			expectedErr: ErrorNotFound,
+		},
+		{
+			name:        "When db returns an error, that error is returned",
+			deleteErr:   errors.New("Something went wrong"),
+			expectedErr: errors.New("Something went wrong"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			dbMocked.
+				On("DeleteItem", &dynamodb.DeleteItemInput{
+					Key:                      map[string]*dynamodb.AttributeValue{"Id": {S: &key}},
+					TableName:                stringToPointer("idempotency-table"),
+					ConditionExpression:      stringToPointer("Fingerprint = :f AND #c = :c"),
+					ExpressionAttributeNames: map[string]*string{"#c": stringToPointer("Created")},
+					ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+						":f": {S: stringToPointer("fingerprint")},
+						":c": {S: &timestamp},
+					},
+				}).
+				Return(&dynamodb.DeleteItemOutput{}, tt.deleteErr).
+				Once()
+
+			d := dynamoDB{session: dbMocked, conf: testConfig}
+			gotErr := d.ReleaseIdempotencyKey(context.Background(), claim)
+
+			assert.Equal(t, tt.expectedErr, gotErr)
+		})
+	}
+}
@@ -0,0 +1,495 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// memoryDB keeps everything in maps, mirroring how the dynamodb implementation behaves
+// It is meant for running locally and in tests, nothing survives a restart
+type memoryDB struct {
+	mu           sync.Mutex
+	lists        map[string]data.List
+	items        map[string]map[string]data.Item
+	activity     map[string][]data.Activity
+	idempotency  map[string]data.IdempotencyRecord
+	generateID   func() string
+	getTimestamp func() string
+	retention    time.Duration
+}
+
+func newMemoryDB(retention time.Duration) DB {
+	return &memoryDB{
+		lists:        map[string]data.List{},
+		items:        map[string]map[string]data.Item{},
+		activity:     map[string][]data.Activity{},
+		idempotency:  map[string]data.IdempotencyRecord{},
+		generateID:   func() string { return generateID() },
+		getTimestamp: func() string { return getTimestamp() },
+		retention:    retention,
+	}
+}
+
+func (m *memoryDB) CompleteIdempotentRequest(ctx context.Context, claim data.IdempotencyRecord, response data.IdempotentResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.idempotency[claim.Key]
+	if !ok || !sameClaim(record, claim) {
+		return ErrorNotFound
+	}
+	record.Response = &response
+	record.ExpiresAt = expiresAt(m.getTimestamp(), idempotencyRetention)
+	m.idempotency[claim.Key] = record
+	return nil
+}
+
+func (m *memoryDB) CreateItem(ctx context.Context, listID string, fields data.NewItem) (*data.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	itemID := m.generateID()
+	if _, ok := m.items[listID][itemID]; ok {
+		return nil, ErrorIDExists
+	}
+
+	timestamp := m.getTimestamp()
+	item := newItem(listID, itemID, fields, newItemPosition(timestamp), timestamp)
+	if err := m.record(ctx, listID, data.ActivityCreateItem, itemID, nil, item, timestamp); err != nil {
+		return nil, err
+	}
+	m.putItem(*item)
+
+	return item, nil
+}
+
+func (m *memoryDB) BatchWriteItems(ctx context.Context, listID string, operations []data.BatchOperation) ([]data.BatchResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	timestamp := m.getTimestamp()
+	position := newItemPosition(timestamp)
+
+	results := make([]data.BatchResult, len(operations))
+	for i, operation := range operations {
+		switch operation.Action {
+		case data.BatchActionCreate:
+			item := newItem(listID, m.generateID(), data.NewItem{Name: operation.Name}, position+float64(i), timestamp)
+			if err := m.record(ctx, listID, data.ActivityCreateItem, item.ID, nil, item, timestamp); err != nil {
+				return nil, err
+			}
+			m.putItem(*item)
+			results[i] = data.BatchResult{Action: operation.Action, ID: item.ID, Succeeded: true, Item: item}
+		case data.BatchActionDelete:
+			if item, ok := m.liveItem(listID, operation.ID); ok {
+				if err := m.record(ctx, listID, data.ActivityDeleteItem, item.ID, &item, nil, timestamp); err != nil {
+					return nil, err
+				}
+				m.putItem(tombstone(item, timestamp, m.retention))
+			}
+			results[i] = data.BatchResult{Action: operation.Action, ID: operation.ID, Succeeded: true}
+		default:
+			return nil, fmt.Errorf("%w: unknown action %q", ErrorBadRequest, operation.Action)
+		}
+	}
+
+	return results, nil
+}
+
+func (m *memoryDB) CreateList(ctx context.Context, listName string, ownerID string) (*data.List, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	listID := m.generateID()
+	if _, ok := m.lists[listID]; ok {
+		return nil, ErrorIDExists
+	}
+
+	timestamp := m.getTimestamp()
+	list := data.List{
+		ListKey: data.ListKey{
+			ID: listID,
+		},
+		Name:             listName,
+		OwnerID:          ownerID,
+		Version:          1,
+		CreatedTimestamp: timestamp,
+		UpdatedTimestamp: timestamp,
+	}
+	if err := m.record(WithActor(ctx, ownerID), listID, data.ActivityCreateList, "", nil, &list, timestamp); err != nil {
+		return nil, err
+	}
+	m.lists[listID] = list
+
+	return &list, nil
+}
+
+func (m *memoryDB) DeleteCompletedItems(ctx context.Context, listID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	timestamp := m.getTimestamp()
+	deleted := 0
+	for _, item := range m.sortedItems(listID) {
+		if item.IsCompleted && item.DeletedTimestamp == "" {
+			if err := m.record(ctx, listID, data.ActivityDeleteItem, item.ID, &item, nil, timestamp); err != nil {
+				return deleted, err
+			}
+			m.putItem(tombstone(item, timestamp, m.retention))
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *memoryDB) DeleteItem(ctx context.Context, listID string, itemID string, expectedVersion *int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.liveItem(listID, itemID)
+	if err := checkVersion(ok, item.Version, expectedVersion); err != nil {
+		// Deleting is idempotent unless the caller asked for a particular version to be deleted
+		if expectedVersion == nil {
+			return nil
+		}
+		return err
+	}
+
+	timestamp := m.getTimestamp()
+	if err := m.record(ctx, listID, data.ActivityDeleteItem, itemID, &item, nil, timestamp); err != nil {
+		return err
+	}
+	m.putItem(tombstone(item, timestamp, m.retention))
+	return nil
+}
+
+func (m *memoryDB) DeleteList(ctx context.Context, listID string, expectedVersion *int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list, ok := m.lists[listID]
+	if err := checkVersion(ok, list.Version, expectedVersion); err != nil {
+		return err
+	}
+
+	if err := m.record(ctx, listID, data.ActivityDeleteList, "", &list, nil, m.getTimestamp()); err != nil {
+		return err
+	}
+	delete(m.lists, listID)
+	delete(m.items, listID)
+	return nil
+}
+
+func (m *memoryDB) GetActivity(ctx context.Context, listID string, limit int64, startKey *data.ActivityKey) (*[]data.Activity, *data.ActivityKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Newest first, activity IDs sort in the order the activities happened
+	activities := append([]data.Activity{}, m.activity[listID]...)
+	sort.Slice(activities, func(i, j int) bool { return activities[i].ID > activities[j].ID })
+	if startKey != nil {
+		start := sort.Search(len(activities), func(i int) bool { return activities[i].ID < startKey.ID })
+		activities = activities[start:]
+	}
+
+	if limit <= 0 || int64(len(activities)) <= limit {
+		return &activities, nil, nil
+	}
+
+	activities = activities[:limit]
+	nextKey := activities[len(activities)-1].ActivityKey
+	return &activities, &nextKey, nil
+}
+
+func (m *memoryDB) GetItem(ctx context.Context, listID string, itemID string) (*data.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.liveItem(listID, itemID)
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &item, nil
+}
+
+func (m *memoryDB) GetItemChanges(ctx context.Context, listID string, since string) (*[]data.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := []data.Item{}
+	for _, item := range m.sortedItems(listID) {
+		if item.UpdatedTimestamp > since {
+			items = append(items, item)
+		}
+	}
+	// Oldest first, ties are broken by ID the same way dynamodb orders the index
+	sort.SliceStable(items, func(i, j int) bool { return items[i].UpdatedTimestamp < items[j].UpdatedTimestamp })
+	return &items, nil
+}
+
+func (m *memoryDB) GetItemsOnList(ctx context.Context, listID string) (*[]data.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := withoutTombstones(m.sortedItems(listID))
+	sortByPosition(items)
+	return &items, nil
+}
+
+func (m *memoryDB) GetItemsOnListPage(ctx context.Context, listID string, limit int64, startKey *data.ItemPositionKey) (*[]data.Item, *data.ItemPositionKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// In position order, ties are broken by ID the same way dynamodb orders the index
+	items := withoutTombstones(m.sortedItems(listID))
+	sortByPosition(items)
+	if startKey != nil {
+		start := sort.Search(len(items), func(i int) bool {
+			return items[i].Position > startKey.Position || (items[i].Position == startKey.Position && items[i].ID > startKey.ID)
+		})
+		items = items[start:]
+	}
+
+	if limit <= 0 || int64(len(items)) <= limit {
+		return &items, nil, nil
+	}
+
+	items = items[:limit]
+	last := items[len(items)-1]
+	nextKey := data.ItemPositionKey{ItemKey: last.ItemKey, Position: last.Position}
+	return &items, &nextKey, nil
+}
+
+func (m *memoryDB) GetList(ctx context.Context, listID string) (*data.List, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list, ok := m.lists[listID]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	return &list, nil
+}
+
+func (m *memoryDB) GetListsForOwner(ctx context.Context, ownerID string, limit int64, startKey *data.OwnerListKey) (*[]data.List, *data.OwnerListKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lists := []data.List{}
+	for _, list := range m.lists {
+		if list.OwnerID == ownerID {
+			lists = append(lists, list)
+		}
+	}
+
+	// Newest first, ties are broken by ID the same way dynamodb orders the index
+	sort.Slice(lists, func(i, j int) bool { return ownerKeyBefore(ownerListKey(lists[i]), ownerListKey(lists[j])) })
+	if startKey != nil {
+		start := sort.Search(len(lists), func(i int) bool { return ownerKeyBefore(*startKey, ownerListKey(lists[i])) })
+		lists = lists[start:]
+	}
+
+	if limit <= 0 || int64(len(lists)) <= limit {
+		return &lists, nil, nil
+	}
+
+	lists = lists[:limit]
+	nextKey := ownerListKey(lists[len(lists)-1])
+	return &lists, &nextKey, nil
+}
+
+func (m *memoryDB) ReleaseIdempotencyKey(ctx context.Context, claim data.IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.idempotency[claim.Key]
+	if !ok || !sameClaim(record, claim) {
+		return ErrorNotFound
+	}
+	delete(m.idempotency, claim.Key)
+	return nil
+}
+
+func (m *memoryDB) ReorderItems(ctx context.Context, listID string, itemIDs []string) (*[]data.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := withoutTombstones(m.sortedItems(listID))
+	changes, err := planReorder(items, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkMoves(len(changes)); err != nil {
+		return nil, err
+	}
+
+	timestamp := m.getTimestamp()
+	for i, item := range items {
+		position, ok := changes[item.ID]
+		if !ok {
+			continue
+		}
+
+		items[i].Position = position
+		items[i].UpdatedTimestamp = timestamp
+		items[i].Version++
+		if err := m.record(ctx, listID, data.ActivityUpdateItem, item.ID, &item, &items[i], timestamp); err != nil {
+			return nil, err
+		}
+		m.items[listID][item.ID] = items[i]
+	}
+
+	sortByPosition(items)
+	return &items, nil
+}
+
+func (m *memoryDB) RestoreItem(ctx context.Context, listID string, itemID string) (*data.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.items[listID][itemID]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+	if item.DeletedTimestamp == "" {
+		return &item, nil
+	}
+
+	timestamp := m.getTimestamp()
+	if item.ExpiresAt <= unixSeconds(timestamp) {
+		return nil, ErrorNotFound
+	}
+
+	item.DeletedTimestamp = ""
+	item.ExpiresAt = 0
+	item.UpdatedTimestamp = timestamp
+	item.Version++
+	if err := m.record(ctx, listID, data.ActivityRestoreItem, itemID, nil, &item, timestamp); err != nil {
+		return nil, err
+	}
+	m.putItem(item)
+	return &item, nil
+}
+
+func (m *memoryDB) StartIdempotentRequest(ctx context.Context, key string, fingerprint string) (*data.IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	timestamp := m.getTimestamp()
+	if record, ok := m.idempotency[key]; ok && record.ExpiresAt > unixSeconds(timestamp) {
+		return &record, ErrorIDExists
+	}
+
+	record := data.IdempotencyRecord{
+		Key:              key,
+		Fingerprint:      fingerprint,
+		CreatedTimestamp: timestamp,
+		ExpiresAt:        pendingExpiresAt(ctx, timestamp),
+	}
+	m.idempotency[key] = record
+	return &record, nil
+}
+
+func (m *memoryDB) UpdateItem(ctx context.Context, listID string, itemID string, update data.ItemUpdate, expectedVersion *int64) (*data.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.liveItem(listID, itemID)
+	if err := checkVersion(ok, item.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	timestamp := m.getTimestamp()
+	updated := applyUpdate(item, update, timestamp)
+	if fieldErrors := update.ValidateResult(updated); len(fieldErrors) != 0 {
+		return nil, data.FieldErrors(fieldErrors)
+	}
+	if err := m.record(ctx, listID, data.ActivityUpdateItem, itemID, &item, &updated, timestamp); err != nil {
+		return nil, err
+	}
+	m.items[listID][itemID] = updated
+
+	return &updated, nil
+}
+
+func (m *memoryDB) UpdateList(ctx context.Context, listID string, newName string, expectedVersion *int64) (*data.List, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list, ok := m.lists[listID]
+	if err := checkVersion(ok, list.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	timestamp := m.getTimestamp()
+	updated := list
+	if newName != "" {
+		updated.Name = newName
+	}
+	updated.UpdatedTimestamp = timestamp
+	updated.Version++
+	if err := m.record(ctx, listID, data.ActivityUpdateList, "", &list, &updated, timestamp); err != nil {
+		return nil, err
+	}
+	m.lists[listID] = updated
+
+	return &updated, nil
+}
+
+// record appends an activity to the list's activity, numbering them so their IDs stay unique without using up generated IDs
+func (m *memoryDB) record(ctx context.Context, listID string, action string, itemID string, before interface{}, after interface{}, timestamp string) error {
+	activity, err := newActivity(ctx, listID, fmt.Sprintf("%09d", len(m.activity[listID])), action, itemID, before, after, timestamp)
+	if err != nil {
+		return err
+	}
+	m.activity[listID] = append(m.activity[listID], *activity)
+	return nil
+}
+
+func (m *memoryDB) putItem(item data.Item) {
+	if m.items[item.ListID] == nil {
+		m.items[item.ListID] = map[string]data.Item{}
+	}
+	m.items[item.ListID][item.ID] = item
+}
+
+// sameClaim returns true if record is still held by claim, as claimCondition checks
+func sameClaim(record data.IdempotencyRecord, claim data.IdempotencyRecord) bool {
+	return record.Fingerprint == claim.Fingerprint && record.CreatedTimestamp == claim.CreatedTimestamp
+}
+
+// liveItem returns the item unless it doesn't exist or has been deleted
+func (m *memoryDB) liveItem(listID string, itemID string) (data.Item, bool) {
+	item, ok := m.items[listID][itemID]
+	if !ok || item.DeletedTimestamp != "" {
+		return data.Item{}, false
+	}
+	return item, true
+}
+
+func ownerListKey(list data.List) data.OwnerListKey {
+	return data.OwnerListKey{ListKey: list.ListKey, OwnerID: list.OwnerID, UpdatedTimestamp: list.UpdatedTimestamp}
+}
+
+// ownerKeyBefore returns true if a comes before b in the owner index, which is read most recently updated first
+func ownerKeyBefore(a data.OwnerListKey, b data.OwnerListKey) bool {
+	if a.UpdatedTimestamp != b.UpdatedTimestamp {
+		return a.UpdatedTimestamp > b.UpdatedTimestamp
+	}
+	return a.ID > b.ID
+}
+
+// sortedItems returns the items on a list ordered by ID, the same order dynamodb uses for the range key
+func (m *memoryDB) sortedItems(listID string) []data.Item {
+	items := make([]data.Item, 0, len(m.items[listID]))
+	for _, item := range m.items[listID] {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items
+}
@@ -0,0 +1,516 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+const memoryTimestamp = "2020-01-23T09:59:14.9396531Z"
+
+func newTestMemoryDB() *memoryDB {
+	nextID := 0
+	return &memoryDB{
+		lists:       map[string]data.List{},
+		items:       map[string]map[string]data.Item{},
+		activity:    map[string][]data.Activity{},
+		idempotency: map[string]data.IdempotencyRecord{},
+		generateID: func() string {
+			nextID++
+			return fmt.Sprintf("id-%d", nextID)
+		},
+		getTimestamp: func() string { return memoryTimestamp },
+	}
+}
+
+func TestMemoryDBLists(t *testing.T) {
+	m := newTestMemoryDB()
+
+	created, err := m.CreateList(context.Background(), "Groceries", "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, &data.List{
+		ListKey:          data.ListKey{ID: "id-1"},
+		Name:             "Groceries",
+		OwnerID:          "user-1",
+		Version:          1,
+		CreatedTimestamp: memoryTimestamp,
+		UpdatedTimestamp: memoryTimestamp,
+	}, created)
+
+	got, err := m.GetList(context.Background(), "id-1")
+	assert.NoError(t, err)
+	assert.Equal(t, created, got)
+
+	updated, err := m.UpdateList(context.Background(), "id-1", "Shopping", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Shopping", updated.Name)
+
+	_, err = m.UpdateList(context.Background(), "missing", "Shopping", nil)
+	assert.Equal(t, ErrorNotFound, err)
+
+	assert.NoError(t, m.DeleteList(context.Background(), "id-1", nil))
+	assert.Equal(t, ErrorNotFound, m.DeleteList(context.Background(), "id-1", nil))
+
+	_, err = m.GetList(context.Background(), "id-1")
+	assert.Equal(t, ErrorNotFound, err)
+}
+
+func TestMemoryDBCreateWithExistingID(t *testing.T) {
+	m := newTestMemoryDB()
+	m.generateID = func() string { return "same-id" }
+
+	_, err := m.CreateList(context.Background(), "first", "user-1")
+	assert.NoError(t, err)
+	_, err = m.CreateList(context.Background(), "second", "user-1")
+	assert.Equal(t, ErrorIDExists, err)
+
+	_, err = m.CreateItem(context.Background(), "list", data.NewItem{Name: "first"})
+	assert.NoError(t, err)
+	_, err = m.CreateItem(context.Background(), "list", data.NewItem{Name: "second"})
+	assert.Equal(t, ErrorIDExists, err)
+}
+
+func TestMemoryDBItems(t *testing.T) {
+	m := newTestMemoryDB()
+
+	created, err := m.CreateItem(context.Background(), "list", data.NewItem{Name: "Apples"})
+	assert.NoError(t, err)
+	assert.Equal(t, &data.Item{
+		ItemKey:          data.ItemKey{ID: "id-1", ListID: "list"},
+		Name:             "Apples",
+		Position:         1579773554939,
+		Version:          1,
+		CreatedTimestamp: memoryTimestamp,
+		UpdatedTimestamp: memoryTimestamp,
+	}, created)
+
+	got, err := m.GetItem(context.Background(), "list", "id-1")
+	assert.NoError(t, err)
+	assert.Equal(t, created, got)
+
+	completed := true
+	updated, err := m.UpdateItem(context.Background(), "list", "id-1", data.ItemUpdate{IsCompleted: &completed}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Apples", updated.Name)
+	assert.True(t, updated.IsCompleted)
+
+	quantity := 6.0
+	updated, err = m.UpdateItem(context.Background(), "list", "id-1", data.ItemUpdate{Quantity: &quantity, Unit: "pcs", Category: "produce"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &quantity, updated.Quantity)
+	assert.Equal(t, "pcs", updated.Unit)
+	assert.Equal(t, "produce", updated.Category)
+	assert.True(t, updated.IsCompleted)
+
+	_, err = m.UpdateItem(context.Background(), "list", "id-1", data.ItemUpdate{ClearQuantity: true}, nil)
+	assert.Equal(t, data.FieldErrors{{Field: "Quantity", Err: data.ErrorQuantityNeededByUnit}}, err)
+
+	updated, err = m.UpdateItem(context.Background(), "list", "id-1", data.ItemUpdate{ClearQuantity: true, ClearUnit: true, ClearCategory: true}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, updated.Quantity)
+	assert.Equal(t, "", updated.Unit)
+	assert.Equal(t, "", updated.Category)
+
+	_, err = m.UpdateItem(context.Background(), "list", "id-1", data.ItemUpdate{Unit: "kg"}, nil)
+	assert.Equal(t, data.FieldErrors{{Field: "Unit", Err: data.ErrorUnitWithoutQuantity}}, err)
+
+	_, err = m.UpdateItem(context.Background(), "list", "missing", data.ItemUpdate{Name: "Pears"}, nil)
+	assert.Equal(t, ErrorNotFound, err)
+
+	assert.NoError(t, m.DeleteItem(context.Background(), "list", "id-1", nil))
+	assert.NoError(t, m.DeleteItem(context.Background(), "list", "id-1", nil))
+
+	_, err = m.GetItem(context.Background(), "list", "id-1")
+	assert.Equal(t, ErrorNotFound, err)
+}
+
+func TestMemoryDBItemsOnList(t *testing.T) {
+	m := newTestMemoryDB()
+	list, _ := m.CreateList(context.Background(), "Groceries", "user-1")
+	for _, name := range []string{"Apples", "Bananas", "Cherries"} {
+		_, err := m.CreateItem(context.Background(), list.ID, data.NewItem{Name: name})
+		assert.NoError(t, err)
+	}
+
+	items, err := m.GetItemsOnList(context.Background(), list.ID)
+	assert.NoError(t, err)
+	assert.Len(t, *items, 3)
+
+	page, nextKey, err := m.GetItemsOnListPage(context.Background(), list.ID, 2, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Apples", "Bananas"}, names(*page))
+	assert.Equal(t, &data.ItemPositionKey{ItemKey: data.ItemKey{ID: "id-3", ListID: list.ID}, Position: (*page)[1].Position}, nextKey)
+
+	page, nextKey, err = m.GetItemsOnListPage(context.Background(), list.ID, 2, nextKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Cherries"}, names(*page))
+	assert.Nil(t, nextKey)
+
+	// Pages follow the items' positions rather than their IDs
+	_, err = m.ReorderItems(context.Background(), list.ID, []string{"id-4", "id-2", "id-3"})
+	assert.NoError(t, err)
+
+	page, nextKey, err = m.GetItemsOnListPage(context.Background(), list.ID, 2, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Cherries", "Apples"}, names(*page))
+
+	page, nextKey, err = m.GetItemsOnListPage(context.Background(), list.ID, 2, nextKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bananas"}, names(*page))
+	assert.Nil(t, nextKey)
+
+	assert.NoError(t, m.DeleteList(context.Background(), list.ID, nil))
+	items, err = m.GetItemsOnList(context.Background(), list.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &[]data.Item{}, items)
+}
+
+func names(items []data.Item) []string {
+	res := []string{}
+	for _, item := range items {
+		res = append(res, item.Name)
+	}
+	return res
+}
+
+func TestMemoryDBReorderItems(t *testing.T) {
+	m := newTestMemoryDB()
+	list, _ := m.CreateList(context.Background(), "Groceries", "user-1")
+	for _, name := range []string{"Apples", "Bananas", "Cherries"} {
+		_, err := m.CreateItem(context.Background(), list.ID, data.NewItem{Name: name})
+		assert.NoError(t, err)
+	}
+
+	reordered, err := m.ReorderItems(context.Background(), list.ID, []string{"id-4", "id-2", "id-3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Cherries", "Apples", "Bananas"}, names(*reordered))
+
+	items, err := m.GetItemsOnList(context.Background(), list.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Cherries", "Apples", "Bananas"}, names(*items))
+
+	activities, _, err := m.GetActivity(context.Background(), list.ID, 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{data.ActivityUpdateItem}, actions(*activities))
+	assert.Equal(t, "id-4", (*activities)[0].ItemID)
+	assert.Equal(t, map[string]interface{}{"Position": (*items)[0].Position}, (*activities)[0].After)
+
+	_, err = m.ReorderItems(context.Background(), list.ID, []string{"id-4", "missing"})
+	assert.True(t, errors.Is(err, ErrorBadRequest))
+}
+
+func TestMemoryDBReorderItemsMovesAtMostOneTransactionsWorth(t *testing.T) {
+	m := newTestMemoryDB()
+	created := time.Date(2020, 1, 23, 9, 59, 14, 0, time.UTC)
+	m.getTimestamp = func() string {
+		created = created.Add(time.Second)
+		return created.Format(time.RFC3339Nano)
+	}
+	itemIDs := []string{}
+	for i := 0; i < maxMoves+2; i++ {
+		item, err := m.CreateItem(context.Background(), "list", data.NewItem{Name: fmt.Sprint(i)})
+		assert.NoError(t, err)
+		itemIDs = append([]string{item.ID}, itemIDs...)
+	}
+
+	_, err := m.ReorderItems(context.Background(), "list", itemIDs)
+	assert.True(t, errors.Is(err, ErrorBadRequest))
+
+	_, err = m.ReorderItems(context.Background(), "list", itemIDs[:2])
+	assert.NoError(t, err)
+}
+
+func TestMemoryDBBatchWriteItems(t *testing.T) {
+	m := newTestMemoryDB()
+	existing, _ := m.CreateItem(context.Background(), "list", data.NewItem{Name: "Bread"})
+
+	results, err := m.BatchWriteItems(context.Background(), "list", []data.BatchOperation{
+		{Action: data.BatchActionCreate, Name: "Milk"},
+		{Action: data.BatchActionDelete, ID: existing.ID},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.True(t, results[0].Succeeded)
+	assert.Equal(t, "Milk", results[0].Item.Name)
+	assert.True(t, results[1].Succeeded)
+
+	items, _ := m.GetItemsOnList(context.Background(), "list")
+	assert.Equal(t, []string{"Milk"}, names(*items))
+
+	activities, _, _ := m.GetActivity(context.Background(), "list", 10, nil)
+	assert.Equal(t, []string{data.ActivityDeleteItem, data.ActivityCreateItem, data.ActivityCreateItem}, actions(*activities))
+	assert.Equal(t, existing.ID, (*activities)[0].ItemID)
+
+	_, err = m.BatchWriteItems(context.Background(), "list", []data.BatchOperation{{Action: "update"}})
+	assert.True(t, errors.Is(err, ErrorBadRequest))
+}
+
+func TestMemoryDBDeleteCompletedItems(t *testing.T) {
+	m := newTestMemoryDB()
+	for _, name := range []string{"Apples", "Bananas", "Cherries"} {
+		_, err := m.CreateItem(context.Background(), "list", data.NewItem{Name: name})
+		assert.NoError(t, err)
+	}
+	completed := true
+	_, _ = m.UpdateItem(context.Background(), "list", "id-1", data.ItemUpdate{IsCompleted: &completed}, nil)
+	_, _ = m.UpdateItem(context.Background(), "list", "id-3", data.ItemUpdate{IsCompleted: &completed}, nil)
+
+	deleted, err := m.DeleteCompletedItems(context.Background(), "list")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	items, _ := m.GetItemsOnList(context.Background(), "list")
+	assert.Equal(t, []string{"Bananas"}, names(*items))
+
+	activities, _, _ := m.GetActivity(context.Background(), "list", 2, nil)
+	assert.Equal(t, []string{data.ActivityDeleteItem, data.ActivityDeleteItem}, actions(*activities))
+
+	deleted, err = m.DeleteCompletedItems(context.Background(), "list")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, deleted)
+}
+
+func TestMemoryDBVersions(t *testing.T) {
+	m := newTestMemoryDB()
+	item, _ := m.CreateItem(context.Background(), "list", data.NewItem{Name: "Apples"})
+	assert.Equal(t, int64(1), item.Version)
+
+	stale := int64(1)
+	updated, err := m.UpdateItem(context.Background(), "list", item.ID, data.ItemUpdate{Name: "Pears"}, &stale)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+
+	_, err = m.UpdateItem(context.Background(), "list", item.ID, data.ItemUpdate{Name: "Plums"}, &stale)
+	assert.Equal(t, ErrorPreconditionFailed, err)
+	assert.Equal(t, ErrorPreconditionFailed, m.DeleteItem(context.Background(), "list", item.ID, &stale))
+	assert.Equal(t, ErrorPreconditionFailed, m.DeleteItem(context.Background(), "list", "missing", &stale))
+
+	current := int64(2)
+	assert.NoError(t, m.DeleteItem(context.Background(), "list", item.ID, &current))
+
+	list, _ := m.CreateList(context.Background(), "Groceries", "user-1")
+	_, err = m.UpdateList(context.Background(), list.ID, "Shopping", &current)
+	assert.Equal(t, ErrorPreconditionFailed, err)
+	assert.Equal(t, ErrorPreconditionFailed, m.DeleteList(context.Background(), list.ID, &current))
+	assert.NoError(t, m.DeleteList(context.Background(), list.ID, &list.Version))
+}
+
+func TestMemoryDBGetListsForOwner(t *testing.T) {
+	m := newTestMemoryDB()
+	timestamps := []string{"2020-01-23T09:00:00Z", "2020-01-23T11:00:00Z", "2020-01-23T10:00:00Z"}
+	for i, name := range []string{"Groceries", "DIY", "Party"} {
+		timestamp := timestamps[i]
+		m.getTimestamp = func() string { return timestamp }
+		_, err := m.CreateList(context.Background(), name, "user-1")
+		assert.NoError(t, err)
+	}
+	_, _ = m.CreateList(context.Background(), "Someone else's", "user-2")
+
+	page, nextKey, err := m.GetListsForOwner(context.Background(), "user-1", 2, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"DIY", "Party"}, listNames(*page))
+	assert.Equal(t, &data.OwnerListKey{ListKey: data.ListKey{ID: "id-3"}, OwnerID: "user-1", UpdatedTimestamp: timestamps[2]}, nextKey)
+
+	page, nextKey, err = m.GetListsForOwner(context.Background(), "user-1", 2, nextKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Groceries"}, listNames(*page))
+	assert.Nil(t, nextKey)
+
+	page, _, err = m.GetListsForOwner(context.Background(), "user-3", 2, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &[]data.List{}, page)
+}
+
+func listNames(lists []data.List) []string {
+	res := []string{}
+	for _, list := range lists {
+		res = append(res, list.Name)
+	}
+	return res
+}
+
+func TestMemoryDBTombstones(t *testing.T) {
+	m := newTestMemoryDB()
+	timestamps := []string{"2021-01-01T00:00:01Z", "2021-01-01T00:00:02Z", "2021-01-01T00:00:03Z", "2021-01-01T00:00:04Z"}
+	m.getTimestamp = func() string {
+		next := timestamps[0]
+		timestamps = timestamps[1:]
+		return next
+	}
+
+	apples, _ := m.CreateItem(context.Background(), "list", data.NewItem{Name: "Apples"})
+	bananas, _ := m.CreateItem(context.Background(), "list", data.NewItem{Name: "Bananas"})
+	assert.NoError(t, m.DeleteItem(context.Background(), "list", apples.ID, nil))
+
+	_, err := m.GetItem(context.Background(), "list", apples.ID)
+	assert.Equal(t, ErrorNotFound, err)
+	_, err = m.UpdateItem(context.Background(), "list", apples.ID, data.ItemUpdate{Name: "Pears"}, nil)
+	assert.Equal(t, ErrorNotFound, err)
+	items, _ := m.GetItemsOnList(context.Background(), "list")
+	assert.Equal(t, []string{"Bananas"}, names(*items))
+
+	changes, err := m.GetItemChanges(context.Background(), "list", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bananas", "Apples"}, names(*changes))
+	assert.Equal(t, "2021-01-01T00:00:03Z", (*changes)[1].DeletedTimestamp)
+	assert.Equal(t, "2021-01-01T00:00:03Z", (*changes)[1].UpdatedTimestamp)
+	assert.Equal(t, int64(2), (*changes)[1].Version)
+
+	changes, err = m.GetItemChanges(context.Background(), "list", bananas.UpdatedTimestamp)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Apples"}, names(*changes))
+
+	// Deleting it again doesn't move the tombstone on
+	assert.NoError(t, m.DeleteItem(context.Background(), "list", apples.ID, nil))
+	changes, _ = m.GetItemChanges(context.Background(), "list", "2021-01-01T00:00:03Z")
+	assert.Empty(t, *changes)
+}
+
+func TestMemoryDBRestoreItem(t *testing.T) {
+	m := newTestMemoryDB()
+	m.retention = time.Hour
+	timestamps := []string{"2021-01-01T00:00:01Z", "2021-01-01T00:00:02Z", "2021-01-01T00:00:03Z", "2021-01-01T00:00:04Z", "2021-01-01T00:00:05Z", "2021-01-01T02:00:00Z"}
+	m.getTimestamp = func() string {
+		next := timestamps[0]
+		timestamps = timestamps[1:]
+		return next
+	}
+
+	apples, _ := m.CreateItem(context.Background(), "list", data.NewItem{Name: "Apples"})
+	bananas, _ := m.CreateItem(context.Background(), "list", data.NewItem{Name: "Bananas"})
+	assert.NoError(t, m.DeleteItem(context.Background(), "list", apples.ID, nil))
+	assert.NoError(t, m.DeleteItem(context.Background(), "list", bananas.ID, nil))
+
+	restored, err := m.RestoreItem(context.Background(), "list", apples.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Apples", restored.Name)
+	assert.Equal(t, "", restored.DeletedTimestamp)
+	assert.Equal(t, int64(0), restored.ExpiresAt)
+	assert.Equal(t, int64(3), restored.Version)
+	assert.Equal(t, "2021-01-01T00:00:05Z", restored.UpdatedTimestamp)
+
+	got, err := m.GetItem(context.Background(), "list", apples.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, restored, got)
+
+	// Restoring it again returns it unchanged
+	again, err := m.RestoreItem(context.Background(), "list", apples.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, restored, again)
+
+	// The bananas' tombstone expired an hour after they were deleted
+	_, err = m.RestoreItem(context.Background(), "list", bananas.ID)
+	assert.Equal(t, ErrorNotFound, err)
+
+	_, err = m.RestoreItem(context.Background(), "list", "missing")
+	assert.Equal(t, ErrorNotFound, err)
+}
+
+func TestMemoryDBActivity(t *testing.T) {
+	m := newTestMemoryDB()
+	timestamps := []string{"2021-01-01T00:00:01Z", "2021-01-01T00:00:02Z", "2021-01-01T00:00:03Z", "2021-01-01T00:00:04Z", "2021-01-01T00:00:05Z"}
+	m.getTimestamp = func() string {
+		next := timestamps[0]
+		timestamps = timestamps[1:]
+		return next
+	}
+	ctx := WithActor(context.Background(), "user-2")
+
+	list, _ := m.CreateList(context.Background(), "Groceries", "user-1")
+	apples, _ := m.CreateItem(ctx, list.ID, data.NewItem{Name: "Apples"})
+	_, err := m.UpdateItem(ctx, list.ID, apples.ID, data.ItemUpdate{Name: "Pears"}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, m.DeleteItem(ctx, list.ID, apples.ID, nil))
+	_, err = m.UpdateList(ctx, list.ID, "Shopping", nil)
+	assert.NoError(t, err)
+
+	activities, nextKey, err := m.GetActivity(context.Background(), list.ID, 2, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &[]data.Activity{
+		{
+			ActivityKey: data.ActivityKey{ListID: list.ID, ID: "2021-01-01T00:00:05.000000000Z#000000004"},
+			Actor:       "user-2",
+			Action:      data.ActivityUpdateList,
+			Before:      map[string]interface{}{"Name": "Groceries"},
+			After:       map[string]interface{}{"Name": "Shopping"},
+			Timestamp:   "2021-01-01T00:00:05Z",
+		},
+		{
+			ActivityKey: data.ActivityKey{ListID: list.ID, ID: "2021-01-01T00:00:04.000000000Z#000000003"},
+			Actor:       "user-2",
+			Action:      data.ActivityDeleteItem,
+			ItemID:      apples.ID,
+			Before:      map[string]interface{}{"Name": "Pears", "IsCompleted": false, "Position": apples.Position},
+			Timestamp:   "2021-01-01T00:00:04Z",
+		},
+	}, activities)
+
+	activities, nextKey, err = m.GetActivity(context.Background(), list.ID, 2, nextKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{data.ActivityUpdateItem, data.ActivityCreateItem}, actions(*activities))
+	assert.Equal(t, map[string]interface{}{"Name": "Apples"}, (*activities)[0].Before)
+	assert.Equal(t, map[string]interface{}{"Name": "Pears"}, (*activities)[0].After)
+
+	activities, nextKey, err = m.GetActivity(context.Background(), list.ID, 2, nextKey)
+	assert.NoError(t, err)
+	assert.Nil(t, nextKey)
+	assert.Equal(t, []string{data.ActivityCreateList}, actions(*activities))
+	assert.Equal(t, "user-1", (*activities)[0].Actor)
+}
+
+func actions(activities []data.Activity) []string {
+	res := []string{}
+	for _, activity := range activities {
+		res = append(res, activity.Action)
+	}
+	return res
+}
+
+func TestMemoryDBIdempotency(t *testing.T) {
+	m := newTestMemoryDB()
+	timestamps := []string{"2021-01-01T00:00:01Z", "2021-01-01T00:00:02Z", "2021-01-01T00:00:03Z", "2021-01-01T00:00:04Z", "2021-01-02T00:00:05Z", "2021-01-02T00:00:06Z", "2021-01-02T00:02:00Z"}
+	m.getTimestamp = func() string {
+		next := timestamps[0]
+		timestamps = timestamps[1:]
+		return next
+	}
+	response := data.IdempotentResponse{StatusCode: 200, Body: `{"Id":"1"}`}
+
+	claim, err := m.StartIdempotentRequest(context.Background(), "key", "fingerprint")
+	assert.NoError(t, err)
+	assert.Equal(t, "fingerprint", claim.Fingerprint)
+	assert.Nil(t, claim.Response)
+
+	// While the request is being handled its record has no response
+	record, err := m.StartIdempotentRequest(context.Background(), "key", "fingerprint")
+	assert.Equal(t, ErrorIDExists, err)
+	assert.Nil(t, record.Response)
+
+	assert.NoError(t, m.CompleteIdempotentRequest(context.Background(), *claim, response))
+	record, err = m.StartIdempotentRequest(context.Background(), "key", "other")
+	assert.Equal(t, ErrorIDExists, err)
+	assert.Equal(t, "fingerprint", record.Fingerprint)
+	assert.Equal(t, &response, record.Response)
+
+	// A day later the key has expired and can be claimed again, the first claim no longer holds it
+	expired := *claim
+	claim, err = m.StartIdempotentRequest(context.Background(), "key", "other")
+	assert.NoError(t, err)
+	assert.Equal(t, "other", claim.Fingerprint)
+	assert.Equal(t, ErrorNotFound, m.CompleteIdempotentRequest(context.Background(), expired, response))
+	assert.Equal(t, ErrorNotFound, m.ReleaseIdempotencyKey(context.Background(), expired))
+
+	// A key whose request never finished is only held for a short while
+	_, err = m.StartIdempotentRequest(context.Background(), "key", "other")
+	assert.Equal(t, ErrorIDExists, err)
+	claim, err = m.StartIdempotentRequest(context.Background(), "key", "other")
+	assert.NoError(t, err)
+
+	assert.NoError(t, m.ReleaseIdempotencyKey(context.Background(), *claim))
+	assert.Equal(t, ErrorNotFound, m.CompleteIdempotentRequest(context.Background(), *claim, response))
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mount-joy/thelist-lambda/metrics"
+)
+
+// instrument has every call made by a client recorded against the metrics.Recorder in the call's context
+func instrument(handlers *request.Handlers) {
+	handlers.Validate.PushBack(requestConsumedCapacity)
+	handlers.Complete.PushBack(recordCall)
+}
+
+// requestConsumedCapacity asks DynamoDB to say how much capacity the call consumed, when the operation can
+func requestConsumedCapacity(r *request.Request) {
+	field := structField(r.Params, "ReturnConsumedCapacity")
+	if field.IsValid() && field.IsNil() && field.CanSet() {
+		field.Set(reflect.ValueOf(aws.String(dynamodb.ReturnConsumedCapacityTotal)))
+	}
+}
+
+// recordCall records the call once it has completed, whether or not it succeeded
+func recordCall(r *request.Request) {
+	metrics.FromContext(r.Context()).RecordDynamoDBCall(time.Since(r.Time), consumedCapacity(r.Data))
+}
+
+// consumedCapacity adds up the capacity in an output, which has one ConsumedCapacity or one per table
+func consumedCapacity(output interface{}) float64 {
+	field := structField(output, "ConsumedCapacity")
+	if !field.IsValid() {
+		return 0
+	}
+
+	total := 0.0
+	switch consumed := field.Interface().(type) {
+	case *dynamodb.ConsumedCapacity:
+		total += capacityUnits(consumed)
+	case []*dynamodb.ConsumedCapacity:
+		for _, c := range consumed {
+			total += capacityUnits(c)
+		}
+	}
+	return total
+}
+
+func capacityUnits(consumed *dynamodb.ConsumedCapacity) float64 {
+	if consumed == nil {
+		return 0
+	}
+	return aws.Float64Value(consumed.CapacityUnits)
+}
+
+// structField returns the field called name of the struct value points to, or an invalid Value if there isn't one
+func structField(value interface{}, name string) reflect.Value {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	return v.Elem().FieldByName(name)
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mount-joy/thelist-lambda/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestConsumedCapacity(t *testing.T) {
+	input := &dynamodb.GetItemInput{}
+	requestConsumedCapacity(&request.Request{Params: input})
+	assert.Equal(t, aws.String("TOTAL"), input.ReturnConsumedCapacity)
+
+	indexes := &dynamodb.QueryInput{ReturnConsumedCapacity: aws.String("INDEXES")}
+	requestConsumedCapacity(&request.Request{Params: indexes})
+	assert.Equal(t, aws.String("INDEXES"), indexes.ReturnConsumedCapacity)
+
+	assert.NotPanics(t, func() { requestConsumedCapacity(&request.Request{Params: &dynamodb.DescribeTableInput{}}) })
+}
+
+func TestRecordCall(t *testing.T) {
+	tests := []struct {
+		name             string
+		data             interface{}
+		expectedCapacity float64
+	}{
+		{
+			name:             "Records the capacity of a call to one table",
+			data:             &dynamodb.GetItemOutput{ConsumedCapacity: &dynamodb.ConsumedCapacity{CapacityUnits: aws.Float64(0.5)}},
+			expectedCapacity: 0.5,
+		},
+		{
+			name: "Records the capacity of a call to several tables",
+			data: &dynamodb.BatchWriteItemOutput{ConsumedCapacity: []*dynamodb.ConsumedCapacity{
+				{CapacityUnits: aws.Float64(2)},
+				{CapacityUnits: aws.Float64(1)},
+			}},
+			expectedCapacity: 3,
+		},
+		{
+			name: "Records a call without consumed capacity",
+			data: &dynamodb.PutItemOutput{},
+		},
+		{
+			name: "Records a call which failed before there was an output",
+			data: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &metrics.Recorder{}
+			r := &request.Request{Data: tt.data, Time: time.Now().Add(-time.Second), HTTPRequest: &http.Request{}}
+			r.SetContext(metrics.NewContext(context.Background(), recorder))
+
+			recordCall(r)
+
+			usage := recorder.DynamoDBUsage()
+			assert.Equal(t, 1, usage.Calls)
+			assert.GreaterOrEqual(t, int64(usage.Latency), int64(time.Second))
+			assert.Equal(t, tt.expectedCapacity, usage.ConsumedCapacity)
+		})
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		gotBody = string(body)
+		fmt.Fprint(w, `{"Item": {"Id": {"S": "item-1"}}, "ConsumedCapacity": {"CapacityUnits": 0.5, "TableName": "items-table"}}`)
+	}))
+	defer server.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("eu-west-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+	client := dynamodb.New(sess)
+	instrument(&client.Handlers)
+
+	recorder := &metrics.Recorder{}
+	d := dynamoDB{session: client, conf: testConfig}
+	_, err := d.GetItem(metrics.NewContext(context.Background(), recorder), "list-1", "item-1")
+
+	assert.NoError(t, err)
+	assert.Contains(t, gotBody, `"ReturnConsumedCapacity":"TOTAL"`)
+	assert.Equal(t, 1, recorder.DynamoDBUsage().Calls)
+	assert.Equal(t, 0.5, recorder.DynamoDBUsage().ConsumedCapacity)
+}
@@ -0,0 +1,250 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// positionGap is the space left between items when there is nothing to fit them between
+const positionGap float64 = 1024
+
+// newItemPosition places a new item after everything already on the list, using the time it was created
+// Items added by hand are seconds apart, which leaves plenty of room to move items in between them later
+func newItemPosition(timestamp string) float64 {
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return 0
+	}
+	return float64(t.UnixNano() / int64(time.Millisecond))
+}
+
+// sortByPosition orders items by their position, items with the same position keep their existing order
+func sortByPosition(items []data.Item) {
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Position < items[j].Position })
+}
+
+// maxMoves is the most items a reorder can move, they are all moved in one transaction
+const maxMoves = maxTransactionChanges
+
+// checkMoves rejects a reorder which would move more items than can be moved at once
+func checkMoves(moves int) error {
+	if moves > maxMoves {
+		return fmt.Errorf("%w: the new order moves %d items, at most %d can be moved at once", ErrorBadRequest, moves, maxMoves)
+	}
+	return nil
+}
+
+// planReorder works out the new positions needed to put itemIDs in the given order
+// The items in itemIDs take the places on the list those items had between them, so items missing from the
+// request stay where they are, both in the list's order and, unless the list has to be renumbered, in position
+// Only the items which have to move are returned, mapped to their new position
+func planReorder(items []data.Item, itemIDs []string) (map[string]float64, error) {
+	byID := make(map[string]data.Item, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	listed := make(map[string]bool, len(itemIDs))
+	for _, id := range itemIDs {
+		if _, ok := byID[id]; !ok {
+			return nil, fmt.Errorf("%w: item %q is not on the list", ErrorBadRequest, id)
+		}
+		if listed[id] {
+			return nil, fmt.Errorf("%w: item %q appears more than once", ErrorBadRequest, id)
+		}
+		listed[id] = true
+	}
+
+	sorted := make([]data.Item, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	sortByPosition(sorted)
+
+	// Fill the places of the listed items with them in their new order
+	order := make([]data.Item, len(sorted))
+	pinned := make([]bool, len(sorted))
+	next := 0
+	for i, item := range sorted {
+		if listed[item.ID] {
+			order[i] = byID[itemIDs[next]]
+			next++
+		} else {
+			order[i] = item
+			pinned[i] = true
+		}
+	}
+
+	current := make([]float64, len(order))
+	for i, item := range order {
+		current[i] = item.Position
+	}
+
+	changes := map[string]float64{}
+	for i, position := range rankPositions(current, pinned) {
+		if position != current[i] {
+			changes[order[i].ID] = position
+		}
+	}
+	return changes, nil
+}
+
+// rankPositions returns strictly increasing positions for items currently at the given positions
+// The longest run of items already in order keeps its positions and every other item is slotted into
+// the gaps between them, so moving one item only changes that item's position
+// Pinned items are kept in preference to the others, every item is renumbered if there's no room left between them
+func rankPositions(current []float64, pinned []bool) []float64 {
+	keep := keepPositions(current, pinned)
+
+	res := make([]float64, len(current))
+	for i := 0; i < len(current); {
+		if keep[i] {
+			res[i] = current[i]
+			i++
+			continue
+		}
+
+		// Find the run of items which need new positions and fit them between their neighbours
+		end := i
+		for end < len(current) && !keep[end] {
+			end++
+		}
+		positions, ok := between(res, i, end, current)
+		if !ok {
+			return renumber(len(current))
+		}
+		copy(res[i:end], positions)
+		i = end
+	}
+
+	return res
+}
+
+// between spreads the items in res[start:end] evenly between their neighbours
+func between(res []float64, start int, end int, current []float64) ([]float64, bool) {
+	count := end - start
+	positions := make([]float64, count)
+
+	hasLow, hasHigh := start > 0, end < len(current)
+	var low, high float64
+	if hasLow {
+		low = res[start-1]
+	}
+	if hasHigh {
+		high = current[end]
+	}
+
+	for j := range positions {
+		switch {
+		case hasLow && hasHigh:
+			positions[j] = low + (high-low)*float64(j+1)/float64(count+1)
+		case hasLow:
+			positions[j] = low + positionGap*float64(j+1)
+		case hasHigh:
+			positions[j] = high - positionGap*float64(count-j)
+		default:
+			positions[j] = positionGap * float64(j+1)
+		}
+	}
+
+	// Once the gaps get too small for a float64 to split, the only option is to start again
+	previous := low
+	for j, position := range positions {
+		if (hasLow || j > 0) && position <= previous {
+			return nil, false
+		}
+		previous = position
+	}
+	if hasHigh && previous >= high {
+		return nil, false
+	}
+	return positions, true
+}
+
+func renumber(count int) []float64 {
+	res := make([]float64, count)
+	for i := range res {
+		res[i] = positionGap * float64(i+1)
+	}
+	return res
+}
+
+// keepPositions marks the items which keep their positions: as many of the pinned items as are in order,
+// along with the longest run of the other items which fits in order between them
+func keepPositions(values []float64, pinned []bool) []bool {
+	anchors := []int{}
+	for i := range values {
+		if i < len(pinned) && pinned[i] {
+			anchors = append(anchors, i)
+		}
+	}
+	anchorValues := make([]float64, len(anchors))
+	for k, i := range anchors {
+		anchorValues[k] = values[i]
+	}
+
+	keep := make([]bool, len(values))
+	kept := []int{}
+	for k, isKept := range longestIncreasing(anchorValues) {
+		if isKept {
+			keep[anchors[k]] = true
+			kept = append(kept, anchors[k])
+		}
+	}
+
+	// Between each pair of kept anchors, keep the longest run of the other items whose positions lie between theirs
+	start := 0
+	for k := 0; k <= len(kept); k++ {
+		end := len(values)
+		if k < len(kept) {
+			end = kept[k]
+		}
+		candidates := []int{}
+		for i := start; i < end; i++ {
+			if (k > 0 && values[i] <= values[kept[k-1]]) || (k < len(kept) && values[i] >= values[end]) {
+				continue
+			}
+			candidates = append(candidates, i)
+		}
+		candidateValues := make([]float64, len(candidates))
+		for c, i := range candidates {
+			candidateValues[c] = values[i]
+		}
+		for c, isKept := range longestIncreasing(candidateValues) {
+			keep[candidates[c]] = isKept
+		}
+		start = end + 1
+	}
+	return keep
+}
+
+// longestIncreasing marks the entries making up the longest strictly increasing subsequence of values
+func longestIncreasing(values []float64) []bool {
+	// tails[k] is the index of the smallest value ending an increasing run of length k+1
+	tails := []int{}
+	previous := make([]int, len(values))
+	for i, value := range values {
+		k := sort.Search(len(tails), func(k int) bool { return values[tails[k]] >= value })
+		if k > 0 {
+			previous[i] = tails[k-1]
+		} else {
+			previous[i] = -1
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+
+	keep := make([]bool, len(values))
+	if len(tails) == 0 {
+		return keep
+	}
+	for i := tails[len(tails)-1]; i >= 0; i = previous[i] {
+		keep[i] = true
+	}
+	return keep
+}
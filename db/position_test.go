@@ -0,0 +1,184 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewItemPosition(t *testing.T) {
+	earlier := newItemPosition("2020-01-23T09:59:14.9396531Z")
+	later := newItemPosition("2020-01-23T09:59:16.0000000Z")
+
+	assert.Equal(t, 1579773554939.0, earlier)
+	assert.Less(t, earlier, later)
+	assert.Equal(t, 0.0, newItemPosition("not a timestamp"))
+}
+
+func TestRankPositions(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  []float64
+		expected []float64
+	}{
+		{
+			name:     "Items already in order keep their positions",
+			current:  []float64{1, 2, 3},
+			expected: []float64{1, 2, 3},
+		},
+		{
+			name:     "Moving an item into the middle only changes that item",
+			current:  []float64{10, 30, 20, 40},
+			expected: []float64{10, 15, 20, 40},
+		},
+		{
+			name:     "Moving an item to the start places it before the first item",
+			current:  []float64{4000, 1000, 2000, 3000},
+			expected: []float64{1000 - positionGap, 1000, 2000, 3000},
+		},
+		{
+			name:     "Moving an item to the end places it after the last item",
+			current:  []float64{2000, 3000, 1000},
+			expected: []float64{2000, 3000, 3000 + positionGap},
+		},
+		{
+			name:     "Several items are spread evenly across the gap",
+			current:  []float64{0, 40, 30, 20, 100},
+			expected: []float64{0, 20.0 / 3, 40.0 / 3, 20, 100},
+		},
+		{
+			name:     "Items with the same position are separated",
+			current:  []float64{0, 0, 0},
+			expected: []float64{-2 * positionGap, -positionGap, 0},
+		},
+		{
+			name:     "When there is no room left everything is renumbered",
+			current:  []float64{1, 1 + 2.220446049250313e-16*2, 1 + 2.220446049250313e-16},
+			expected: []float64{positionGap, 2 * positionGap, 3 * positionGap},
+		},
+		{
+			name:     "No items gives no positions",
+			current:  []float64{},
+			expected: []float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rankPositions(tt.current, nil)
+
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestPlanReorder(t *testing.T) {
+	items := []data.Item{
+		{ItemKey: data.ItemKey{ID: "a"}, Position: 1000},
+		{ItemKey: data.ItemKey{ID: "b"}, Position: 2000},
+		{ItemKey: data.ItemKey{ID: "c"}, Position: 3000},
+	}
+
+	tests := []struct {
+		name        string
+		itemIDs     []string
+		expectedRes map[string]float64
+		expectedErr error
+	}{
+		{
+			name:        "Only the moved item changes",
+			itemIDs:     []string{"b", "a", "c"},
+			expectedRes: map[string]float64{"b": 1000 - positionGap},
+		},
+		{
+			name:        "Swapping two items only moves one of them",
+			itemIDs:     []string{"a", "c", "b"},
+			expectedRes: map[string]float64{"c": 1500},
+		},
+		{
+			name:        "Nothing changes when the order is the same",
+			itemIDs:     []string{"a", "b", "c"},
+			expectedRes: map[string]float64{},
+		},
+		{
+			name:        "Items missing from the request keep their place",
+			itemIDs:     []string{"c", "a"},
+			expectedRes: map[string]float64{"c": 2000 - positionGap, "a": 2000 + positionGap},
+		},
+		{
+			name:        "Unknown items are rejected",
+			itemIDs:     []string{"a", "z"},
+			expectedErr: ErrorBadRequest,
+		},
+		{
+			name:        "Repeated items are rejected",
+			itemIDs:     []string{"a", "b", "a"},
+			expectedErr: ErrorBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRes, gotErr := planReorder(items, tt.itemIDs)
+
+			assert.Equal(t, tt.expectedRes, gotRes)
+			assert.True(t, errors.Is(gotErr, tt.expectedErr), "expected %v, got %v", tt.expectedErr, gotErr)
+		})
+	}
+}
+
+func TestPlanReorderKeepsItemsMissingFromTheRequest(t *testing.T) {
+	ulp := 2.220446049250313e-16
+
+	tests := []struct {
+		name        string
+		items       []data.Item
+		itemIDs     []string
+		expectedRes map[string]float64
+	}{
+		{
+			name: "Listed items swap places around the items which aren't listed, which keep their positions",
+			items: []data.Item{
+				{ItemKey: data.ItemKey{ID: "a"}, Position: 1000},
+				{ItemKey: data.ItemKey{ID: "b"}, Position: 2000},
+				{ItemKey: data.ItemKey{ID: "c"}, Position: 3000},
+				{ItemKey: data.ItemKey{ID: "d"}, Position: 4000},
+				{ItemKey: data.ItemKey{ID: "e"}, Position: 5000},
+			},
+			itemIDs:     []string{"e", "c", "a"},
+			expectedRes: map[string]float64{"e": 2000 - positionGap, "a": 4000 + positionGap},
+		},
+		{
+			name: "When there is no room left the whole list is renumbered, keeping the items which aren't listed in their place",
+			items: []data.Item{
+				{ItemKey: data.ItemKey{ID: "a"}, Position: 1},
+				{ItemKey: data.ItemKey{ID: "u"}, Position: 1 + ulp},
+				{ItemKey: data.ItemKey{ID: "b"}, Position: 1 + 2*ulp},
+				{ItemKey: data.ItemKey{ID: "c"}, Position: 1 + 3*ulp},
+			},
+			itemIDs:     []string{"a", "c", "b"},
+			expectedRes: map[string]float64{"a": positionGap, "u": 2 * positionGap, "c": 3 * positionGap, "b": 4 * positionGap},
+		},
+		{
+			name: "Items with the same position are ordered by ID",
+			items: []data.Item{
+				{ItemKey: data.ItemKey{ID: "c"}, Position: 0},
+				{ItemKey: data.ItemKey{ID: "b"}, Position: 0},
+				{ItemKey: data.ItemKey{ID: "a"}, Position: 0},
+			},
+			itemIDs:     []string{"c", "a"},
+			expectedRes: map[string]float64{"c": -positionGap, "a": positionGap},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRes, gotErr := planReorder(tt.items, tt.itemIDs)
+
+			assert.NoError(t, gotErr)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
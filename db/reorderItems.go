@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// ReorderItems moves the items into the order given, recording each item which moved in the list's activity
+// The items are moved in a single transaction, so the list is never left partly reordered, which bounds how many can move
+func (d *dynamoDB) ReorderItems(ctx context.Context, listID string, itemIDs []string) (*[]data.Item, error) {
+	items, err := d.GetItemsOnList(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	positions, err := planReorder(*items, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkMoves(len(positions)); err != nil {
+		return nil, err
+	}
+
+	timestamp := d.getTimestamp()
+	changes := []change{}
+	moved := []int{}
+	for i, item := range *items {
+		position, ok := positions[item.ID]
+		if !ok {
+			continue
+		}
+
+		c, err := d.positionChange(ctx, item, position, timestamp)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+		moved = append(moved, i)
+	}
+
+	// An item which changed since it was read might have been moved or deleted, so the order asked for may no longer make sense
+	err = d.transactAll(ctx, changes)
+	if err == errConditionFailed {
+		return nil, ErrorPreconditionFailed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range moved {
+		item := &(*items)[i]
+		item.Position = positions[item.ID]
+		item.UpdatedTimestamp = timestamp
+		item.Version++
+	}
+
+	sortByPosition(*items)
+	return items, nil
+}
+
+// positionChange returns the change which moves item to position, as long as it hasn't changed since it was read
+func (d *dynamoDB) positionChange(ctx context.Context, item data.Item, position float64, timestamp string) (change, error) {
+	key, err := dynamodbattribute.MarshalMap(&item.ItemKey)
+	if err != nil {
+		return change{}, err
+	}
+
+	moved := item
+	moved.Position = position
+	activity, err := newActivity(ctx, item.ListID, d.generateID(), data.ActivityUpdateItem, item.ID, &item, &moved, timestamp)
+	if err != nil {
+		return change{}, err
+	}
+
+	condition, conditionValues := itemCondition(&item.Version)
+	return change{activity: activity, write: &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			ExpressionAttributeValues: mergeValues(map[string]*dynamodb.AttributeValue{
+				":p":   {N: aws.String(strconv.FormatFloat(position, 'f', -1, 64))},
+				":t":   {S: &timestamp},
+				":one": {N: aws.String("1")},
+			}, conditionValues),
+			Key:                 key,
+			TableName:           aws.String(d.conf.TableNames.Items),
+			UpdateExpression:    aws.String("SET Position = :p, Updated = :t ADD Version :one"),
+			ConditionExpression: condition,
+		},
+	}}, nil
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReorderItems(t *testing.T) {
+	listID := "474c2Fff7"
+	timestamp := "2020-01-23T09:59:14.9396531Z"
+	storedItems := &dynamodb.QueryOutput{
+		Items: []map[string]*dynamodb.AttributeValue{
+			{"ListId": {S: aws.String(listID)}, "Id": {S: aws.String("a")}, "Position": {N: aws.String("1000")}, "Version": {N: aws.String("3")}},
+			{"ListId": {S: aws.String(listID)}, "Id": {S: aws.String("b")}, "Position": {N: aws.String("2000")}, "Version": {N: aws.String("3")}},
+			{"ListId": {S: aws.String(listID)}, "Id": {S: aws.String("c")}, "Position": {N: aws.String("3000")}, "Version": {N: aws.String("3")}},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		itemIDs           []string
+		expectUpdate      bool
+		mockedErrResponse error
+		expectedRes       *[]data.Item
+		expectedErr       error
+	}{
+		{
+			name:         "Only the item which moved is updated, and the move is recorded",
+			itemIDs:      []string{"b", "a", "c"},
+			expectUpdate: true,
+			expectedRes: &[]data.Item{
+				{ItemKey: data.ItemKey{ID: "b", ListID: listID}, Position: -24, Version: 4, UpdatedTimestamp: timestamp},
+				{ItemKey: data.ItemKey{ID: "a", ListID: listID}, Position: 1000, Version: 3},
+				{ItemKey: data.ItemKey{ID: "c", ListID: listID}, Position: 3000, Version: 3},
+			},
+		},
+		{
+			name:        "Items which aren't on the list are rejected",
+			itemIDs:     []string{"b", "z"},
+			expectedErr: ErrorBadRequest,
+		},
+		{
+			name:              "If an item has changed in the meantime, precondition failed is returned",
+			itemIDs:           []string{"b", "a", "c"},
+			expectUpdate:      true,
+			mockedErrResponse: transactionCanceled(),
+			expectedErr:       ErrorPreconditionFailed,
+		},
+		{
+			name:              "When db returns an error, that error is returned",
+			itemIDs:           []string{"b", "a", "c"},
+			expectUpdate:      true,
+			mockedErrResponse: errors.New("Something went wrong"),
+			expectedErr:       errors.New("Something went wrong"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			queryInput := dynamodb.QueryInput{
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":id": {S: &listID}},
+				KeyConditionExpression:    aws.String("ListId = :id"),
+				FilterExpression:          aws.String("attribute_not_exists(DeletedAt)"),
+				TableName:                 aws.String("items-table"),
+			}
+			dbMocked.
+				On("Query", &queryInput).
+				Return(storedItems, nil).
+				Once()
+
+			if tt.expectUpdate {
+				input := dynamodb.TransactWriteItemsInput{
+					TransactItems: []*dynamodb.TransactWriteItem{
+						{
+							Update: &dynamodb.Update{
+								ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+									":p":   {N: aws.String("-24")},
+									":t":   {S: &timestamp},
+									":one": {N: aws.String("1")},
+									":v":   {N: aws.String("3")},
+								},
+								Key:                 map[string]*dynamodb.AttributeValue{"Id": {S: aws.String("b")}, "ListId": {S: &listID}},
+								TableName:           aws.String("items-table"),
+								UpdateExpression:    aws.String("SET Position = :p, Updated = :t ADD Version :one"),
+								ConditionExpression: aws.String("attribute_exists(Id) AND Version = :v AND attribute_not_exists(DeletedAt)"),
+							},
+						},
+						activityWrite(data.Activity{
+							ActivityKey: data.ActivityKey{ListID: listID, ID: "2020-01-23T09:59:14.939653100Z#activity-id"},
+							Actor:       testActor,
+							Action:      data.ActivityUpdateItem,
+							ItemID:      "b",
+							Before:      map[string]interface{}{"Position": 2000.0},
+							After:       map[string]interface{}{"Position": -24.0},
+							Timestamp:   timestamp,
+						}),
+					},
+				}
+				dbMocked.
+					On("TransactWriteItems", &input).
+					Return(&dynamodb.TransactWriteItemsOutput{}, tt.mockedErrResponse).
+					Once()
+			}
+
+			d := dynamoDB{
+				session:      dbMocked,
+				conf:         testConfig,
+				generateID:   func() string { return "activity-id" },
+				getTimestamp: func() string { return timestamp },
+			}
+			gotRes, gotErr := d.ReorderItems(WithActor(context.Background(), testActor), listID, tt.itemIDs)
+
+			if tt.expectedErr == ErrorBadRequest {
+				assert.True(t, errors.Is(gotErr, ErrorBadRequest))
+			} else {
+				assert.Equal(t, tt.expectedErr, gotErr)
+			}
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// RestoreItem turns a tombstone back into the item it was, as long as it hasn't expired, recording it in the list's activity
+// Restoring an item which isn't deleted returns it unchanged
+func (d *dynamoDB) RestoreItem(ctx context.Context, listID string, itemID string) (*data.Item, error) {
+	var restored *data.Item
+	err := retryConditionFailed(nil, func() error {
+		var err error
+		restored, err = d.restoreItem(ctx, listID, itemID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+func (d *dynamoDB) restoreItem(ctx context.Context, listID string, itemID string) (*data.Item, error) {
+	tableName := d.conf.TableNames.Items
+	if len(tableName) == 0 {
+		panic("Items table name not set")
+	}
+
+	item, err := d.getItem(ctx, listID, itemID, true)
+	if err != nil {
+		return nil, err
+	}
+	if item.DeletedTimestamp == "" {
+		return item, nil
+	}
+
+	// DynamoDB can take a while to purge expired items, they mustn't come back in the meantime
+	timestamp := d.getTimestamp()
+	if item.ExpiresAt <= unixSeconds(timestamp) {
+		return nil, ErrorNotFound
+	}
+
+	restored := *item
+	restored.DeletedTimestamp = ""
+	restored.ExpiresAt = 0
+	restored.UpdatedTimestamp = timestamp
+	restored.Version++
+	activity, err := newActivity(ctx, listID, d.generateID(), data.ActivityRestoreItem, itemID, nil, &restored, timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	condition, conditionValues := versionCondition(&item.Version)
+	err = d.transactWrite(ctx, activity, &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			Key: map[string]*dynamodb.AttributeValue{
+				"ListId": {S: &listID},
+				"Id":     {S: &itemID},
+			},
+			TableName:        aws.String(tableName),
+			UpdateExpression: aws.String("SET Updated = :t REMOVE DeletedAt, ExpiresAt ADD Version :one"),
+			ExpressionAttributeValues: mergeValues(map[string]*dynamodb.AttributeValue{
+				":t":   {S: &timestamp},
+				":one": {N: aws.String("1")},
+			}, conditionValues),
+			ConditionExpression: aws.String(*condition + " AND attribute_exists(DeletedAt)"),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &restored, nil
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreItem(t *testing.T) {
+	listID := "474c2Fff7"
+	itemID := "b6cf642d"
+	timestamp := "2020-01-23T09:59:14.9396531Z"
+	deletedAt := "2020-01-22T09:00:00Z"
+	key := data.ItemKey{ID: itemID, ListID: listID}
+	tombstone := &data.Item{ItemKey: key, Name: "Milk", Version: 3, UpdatedTimestamp: deletedAt, DeletedTimestamp: deletedAt, ExpiresAt: 1579773555}
+	restored := &data.Item{ItemKey: key, Name: "Milk", Version: 4, UpdatedTimestamp: timestamp}
+
+	tests := []struct {
+		name         string
+		existing     *data.Item
+		getErr       error
+		transactErrs []error
+		expectedRes  *data.Item
+		expectedErr  error
+	}{
+		{
+			name:         "If the item is a tombstone which hasn't expired it is restored and the restore is recorded",
+			existing:     tombstone,
+			transactErrs: []error{nil},
+			expectedRes:  restored,
+		},
+		{
+			name:         "If the tombstone changes after it's read, it is read again",
+			existing:     tombstone,
+			transactErrs: []error{transactionCanceled(), nil},
+			expectedRes:  restored,
+		},
+		{
+			name:        "If the item isn't deleted it is returned unchanged",
+			existing:    &data.Item{ItemKey: key, Name: "Milk", Version: 3},
+			expectedRes: &data.Item{ItemKey: key, Name: "Milk", Version: 3},
+		},
+		{
+			name:        "If the tombstone has expired, not found is returned",
+			existing:    &data.Item{ItemKey: key, Name: "Milk", Version: 3, DeletedTimestamp: deletedAt, ExpiresAt: 1579773554},
+			expectedErr: ErrorNotFound,
+		},
+		{
+			name:        "If the item doesn't exist, not found is returned",
+			expectedErr: ErrorNotFound,
+		},
+		{
+			name:        "When reading the item returns an error, that error is returned",
+			getErr:      errors.New("Something went wrong"),
+			expectedErr: errors.New("Something went wrong"),
+		},
+		{
+			name:         "When the write returns an error, that error is returned",
+			existing:     tombstone,
+			transactErrs: []error{errors.New("Something went wrong")},
+			expectedErr:  errors.New("Something went wrong"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			dynamoKey := map[string]*dynamodb.AttributeValue{
+				"ListId": {S: &listID},
+				"Id":     {S: &itemID},
+			}
+			getOutput := &dynamodb.GetItemOutput{}
+			if tt.existing != nil {
+				getOutput.Item, _ = dynamodbattribute.MarshalMap(tt.existing)
+			}
+			reads := len(tt.transactErrs)
+			if reads == 0 {
+				reads = 1
+			}
+			dbMocked.
+				On("GetItem", &dynamodb.GetItemInput{Key: dynamoKey, TableName: stringToPointer("items-table"), ConsistentRead: boolToPointer(true)}).
+				Return(getOutput, tt.getErr).
+				Times(reads)
+
+			for _, transactErr := range tt.transactErrs {
+				dbMocked.
+					On("TransactWriteItems", &dynamodb.TransactWriteItemsInput{
+						TransactItems: []*dynamodb.TransactWriteItem{
+							{
+								Update: &dynamodb.Update{
+									Key:              dynamoKey,
+									TableName:        stringToPointer("items-table"),
+									UpdateExpression: stringToPointer("SET Updated = :t REMOVE DeletedAt, ExpiresAt ADD Version :one"),
+									ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+										":t":   {S: &timestamp},
+										":one": {N: stringToPointer("1")},
+										":v":   {N: stringToPointer("3")},
+									},
+									ConditionExpression: stringToPointer("attribute_exists(Id) AND Version = :v AND attribute_exists(DeletedAt)"),
+								},
+							},
+							activityWrite(data.Activity{
+								ActivityKey: data.ActivityKey{ListID: listID, ID: "2020-01-23T09:59:14.939653100Z#activity-id"},
+								Actor:       testActor,
+								Action:      data.ActivityRestoreItem,
+								ItemID:      itemID,
+								After:       map[string]interface{}{"Name": "Milk", "IsCompleted": false, "Position": 0.0},
+								Timestamp:   timestamp,
+							}),
+						},
+					}).
+					Return(&dynamodb.TransactWriteItemsOutput{}, transactErr).
+					Once()
+			}
+
+			d := dynamoDB{
+				session:      dbMocked,
+				conf:         testConfig,
+				generateID:   func() string { return "activity-id" },
+				getTimestamp: func() string { return timestamp },
+			}
+			gotRes, gotErr := d.RestoreItem(WithActor(context.Background(), testActor), listID, itemID)
+
+			assert.Equal(t, tt.expectedErr, gotErr)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
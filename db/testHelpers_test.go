@@ -1,9 +1,15 @@
 package db
 
 import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/mount-joy/thelist-lambda/config"
+	"github.com/mount-joy/thelist-lambda/data"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -12,37 +18,55 @@ type mockDB struct {
 	dynamodbiface.DynamoDBAPI
 }
 
-func (m *mockDB) GetItem(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
-	args := m.Called(input)
+func (m *mockDB) GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	args := m.MethodCalled("GetItem", input)
 	return args.Get(0).(*dynamodb.GetItemOutput), args.Error(1)
 }
 
-func (m *mockDB) DeleteItem(input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
-	args := m.Called(input)
+func (m *mockDB) DeleteItemWithContext(ctx aws.Context, input *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	args := m.MethodCalled("DeleteItem", input)
 	return args.Get(0).(*dynamodb.DeleteItemOutput), args.Error(1)
 }
 
-func (m *mockDB) Query(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
-	args := m.Called(input)
+func (m *mockDB) BatchWriteItemWithContext(ctx aws.Context, input *dynamodb.BatchWriteItemInput, opts ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	args := m.MethodCalled("BatchWriteItem", input)
+	return args.Get(0).(*dynamodb.BatchWriteItemOutput), args.Error(1)
+}
+
+func (m *mockDB) QueryWithContext(ctx aws.Context, input *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	args := m.MethodCalled("Query", input)
 	return args.Get(0).(*dynamodb.QueryOutput), args.Error(1)
 }
 
-func (m *mockDB) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
-	args := m.Called(input)
+func (m *mockDB) ScanWithContext(ctx aws.Context, input *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+	args := m.MethodCalled("Scan", input)
+	return args.Get(0).(*dynamodb.ScanOutput), args.Error(1)
+}
+
+func (m *mockDB) PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	args := m.MethodCalled("PutItem", input)
 	return args.Get(0).(*dynamodb.PutItemOutput), args.Error(1)
 }
 
-func (m *mockDB) UpdateItem(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
-	args := m.Called(input)
+func (m *mockDB) UpdateItemWithContext(ctx aws.Context, input *dynamodb.UpdateItemInput, opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	args := m.MethodCalled("UpdateItem", input)
 	return args.Get(0).(*dynamodb.UpdateItemOutput), args.Error(1)
 }
 
+func (m *mockDB) TransactWriteItemsWithContext(ctx aws.Context, input *dynamodb.TransactWriteItemsInput, opts ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	args := m.MethodCalled("TransactWriteItems", input)
+	return args.Get(0).(*dynamodb.TransactWriteItemsOutput), args.Error(1)
+}
+
 var testConfig config.Config = config.Config{
 	Endpoint: "db://thelist",
 	TableNames: config.TableNames{
-		Items: "items-table",
-		Lists: "lists-table",
+		Activity:    "activity-table",
+		Idempotency: "idempotency-table",
+		Items:       "items-table",
+		Lists:       "lists-table",
 	},
+	TombstoneRetention: 24 * time.Hour,
 }
 
 func stringToPointer(input string) *string {
@@ -52,3 +76,54 @@ func stringToPointer(input string) *string {
 func boolToPointer(input bool) *bool {
 	return &input
 }
+
+// testActor is the caller the activity is recorded against in tests
+const testActor = "user-1"
+
+// activityWrite returns the write transactWrite adds for activity
+func activityWrite(activity data.Activity) *dynamodb.TransactWriteItem {
+	item, _ := dynamodbattribute.MarshalMap(activity)
+	return &dynamodb.TransactWriteItem{
+		Put: &dynamodb.Put{
+			TableName:           stringToPointer("activity-table"),
+			Item:                item,
+			ConditionExpression: stringToPointer("attribute_not_exists(Id)"),
+		},
+	}
+}
+
+// transactionCanceled returns the error for a transaction where a write's condition failed
+func transactionCanceled() error {
+	return &dynamodb.TransactionCanceledException{
+		CancellationReasons: []*dynamodb.CancellationReason{{Code: stringToPointer("None")}, {Code: stringToPointer("ConditionalCheckFailed")}},
+	}
+}
+
+// createWrite returns the write which puts a new item, as CreateItem and BatchWriteItems make it
+func createWrite(item map[string]*dynamodb.AttributeValue) *dynamodb.TransactWriteItem {
+	return &dynamodb.TransactWriteItem{
+		Put: &dynamodb.Put{
+			Item:                item,
+			TableName:           stringToPointer("items-table"),
+			ConditionExpression: stringToPointer("attribute_not_exists(Id)"),
+		},
+	}
+}
+
+// deleteWrite returns the write which replaces the item at version with its tombstone, which is purged at expires
+func deleteWrite(listID string, itemID string, version string, timestamp string, expires string) *dynamodb.TransactWriteItem {
+	return &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			Key:              map[string]*dynamodb.AttributeValue{"Id": {S: &itemID}, "ListId": {S: &listID}},
+			TableName:        stringToPointer("items-table"),
+			UpdateExpression: stringToPointer("SET DeletedAt = :t, Updated = :t, ExpiresAt = :expires ADD Version :one"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":t":       {S: &timestamp},
+				":expires": {N: &expires},
+				":one":     {N: stringToPointer("1")},
+				":v":       {N: &version},
+			},
+			ConditionExpression: stringToPointer("attribute_exists(Id) AND Version = :v AND attribute_not_exists(DeletedAt)"),
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// notDeleted is the filter which leaves out the tombstones of deleted items
+const notDeleted = "attribute_not_exists(DeletedAt)"
+
+// itemCondition returns the condition for writing to an item which must exist and not have been deleted,
+// along with the values to use in it, see versionCondition
+func itemCondition(expectedVersion *int64) (*string, map[string]*dynamodb.AttributeValue) {
+	condition, values := versionCondition(expectedVersion)
+	return aws.String(*condition + " AND " + notDeleted), values
+}
+
+// tombstone returns item marked as deleted at timestamp, it keeps every field so it can be restored until it expires
+func tombstone(item data.Item, timestamp string, retention time.Duration) data.Item {
+	item.DeletedTimestamp = timestamp
+	item.UpdatedTimestamp = timestamp
+	item.ExpiresAt = expiresAt(timestamp, retention)
+	item.Version++
+	return item
+}
+
+// expiresAt returns when a tombstone written at timestamp is purged, in seconds since the epoch
+func expiresAt(timestamp string, retention time.Duration) int64 {
+	return unixSeconds(timestamp) + int64(retention/time.Second)
+}
+
+// unixSeconds converts a timestamp from getTimestamp to seconds since the epoch, as used by DynamoDB's TTL
+func unixSeconds(timestamp string) int64 {
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		t = time.Now()
+	}
+	return t.Unix()
+}
+
+// withoutTombstones returns the items which haven't been deleted
+func withoutTombstones(items []data.Item) []data.Item {
+	live := make([]data.Item, 0, len(items))
+	for _, item := range items {
+		if item.DeletedTimestamp == "" {
+			live = append(live, item)
+		}
+	}
+	return live
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/mount-joy/thelist-lambda/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type spanKey struct{}
+
+// traceCalls has a span recorded for every call made by a client, as a child of the span in the call's context
+func traceCalls(handlers *request.Handlers) {
+	handlers.Validate.PushFront(startSpan)
+	handlers.Complete.PushBack(endSpan)
+}
+
+// startSpan starts the call's span before anything else is done with it, so it covers any retries
+func startSpan(r *request.Request) {
+	ctx, span := tracing.Start(r.Context(), "DynamoDB."+r.Operation.Name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "dynamodb"),
+			attribute.String("db.operation", r.Operation.Name),
+		),
+	)
+	r.SetContext(context.WithValue(ctx, spanKey{}, span))
+}
+
+// endSpan ends the span startSpan started once the call has completed, recording the error if it failed
+func endSpan(r *request.Request) {
+	span, ok := r.Context().Value(spanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	if r.RequestID != "" {
+		span.SetAttributes(attribute.String("aws.request_id", r.RequestID))
+	}
+	if r.Error != nil {
+		span.RecordError(r.Error)
+		span.SetStatus(codes.Error, r.Error.Error())
+	}
+	span.End()
+}
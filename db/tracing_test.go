@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/mount-joy/thelist-lambda/tracing"
+	"github.com/mount-joy/thelist-lambda/tracing/tracingtest"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceCalls(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		body           string
+		expectedStatus codes.Code
+	}{
+		{
+			name:           "Records a span for a call which succeeded",
+			status:         200,
+			body:           `{"Item": {"Id": {"S": "item-1"}}}`,
+			expectedStatus: codes.Unset,
+		},
+		{
+			name:           "Records a span with the error for a call which failed",
+			status:         400,
+			body:           `{"__type": "com.amazonaws.dynamodb.v20120810#ResourceNotFoundException", "message": "Requested resource not found"}`,
+			expectedStatus: codes.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracingtest.Record(t)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("x-amzn-RequestId", "request-1")
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			sess := session.Must(session.NewSession(&aws.Config{
+				Endpoint:    aws.String(server.URL),
+				Region:      aws.String("eu-west-1"),
+				Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+				MaxRetries:  aws.Int(0),
+			}))
+			client := dynamodb.New(sess)
+			traceCalls(&client.Handlers)
+
+			ctx, parent := tracing.Start(context.Background(), "parent")
+			d := dynamoDB{session: client, conf: testConfig}
+			_, _ = d.GetItem(ctx, "list-1", "item-1")
+			parent.End()
+
+			spans := recorder.Ended()
+			assert.Equal(t, []string{"DynamoDB.GetItem", "parent"}, tracingtest.Names(spans))
+			assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+			assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+			assert.Subset(t, spans[0].Attributes(), []attribute.KeyValue{
+				attribute.String("db.system", "dynamodb"),
+				attribute.String("db.operation", "GetItem"),
+				attribute.String("aws.request_id", "request-1"),
+			})
+			assert.Equal(t, tt.expectedStatus, spans[0].Status().Code)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// maxWriteAttempts is how many times a record is read and changed when other requests keep changing it in between
+const maxWriteAttempts = 3
+
+// maxTransactionSize is the maximum number of writes dynamodb accepts in a single TransactWriteItems call
+const maxTransactionSize = 100
+
+// maxTransactionChanges is how many changes fit in a transaction, each one also puts its activity
+const maxTransactionChanges = maxTransactionSize / 2
+
+// errConditionFailed is returned by transactWrite when the condition on one of its writes wasn't met
+var errConditionFailed = errors.New("Transaction condition failed")
+
+// change is a write to an item along with the activity recording it
+type change struct {
+	write    *dynamodb.TransactWriteItem
+	activity *data.Activity
+}
+
+// transactWrite makes the writes along with putting the activity recording them, either all of them happen or none do
+func (d *dynamoDB) transactWrite(ctx context.Context, activity *data.Activity, writes ...*dynamodb.TransactWriteItem) error {
+	put, err := d.activityPut(activity)
+	if err != nil {
+		return err
+	}
+	return d.transact(ctx, append(writes, put))
+}
+
+// transactChanges makes each change along with putting its activity, in as few transactions as the changes fit in
+// Either all of the changes in a transaction happen or none do, the error returned for each change is its transaction's
+func (d *dynamoDB) transactChanges(ctx context.Context, changes []change) []error {
+	errs := make([]error, len(changes))
+	for start := 0; start < len(changes); start += maxTransactionChanges {
+		end := start + maxTransactionChanges
+		if end > len(changes) {
+			end = len(changes)
+		}
+
+		err := d.transactAll(ctx, changes[start:end])
+		for i := start; i < end; i++ {
+			errs[i] = err
+		}
+	}
+	return errs
+}
+
+// transactAll makes every change along with putting its activity in a single transaction, so either all of them happen or none do
+// There can be at most maxTransactionChanges of them
+func (d *dynamoDB) transactAll(ctx context.Context, changes []change) error {
+	writes := make([]*dynamodb.TransactWriteItem, 0, 2*len(changes))
+	for _, c := range changes {
+		put, err := d.activityPut(c.activity)
+		if err != nil {
+			return err
+		}
+		writes = append(writes, c.write, put)
+	}
+	return d.transact(ctx, writes)
+}
+
+// activityPut returns the write which puts activity in the activity table
+func (d *dynamoDB) activityPut(activity *data.Activity) (*dynamodb.TransactWriteItem, error) {
+	tableName := d.conf.TableNames.Activity
+	if len(tableName) == 0 {
+		panic("Activity table name not set")
+	}
+
+	activityToInsert, err := dynamodbattribute.MarshalMap(activity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.TransactWriteItem{
+		Put: &dynamodb.Put{
+			TableName:           aws.String(tableName),
+			Item:                activityToInsert,
+			ConditionExpression: aws.String("attribute_not_exists(Id)"),
+		},
+	}, nil
+}
+
+// transact makes the writes in a single transaction, returning errConditionFailed if one of their conditions wasn't met
+func (d *dynamoDB) transact(ctx context.Context, writes []*dynamodb.TransactWriteItem) error {
+	input := &dynamodb.TransactWriteItemsInput{
+		TransactItems: writes,
+	}
+
+	_, err := d.session.TransactWriteItemsWithContext(ctx, input)
+
+	var canceled *dynamodb.TransactionCanceledException
+	if errors.As(err, &canceled) {
+		for _, reason := range canceled.CancellationReasons {
+			if aws.StringValue(reason.Code) == "ConditionalCheckFailed" {
+				return errConditionFailed
+			}
+		}
+	}
+	return err
+}
+
+// retryConditionFailed calls write, which reads a record and changes it, again when the record changed in between
+// When the caller expected a version the record can't be at it any more, so that is a failed precondition
+func retryConditionFailed(expectedVersion *int64, write func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if err != errConditionFailed {
+			return err
+		}
+		if expectedVersion != nil {
+			return ErrorPreconditionFailed
+		}
+		if attempt == maxWriteAttempts {
+			return fmt.Errorf("Record kept changing after %d attempts: %w", attempt, err)
+		}
+	}
+}
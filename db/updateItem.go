@@ -1,7 +1,10 @@
 package db
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -10,7 +13,22 @@ import (
 	"github.com/mount-joy/thelist-lambda/data"
 )
 
-func (d *dynamoDB) UpdateItem(listID string, itemID string, newName string, isCompleted *bool) (*data.Item, error) {
+// UpdateItem changes the fields set in update, recording the change in the list's activity
+// The item is read first so the activity has the fields as they were, it's read again if it changes before it's written
+func (d *dynamoDB) UpdateItem(ctx context.Context, listID string, itemID string, update data.ItemUpdate, expectedVersion *int64) (*data.Item, error) {
+	var updated *data.Item
+	err := retryConditionFailed(expectedVersion, func() error {
+		var err error
+		updated, err = d.updateItem(ctx, listID, itemID, update, expectedVersion)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (d *dynamoDB) updateItem(ctx context.Context, listID string, itemID string, update data.ItemUpdate, expectedVersion *int64) (*data.Item, error) {
 	key, err := dynamodbattribute.MarshalMap(&data.ItemKey{ID: itemID, ListID: listID})
 	if err != nil {
 		return nil, err
@@ -21,27 +39,38 @@ func (d *dynamoDB) UpdateItem(listID string, itemID string, newName string, isCo
 		panic("Items table name not set")
 	}
 
+	item, err := d.getItemToChange(ctx, listID, itemID, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
 	timestamp := d.getTimestamp()
-	fieldsToUpdate, updateExpression, expressionAttributeNames := getUpdateFields(newName, isCompleted, timestamp)
-	input := &dynamodb.UpdateItemInput{
-		ExpressionAttributeValues: fieldsToUpdate,
-		Key:                       key,
-		TableName:                 aws.String(tableName),
-		UpdateExpression:          updateExpression,
-		ReturnValues:              aws.String("ALL_NEW"),
-		ExpressionAttributeNames:  expressionAttributeNames,
-		ConditionExpression:       aws.String("attribute_exists(Id)"),
+	updated := applyUpdate(*item, update, timestamp)
+	if fieldErrors := update.ValidateResult(updated); len(fieldErrors) != 0 {
+		return nil, data.FieldErrors(fieldErrors)
+	}
+	activity, err := newActivity(ctx, listID, d.generateID(), data.ActivityUpdateItem, itemID, item, &updated, timestamp)
+	if err != nil {
+		return nil, err
 	}
 
-	output, err := d.session.UpdateItem(input)
+	fieldsToUpdate, updateExpression, expressionAttributeNames := getUpdateFields(update, timestamp)
+	condition, conditionValues := itemCondition(&item.Version)
+	err = d.transactWrite(ctx, activity, &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			ExpressionAttributeValues: mergeValues(fieldsToUpdate, conditionValues),
+			Key:                       key,
+			TableName:                 aws.String(tableName),
+			UpdateExpression:          updateExpression,
+			ExpressionAttributeNames:  expressionAttributeNames,
+			ConditionExpression:       condition,
+		},
+	})
 
 	switch e := err.(type) {
 	case nil:
 		break
 	case awserr.Error:
-		if e.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
-			return nil, ErrorNotFound
-		}
 		if e.Code() == "ValidationException" { // https://github.com/aws/aws-sdk-go/issues/3140
 			return nil, ErrorBadRequest
 		}
@@ -50,31 +79,118 @@ func (d *dynamoDB) UpdateItem(listID string, itemID string, newName string, isCo
 		return nil, err
 	}
 
-	item := new(data.Item)
-	err = dynamodbattribute.UnmarshalMap(output.Attributes, &item)
-	return item, err
+	return &updated, nil
 }
 
-func getUpdateFields(newName string, isCompleted *bool, timestamp string) (map[string]*dynamodb.AttributeValue, *string, map[string]*string) {
+// getItemToChange reads an item before it's changed, it must not have been deleted and must be at expectedVersion if that's set
+func (d *dynamoDB) getItemToChange(ctx context.Context, listID string, itemID string, expectedVersion *int64) (*data.Item, error) {
+	item, err := d.getItem(ctx, listID, itemID, true)
+	if err == ErrorNotFound || (err == nil && item.DeletedTimestamp != "") {
+		return nil, conditionFailedError(expectedVersion)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(true, item.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// applyUpdate returns item with the fields set in update changed, as getUpdateFields changes them
+func applyUpdate(item data.Item, update data.ItemUpdate, timestamp string) data.Item {
+	if update.IsCompleted != nil {
+		item.IsCompleted = *update.IsCompleted
+	}
+	if update.Name != "" {
+		item.Name = update.Name
+	}
+	if update.Quantity != nil {
+		item.Quantity = update.Quantity
+	}
+	if update.Unit != "" {
+		item.Unit = update.Unit
+	}
+	if update.Category != "" {
+		item.Category = update.Category
+	}
+	if update.ClearQuantity {
+		item.Quantity = nil
+	}
+	if update.ClearUnit {
+		item.Unit = ""
+	}
+	if update.ClearCategory {
+		item.Category = ""
+	}
+	item.UpdatedTimestamp = timestamp
+	item.Version++
+	return item
+}
+
+func getUpdateFields(update data.ItemUpdate, timestamp string) (map[string]*dynamodb.AttributeValue, *string, map[string]*string) {
 	fields := map[string]*dynamodb.AttributeValue{}
 	var expressionAttributeNames map[string]*string
 	var updateExpression *string
+	var removeNames []string
 
-	if isCompleted != nil {
-		fields[":c"] = &dynamodb.AttributeValue{BOOL: isCompleted}
+	if update.IsCompleted != nil {
+		fields[":c"] = &dynamodb.AttributeValue{BOOL: update.IsCompleted}
 		updateExpression = appendUpdateExpression(updateExpression, "IsCompleted = :c")
 	}
 
-	if newName != "" {
-		fields[":n"] = &dynamodb.AttributeValue{S: aws.String(newName)}
+	if update.Name != "" {
+		fields[":n"] = &dynamodb.AttributeValue{S: aws.String(update.Name)}
 		expressionAttributeNames = appendNames(expressionAttributeNames, "#n", "Name")
 		updateExpression = appendUpdateExpression(updateExpression, "#n = :n")
 	}
 
+	if update.Quantity != nil {
+		fields[":q"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatFloat(*update.Quantity, 'f', -1, 64))}
+		expressionAttributeNames = appendNames(expressionAttributeNames, "#q", "Quantity")
+		updateExpression = appendUpdateExpression(updateExpression, "#q = :q")
+	}
+
+	if update.Unit != "" {
+		fields[":u"] = &dynamodb.AttributeValue{S: aws.String(update.Unit)}
+		expressionAttributeNames = appendNames(expressionAttributeNames, "#u", "Unit")
+		updateExpression = appendUpdateExpression(updateExpression, "#u = :u")
+	}
+
+	if update.Category != "" {
+		fields[":g"] = &dynamodb.AttributeValue{S: aws.String(update.Category)}
+		expressionAttributeNames = appendNames(expressionAttributeNames, "#g", "Category")
+		updateExpression = appendUpdateExpression(updateExpression, "#g = :g")
+	}
+
+	// Cleared fields are removed from the item rather than stored empty
+	for _, cleared := range []struct {
+		clear bool
+		key   string
+		name  string
+	}{
+		{clear: update.ClearQuantity, key: "#q", name: "Quantity"},
+		{clear: update.ClearUnit, key: "#u", name: "Unit"},
+		{clear: update.ClearCategory, key: "#g", name: "Category"},
+	} {
+		if cleared.clear {
+			expressionAttributeNames = appendNames(expressionAttributeNames, cleared.key, cleared.name)
+			removeNames = append(removeNames, cleared.key)
+		}
+	}
+
 	// Updated timestamp
 	fields[":t"] = &dynamodb.AttributeValue{S: &timestamp}
 	updateExpression = appendUpdateExpression(updateExpression, "Updated = :t")
 
+	if len(removeNames) != 0 {
+		updateExpression = aws.String(fmt.Sprintf("%s REMOVE %s", *updateExpression, strings.Join(removeNames, ", ")))
+	}
+
+	// Every change moves the record on to a new version
+	fields[":one"] = &dynamodb.AttributeValue{N: aws.String("1")}
+	updateExpression = aws.String(fmt.Sprintf("%s ADD Version :one", *updateExpression))
+
 	return fields, updateExpression, expressionAttributeNames
 }
 
@@ -1,11 +1,13 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/mount-joy/thelist-lambda/data"
 	"github.com/stretchr/testify/assert"
 )
@@ -15,107 +17,216 @@ func TestUpdateItem(t *testing.T) {
 	itemID := "b6cf642d"
 	newName := "Cheese"
 	timestamp := "2020-01-23T09:59:14.9396531Z"
+	activityID := "2020-01-23T09:59:14.939653100Z#activity-id"
+	quantity := 250.0
+	version := int64(2)
+	otherVersion := int64(1)
+	existing := &data.Item{ItemKey: data.ItemKey{ID: itemID, ListID: listID}, Name: "Bread", Version: version, UpdatedTimestamp: "2020-01-22T09:00:00Z"}
+	updated := func(item data.Item) *data.Item {
+		item.Version = version + 1
+		item.UpdatedTimestamp = timestamp
+		return &item
+	}
 
 	tests := []struct {
 		testName                         string
-		newName                          string
-		isCompleted                      *bool
-		mockedErrResponse                error
-		mockedResponse                   *dynamodb.UpdateItemOutput
+		update                           data.ItemUpdate
+		expectedVersion                  *int64
+		existing                         *data.Item
+		getErr                           error
+		transactErrs                     []error
 		expectedUpdateExpression         *string
 		expectedFieldsToUpdate           map[string]*dynamodb.AttributeValue
-		expectedKey                      map[string]*dynamodb.AttributeValue
 		expectedExpressionAttributeNames map[string]*string
+		expectedBefore                   map[string]interface{}
+		expectedAfter                    map[string]interface{}
 		expectedRes                      *data.Item
 		expectedErr                      error
 	}{
 		{
-			testName:                         "If the item exists it is updated",
-			newName:                          newName,
-			isCompleted:                      boolToPointer(true),
-			mockedResponse:                   updateItemOutput(listID, itemID, newName, true),
-			mockedErrResponse:                nil,
-			expectedUpdateExpression:         stringToPointer("SET IsCompleted = :c, #n = :n, Updated = :t"),
+			testName:                         "If the item exists it is updated and the change is recorded",
+			update:                           data.ItemUpdate{Name: newName, IsCompleted: boolToPointer(true)},
+			existing:                         existing,
+			transactErrs:                     []error{nil},
+			expectedUpdateExpression:         stringToPointer("SET IsCompleted = :c, #n = :n, Updated = :t ADD Version :one"),
 			expectedFieldsToUpdate:           updateBothFields(newName, true, timestamp),
-			expectedKey:                      map[string]*dynamodb.AttributeValue{"Id": {S: &itemID}, "ListId": {S: &listID}},
 			expectedExpressionAttributeNames: map[string]*string{"#n": stringToPointer("Name")},
-			expectedRes:                      &data.Item{ItemKey: data.ItemKey{ID: itemID, ListID: listID}, Name: newName, IsCompleted: true},
-			expectedErr:                      nil,
+			expectedBefore:                   map[string]interface{}{"Name": "Bread", "IsCompleted": false},
+			expectedAfter:                    map[string]interface{}{"Name": newName, "IsCompleted": true},
+			expectedRes:                      updated(data.Item{ItemKey: existing.ItemKey, Name: newName, IsCompleted: true}),
 		},
 		{
 			testName:                         "If only a new name is supplied, only it is updated",
-			newName:                          newName,
-			isCompleted:                      nil,
-			mockedResponse:                   updateItemOutput(listID, itemID, newName, false),
-			mockedErrResponse:                nil,
-			expectedUpdateExpression:         stringToPointer("SET #n = :n, Updated = :t"),
+			update:                           data.ItemUpdate{Name: newName},
+			existing:                         existing,
+			transactErrs:                     []error{nil},
+			expectedUpdateExpression:         stringToPointer("SET #n = :n, Updated = :t ADD Version :one"),
 			expectedFieldsToUpdate:           updateName(newName, timestamp),
-			expectedKey:                      map[string]*dynamodb.AttributeValue{"Id": {S: &itemID}, "ListId": {S: &listID}},
 			expectedExpressionAttributeNames: map[string]*string{"#n": stringToPointer("Name")},
-			expectedRes:                      &data.Item{ItemKey: data.ItemKey{ID: itemID, ListID: listID}, Name: newName, IsCompleted: false},
-			expectedErr:                      nil,
-		},
-		{
-			testName:                         "If only isCompleted is changed, only it is updated",
-			newName:                          "",
-			isCompleted:                      boolToPointer(true),
-			mockedResponse:                   updateItemOutput(listID, itemID, newName, true),
-			mockedErrResponse:                nil,
-			expectedUpdateExpression:         stringToPointer("SET IsCompleted = :c, Updated = :t"),
-			expectedFieldsToUpdate:           updateIsCompleted(true, timestamp),
-			expectedKey:                      map[string]*dynamodb.AttributeValue{"Id": {S: &itemID}, "ListId": {S: &listID}},
-			expectedExpressionAttributeNames: nil,
-			expectedRes:                      &data.Item{ItemKey: data.ItemKey{ID: itemID, ListID: listID}, Name: newName, IsCompleted: true},
-			expectedErr:                      nil,
-		},
-		{
-			testName:                         "When db returns an error, that error is returned",
-			newName:                          newName,
-			isCompleted:                      boolToPointer(true),
-			mockedResponse:                   nil,
-			mockedErrResponse:                errors.New("Something went wrong"),
-			expectedUpdateExpression:         stringToPointer("SET IsCompleted = :c, #n = :n, Updated = :t"),
-			expectedFieldsToUpdate:           updateBothFields(newName, true, timestamp),
-			expectedKey:                      map[string]*dynamodb.AttributeValue{"Id": {S: &itemID}, "ListId": {S: &listID}},
+			expectedBefore:                   map[string]interface{}{"Name": "Bread"},
+			expectedAfter:                    map[string]interface{}{"Name": newName},
+			expectedRes:                      updated(data.Item{ItemKey: existing.ItemKey, Name: newName}),
+		},
+		{
+			testName:                 "If only isCompleted is changed, only it is updated",
+			update:                   data.ItemUpdate{IsCompleted: boolToPointer(true)},
+			existing:                 existing,
+			transactErrs:             []error{nil},
+			expectedUpdateExpression: stringToPointer("SET IsCompleted = :c, Updated = :t ADD Version :one"),
+			expectedFieldsToUpdate:   updateIsCompleted(true, timestamp),
+			expectedBefore:           map[string]interface{}{"IsCompleted": false},
+			expectedAfter:            map[string]interface{}{"IsCompleted": true},
+			expectedRes:              updated(data.Item{ItemKey: existing.ItemKey, Name: "Bread", IsCompleted: true}),
+		},
+		{
+			testName:                 "If a quantity and unit are supplied, they are updated",
+			update:                   data.ItemUpdate{Quantity: &quantity, Unit: "g"},
+			existing:                 existing,
+			transactErrs:             []error{nil},
+			expectedUpdateExpression: stringToPointer("SET #q = :q, #u = :u, Updated = :t ADD Version :one"),
+			expectedFieldsToUpdate: map[string]*dynamodb.AttributeValue{
+				":q":   &dynamodb.AttributeValue{N: stringToPointer("250")},
+				":u":   &dynamodb.AttributeValue{S: stringToPointer("g")},
+				":t":   &dynamodb.AttributeValue{S: &timestamp},
+				":one": &dynamodb.AttributeValue{N: stringToPointer("1")},
+			},
+			expectedExpressionAttributeNames: map[string]*string{"#q": stringToPointer("Quantity"), "#u": stringToPointer("Unit")},
+			expectedAfter:                    map[string]interface{}{"Quantity": quantity, "Unit": "g"},
+			expectedRes:                      updated(data.Item{ItemKey: existing.ItemKey, Name: "Bread", Quantity: &quantity, Unit: "g"}),
+		},
+		{
+			testName:                 "If a category is supplied, it is updated",
+			update:                   data.ItemUpdate{Category: "dairy"},
+			existing:                 existing,
+			transactErrs:             []error{nil},
+			expectedUpdateExpression: stringToPointer("SET #g = :g, Updated = :t ADD Version :one"),
+			expectedFieldsToUpdate: map[string]*dynamodb.AttributeValue{
+				":g":   &dynamodb.AttributeValue{S: stringToPointer("dairy")},
+				":t":   &dynamodb.AttributeValue{S: &timestamp},
+				":one": &dynamodb.AttributeValue{N: stringToPointer("1")},
+			},
+			expectedExpressionAttributeNames: map[string]*string{"#g": stringToPointer("Category")},
+			expectedAfter:                    map[string]interface{}{"Category": "dairy"},
+			expectedRes:                      updated(data.Item{ItemKey: existing.ItemKey, Name: "Bread", Category: "dairy"}),
+		},
+		{
+			testName:                 "If fields are cleared, they are removed from the item",
+			update:                   data.ItemUpdate{ClearQuantity: true, ClearUnit: true, ClearCategory: true},
+			existing:                 &data.Item{ItemKey: existing.ItemKey, Name: "Bread", Quantity: &quantity, Unit: "g", Category: "bakery", Version: version},
+			transactErrs:             []error{nil},
+			expectedUpdateExpression: stringToPointer("SET Updated = :t REMOVE #q, #u, #g ADD Version :one"),
+			expectedFieldsToUpdate: map[string]*dynamodb.AttributeValue{
+				":t":   &dynamodb.AttributeValue{S: &timestamp},
+				":one": &dynamodb.AttributeValue{N: stringToPointer("1")},
+			},
+			expectedExpressionAttributeNames: map[string]*string{"#q": stringToPointer("Quantity"), "#u": stringToPointer("Unit"), "#g": stringToPointer("Category")},
+			expectedBefore:                   map[string]interface{}{"Quantity": quantity, "Unit": "g", "Category": "bakery"},
+			expectedRes:                      updated(data.Item{ItemKey: existing.ItemKey, Name: "Bread"}),
+		},
+		{
+			testName:    "If a unit is set on an item without a quantity, the unit is invalid",
+			update:      data.ItemUpdate{Unit: "kg"},
+			existing:    existing,
+			expectedErr: data.FieldErrors{{Field: "Unit", Err: data.ErrorUnitWithoutQuantity}},
+		},
+		{
+			testName:    "If the quantity is cleared from an item which keeps its unit, the quantity is invalid",
+			update:      data.ItemUpdate{ClearQuantity: true},
+			existing:    &data.Item{ItemKey: existing.ItemKey, Name: "Bread", Quantity: &quantity, Unit: "g", Version: version},
+			expectedErr: data.FieldErrors{{Field: "Quantity", Err: data.ErrorQuantityNeededByUnit}},
+		},
+		{
+			testName:                         "If the item is at the expected version it is updated",
+			update:                           data.ItemUpdate{Name: newName},
+			expectedVersion:                  &version,
+			existing:                         existing,
+			transactErrs:                     []error{nil},
+			expectedUpdateExpression:         stringToPointer("SET #n = :n, Updated = :t ADD Version :one"),
+			expectedFieldsToUpdate:           updateName(newName, timestamp),
 			expectedExpressionAttributeNames: map[string]*string{"#n": stringToPointer("Name")},
-			expectedRes:                      nil,
-			expectedErr:                      errors.New("Something went wrong"),
+			expectedBefore:                   map[string]interface{}{"Name": "Bread"},
+			expectedAfter:                    map[string]interface{}{"Name": newName},
+			expectedRes:                      updated(data.Item{ItemKey: existing.ItemKey, Name: newName}),
 		},
 		{
-			testName:                         "If the item doesn't exist the Query returns condition not match error, not found error is returned",
-			newName:                          newName,
-			isCompleted:                      boolToPointer(true),
-			mockedResponse:                   nil,
-			mockedErrResponse:                awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "Bad", errors.New("Oh dear")),
-			expectedUpdateExpression:         stringToPointer("SET IsCompleted = :c, #n = :n, Updated = :t"),
-			expectedFieldsToUpdate:           updateBothFields(newName, true, timestamp),
-			expectedKey:                      map[string]*dynamodb.AttributeValue{"Id": {S: &itemID}, "ListId": {S: &listID}},
+			testName:                         "If the item changes after it's read, it is read again",
+			update:                           data.ItemUpdate{Name: newName},
+			existing:                         existing,
+			transactErrs:                     []error{transactionCanceled(), nil},
+			expectedUpdateExpression:         stringToPointer("SET #n = :n, Updated = :t ADD Version :one"),
+			expectedFieldsToUpdate:           updateName(newName, timestamp),
+			expectedExpressionAttributeNames: map[string]*string{"#n": stringToPointer("Name")},
+			expectedBefore:                   map[string]interface{}{"Name": "Bread"},
+			expectedAfter:                    map[string]interface{}{"Name": newName},
+			expectedRes:                      updated(data.Item{ItemKey: existing.ItemKey, Name: newName}),
+		},
+		{
+			testName:                         "If the item changes after it's read when a version is expected, PreconditionFailed is returned",
+			update:                           data.ItemUpdate{Name: newName},
+			expectedVersion:                  &version,
+			existing:                         existing,
+			transactErrs:                     []error{transactionCanceled()},
+			expectedUpdateExpression:         stringToPointer("SET #n = :n, Updated = :t ADD Version :one"),
+			expectedFieldsToUpdate:           updateName(newName, timestamp),
 			expectedExpressionAttributeNames: map[string]*string{"#n": stringToPointer("Name")},
-			expectedRes:                      nil,
-			expectedErr:                      ErrorNotFound,
+			expectedBefore:                   map[string]interface{}{"Name": "Bread"},
+			expectedAfter:                    map[string]interface{}{"Name": newName},
+			expectedErr:                      ErrorPreconditionFailed,
+		},
+		{
+			testName:        "If the item isn't at the expected version, PreconditionFailed is returned",
+			update:          data.ItemUpdate{Name: newName},
+			expectedVersion: &otherVersion,
+			existing:        existing,
+			expectedErr:     ErrorPreconditionFailed,
+		},
+		{
+			testName:    "If the item doesn't exist, not found error is returned",
+			update:      data.ItemUpdate{Name: newName},
+			expectedErr: ErrorNotFound,
+		},
+		{
+			testName:    "If the item has been deleted, not found error is returned",
+			update:      data.ItemUpdate{Name: newName},
+			existing:    &data.Item{ItemKey: existing.ItemKey, Name: "Bread", Version: version, DeletedTimestamp: timestamp},
+			expectedErr: ErrorNotFound,
+		},
+		{
+			testName:    "When reading the item returns an error, that error is returned",
+			update:      data.ItemUpdate{Name: newName},
+			getErr:      errors.New("Something went wrong"),
+			expectedErr: errors.New("Something went wrong"),
+		},
+		{
+			testName:                         "When the write returns an error, that error is returned",
+			update:                           data.ItemUpdate{Name: newName},
+			existing:                         existing,
+			transactErrs:                     []error{errors.New("Something went wrong")},
+			expectedUpdateExpression:         stringToPointer("SET #n = :n, Updated = :t ADD Version :one"),
+			expectedFieldsToUpdate:           updateName(newName, timestamp),
+			expectedExpressionAttributeNames: map[string]*string{"#n": stringToPointer("Name")},
+			expectedBefore:                   map[string]interface{}{"Name": "Bread"},
+			expectedAfter:                    map[string]interface{}{"Name": newName},
+			expectedErr:                      errors.New("Something went wrong"),
 		},
 		{
 			testName:                 "If the update request is invalid, BadRequest is returned",
-			newName:                  "",
-			mockedResponse:           nil,
-			mockedErrResponse:        awserr.New("ValidationException", "Bad", errors.New("Oh dear")),
-			expectedUpdateExpression: stringToPointer("SET Updated = :t"),
-			expectedFieldsToUpdate:   map[string]*dynamodb.AttributeValue{":t": &dynamodb.AttributeValue{S: &timestamp}},
-			expectedKey:              map[string]*dynamodb.AttributeValue{"Id": {S: &itemID}, "ListId": {S: &listID}},
-			expectedRes:              nil,
+			existing:                 existing,
+			transactErrs:             []error{awserr.New("ValidationException", "Bad", errors.New("Oh dear"))},
+			expectedUpdateExpression: stringToPointer("SET Updated = :t ADD Version :one"),
+			expectedFieldsToUpdate:   map[string]*dynamodb.AttributeValue{":t": &dynamodb.AttributeValue{S: &timestamp}, ":one": &dynamodb.AttributeValue{N: stringToPointer("1")}},
 			expectedErr:              ErrorBadRequest,
 		},
 		{
 			testName:                         "If another AWS error is returned that error message is passed on",
-			newName:                          newName,
-			isCompleted:                      boolToPointer(true),
-			mockedResponse:                   nil,
-			mockedErrResponse:                awserr.New("Oops", "Bad", errors.New("Oh dear")),
-			expectedUpdateExpression:         stringToPointer("SET IsCompleted = :c, #n = :n, Updated = :t"),
-			expectedFieldsToUpdate:           updateBothFields(newName, true, timestamp),
-			expectedKey:                      map[string]*dynamodb.AttributeValue{"Id": {S: &itemID}, "ListId": {S: &listID}},
+			update:                           data.ItemUpdate{Name: newName},
+			existing:                         existing,
+			transactErrs:                     []error{awserr.New("Oops", "Bad", errors.New("Oh dear"))},
+			expectedUpdateExpression:         stringToPointer("SET #n = :n, Updated = :t ADD Version :one"),
+			expectedFieldsToUpdate:           updateName(newName, timestamp),
 			expectedExpressionAttributeNames: map[string]*string{"#n": stringToPointer("Name")},
-			expectedRes:                      nil,
+			expectedBefore:                   map[string]interface{}{"Name": "Bread"},
+			expectedAfter:                    map[string]interface{}{"Name": newName},
 			expectedErr:                      awserr.New("Oops", "Bad", errors.New("Oh dear")),
 		},
 	}
@@ -126,22 +237,57 @@ func TestUpdateItem(t *testing.T) {
 			dbMocked.Test(t)
 			defer dbMocked.AssertExpectations(t)
 
-			input := dynamodb.UpdateItemInput{
-				ExpressionAttributeValues: tt.expectedFieldsToUpdate,
-				Key:                       tt.expectedKey,
-				TableName:                 stringToPointer("items-table"),
-				UpdateExpression:          tt.expectedUpdateExpression,
-				ReturnValues:              stringToPointer("ALL_NEW"),
-				ExpressionAttributeNames:  tt.expectedExpressionAttributeNames,
-				ConditionExpression:       stringToPointer("attribute_exists(Id)"),
+			key := map[string]*dynamodb.AttributeValue{"Id": {S: &itemID}, "ListId": {S: &listID}}
+			getOutput := &dynamodb.GetItemOutput{}
+			if tt.existing != nil {
+				getOutput.Item, _ = dynamodbattribute.MarshalMap(tt.existing)
+			}
+			reads := len(tt.transactErrs)
+			if reads == 0 {
+				reads = 1
 			}
 			dbMocked.
-				On("UpdateItem", &input).
-				Return(tt.mockedResponse, tt.mockedErrResponse).
-				Once()
+				On("GetItem", &dynamodb.GetItemInput{Key: key, TableName: stringToPointer("items-table"), ConsistentRead: boolToPointer(true)}).
+				Return(getOutput, tt.getErr).
+				Times(reads)
 
-			d := dynamoDB{session: dbMocked, conf: testConfig, getTimestamp: func() string { return timestamp }}
-			gotRes, gotErr := d.UpdateItem(listID, itemID, tt.newName, tt.isCompleted)
+			for _, transactErr := range tt.transactErrs {
+				input := dynamodb.TransactWriteItemsInput{
+					TransactItems: []*dynamodb.TransactWriteItem{
+						{
+							Update: &dynamodb.Update{
+								ExpressionAttributeValues: mergeValues(tt.expectedFieldsToUpdate, map[string]*dynamodb.AttributeValue{":v": {N: stringToPointer("2")}}),
+								Key:                       key,
+								TableName:                 stringToPointer("items-table"),
+								UpdateExpression:          tt.expectedUpdateExpression,
+								ExpressionAttributeNames:  tt.expectedExpressionAttributeNames,
+								ConditionExpression:       stringToPointer("attribute_exists(Id) AND Version = :v AND attribute_not_exists(DeletedAt)"),
+							},
+						},
+						activityWrite(data.Activity{
+							ActivityKey: data.ActivityKey{ListID: listID, ID: activityID},
+							Actor:       testActor,
+							Action:      data.ActivityUpdateItem,
+							ItemID:      itemID,
+							Before:      tt.expectedBefore,
+							After:       tt.expectedAfter,
+							Timestamp:   timestamp,
+						}),
+					},
+				}
+				dbMocked.
+					On("TransactWriteItems", &input).
+					Return(&dynamodb.TransactWriteItemsOutput{}, transactErr).
+					Once()
+			}
+
+			d := dynamoDB{
+				session:      dbMocked,
+				conf:         testConfig,
+				generateID:   func() string { return "activity-id" },
+				getTimestamp: func() string { return timestamp },
+			}
+			gotRes, gotErr := d.UpdateItem(WithActor(context.Background(), testActor), listID, itemID, tt.update, tt.expectedVersion)
 
 			assert.Equal(t, tt.expectedErr, gotErr)
 			assert.Equal(t, tt.expectedRes, gotRes)
@@ -149,43 +295,27 @@ func TestUpdateItem(t *testing.T) {
 	}
 }
 
-func updateItemOutput(listID string, itemID string, name string, isCompleted bool) *dynamodb.UpdateItemOutput {
-	return &dynamodb.UpdateItemOutput{
-		Attributes: map[string]*dynamodb.AttributeValue{
-			"Id": &dynamodb.AttributeValue{
-				S: stringToPointer(itemID),
-			},
-			"ListId": &dynamodb.AttributeValue{
-				S: stringToPointer(listID),
-			},
-			"Name": &dynamodb.AttributeValue{
-				S: stringToPointer(name),
-			},
-			"IsCompleted": &dynamodb.AttributeValue{
-				BOOL: boolToPointer(isCompleted),
-			},
-		},
-	}
-}
-
 func updateBothFields(name string, isCompleted bool, timestamp string) map[string]*dynamodb.AttributeValue {
 	return map[string]*dynamodb.AttributeValue{
-		":c": &dynamodb.AttributeValue{BOOL: &isCompleted},
-		":n": &dynamodb.AttributeValue{S: &name},
-		":t": &dynamodb.AttributeValue{S: &timestamp},
+		":c":   &dynamodb.AttributeValue{BOOL: &isCompleted},
+		":n":   &dynamodb.AttributeValue{S: &name},
+		":t":   &dynamodb.AttributeValue{S: &timestamp},
+		":one": &dynamodb.AttributeValue{N: stringToPointer("1")},
 	}
 }
 
 func updateName(name string, timestamp string) map[string]*dynamodb.AttributeValue {
 	return map[string]*dynamodb.AttributeValue{
-		":n": &dynamodb.AttributeValue{S: &name},
-		":t": &dynamodb.AttributeValue{S: &timestamp},
+		":n":   &dynamodb.AttributeValue{S: &name},
+		":t":   &dynamodb.AttributeValue{S: &timestamp},
+		":one": &dynamodb.AttributeValue{N: stringToPointer("1")},
 	}
 }
 
 func updateIsCompleted(isCompleted bool, timestamp string) map[string]*dynamodb.AttributeValue {
 	return map[string]*dynamodb.AttributeValue{
-		":c": &dynamodb.AttributeValue{BOOL: &isCompleted},
-		":t": &dynamodb.AttributeValue{S: &timestamp},
+		":c":   &dynamodb.AttributeValue{BOOL: &isCompleted},
+		":t":   &dynamodb.AttributeValue{S: &timestamp},
+		":one": &dynamodb.AttributeValue{N: stringToPointer("1")},
 	}
 }
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+)
+
+// UpdateList renames the list, recording the change in its activity
+// The list is read first so the activity has the name it had, it's read again if it changes before it's written
+func (d *dynamoDB) UpdateList(ctx context.Context, listID string, newName string, expectedVersion *int64) (*data.List, error) {
+	var updated *data.List
+	err := retryConditionFailed(expectedVersion, func() error {
+		var err error
+		updated, err = d.updateList(ctx, listID, newName, expectedVersion)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (d *dynamoDB) updateList(ctx context.Context, listID string, newName string, expectedVersion *int64) (*data.List, error) {
+	key, err := dynamodbattribute.MarshalMap(&data.ListKey{ID: listID})
+	if err != nil {
+		return nil, err
+	}
+
+	tableName := d.conf.TableNames.Lists
+	if len(tableName) == 0 {
+		panic("Lists table name not set")
+	}
+
+	list, err := d.getListToChange(ctx, listID, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := d.getTimestamp()
+	updated := *list
+	if newName != "" {
+		updated.Name = newName
+	}
+	updated.UpdatedTimestamp = timestamp
+	updated.Version++
+	activity, err := newActivity(ctx, listID, d.generateID(), data.ActivityUpdateList, "", list, &updated, timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldsToUpdate, updateExpression, expressionAttributeNames := getUpdateFields(data.ItemUpdate{Name: newName}, timestamp)
+	condition, conditionValues := versionCondition(&list.Version)
+	err = d.transactWrite(ctx, activity, &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			ExpressionAttributeValues: mergeValues(fieldsToUpdate, conditionValues),
+			Key:                       key,
+			TableName:                 aws.String(tableName),
+			UpdateExpression:          updateExpression,
+			ExpressionAttributeNames:  expressionAttributeNames,
+			ConditionExpression:       condition,
+		},
+	})
+
+	switch e := err.(type) {
+	case nil:
+		break
+	case awserr.Error:
+		if e.Code() == "ValidationException" { // https://github.com/aws/aws-sdk-go/issues/3140
+			return nil, ErrorBadRequest
+		}
+		return nil, err
+	default:
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// getListToChange reads a list before it's changed, it must be at expectedVersion if that's set
+func (d *dynamoDB) getListToChange(ctx context.Context, listID string, expectedVersion *int64) (*data.List, error) {
+	list, err := d.getList(ctx, listID, true)
+	if err == ErrorNotFound {
+		return nil, conditionFailedError(expectedVersion)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(true, list.Version, expectedVersion); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateList(t *testing.T) {
+	listID := "474c2Fff7"
+	newName := "Groceries"
+	timestamp := "2020-01-23T09:59:14.9396531Z"
+	version := int64(2)
+	otherVersion := int64(1)
+	existing := &data.List{ListKey: data.ListKey{ID: listID}, Name: "Shopping", OwnerID: "user-1", Version: version}
+	renamed := &data.List{ListKey: data.ListKey{ID: listID}, Name: newName, OwnerID: "user-1", Version: version + 1, UpdatedTimestamp: timestamp}
+
+	tests := []struct {
+		testName        string
+		newName         string
+		expectedVersion *int64
+		existing        *data.List
+		getErr          error
+		transactErrs    []error
+		expectedRes     *data.List
+		expectedErr     error
+	}{
+		{
+			testName:     "If the list exists it is renamed and the change is recorded",
+			newName:      newName,
+			existing:     existing,
+			transactErrs: []error{nil},
+			expectedRes:  renamed,
+		},
+		{
+			testName:        "If the list is at the expected version it is renamed",
+			newName:         newName,
+			expectedVersion: &version,
+			existing:        existing,
+			transactErrs:    []error{nil},
+			expectedRes:     renamed,
+		},
+		{
+			testName:     "When db returns an error, that error is returned",
+			newName:      newName,
+			existing:     existing,
+			transactErrs: []error{errors.New("Something went wrong")},
+			expectedErr:  errors.New("Something went wrong"),
+		},
+		{
+			testName:    "When reading the list returns an error, that error is returned",
+			newName:     newName,
+			getErr:      errors.New("Something went wrong"),
+			expectedErr: errors.New("Something went wrong"),
+		},
+		{
+			testName:    "If the list doesn't exist, not found error is returned",
+			newName:     newName,
+			expectedErr: ErrorNotFound,
+		},
+		{
+			testName:        "If the list isn't at the expected version, PreconditionFailed is returned",
+			newName:         newName,
+			expectedVersion: &otherVersion,
+			existing:        existing,
+			expectedErr:     ErrorPreconditionFailed,
+		},
+		{
+			testName:     "If the list changes after it's read, it is read again",
+			newName:      newName,
+			existing:     existing,
+			transactErrs: []error{transactionCanceled(), nil},
+			expectedRes:  renamed,
+		},
+		{
+			testName:        "If the list changes after it's read when a version is expected, PreconditionFailed is returned",
+			newName:         newName,
+			expectedVersion: &version,
+			existing:        existing,
+			transactErrs:    []error{transactionCanceled()},
+			expectedErr:     ErrorPreconditionFailed,
+		},
+		{
+			testName:     "If the update request is invalid, BadRequest is returned",
+			newName:      "",
+			existing:     existing,
+			transactErrs: []error{awserr.New("ValidationException", "Bad", errors.New("Oh dear"))},
+			expectedErr:  ErrorBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			dbMocked := &mockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			key := map[string]*dynamodb.AttributeValue{"Id": {S: &listID}}
+			getOutput := &dynamodb.GetItemOutput{}
+			if tt.existing != nil {
+				getOutput.Item, _ = dynamodbattribute.MarshalMap(tt.existing)
+			}
+			reads := len(tt.transactErrs)
+			if reads == 0 {
+				reads = 1
+			}
+			dbMocked.
+				On("GetItem", &dynamodb.GetItemInput{Key: key, TableName: stringToPointer("lists-table"), ConsistentRead: boolToPointer(true)}).
+				Return(getOutput, tt.getErr).
+				Times(reads)
+
+			fieldsToUpdate := map[string]*dynamodb.AttributeValue{":t": {S: &timestamp}, ":one": {N: stringToPointer("1")}, ":v": {N: stringToPointer("2")}}
+			updateExpression := stringToPointer("SET Updated = :t ADD Version :one")
+			var expressionAttributeNames map[string]*string
+			var before, after map[string]interface{}
+			if tt.newName != "" {
+				fieldsToUpdate[":n"] = &dynamodb.AttributeValue{S: &tt.newName}
+				updateExpression = stringToPointer("SET #n = :n, Updated = :t ADD Version :one")
+				expressionAttributeNames = map[string]*string{"#n": stringToPointer("Name")}
+				before = map[string]interface{}{"Name": "Shopping"}
+				after = map[string]interface{}{"Name": tt.newName}
+			}
+			for _, transactErr := range tt.transactErrs {
+				input := dynamodb.TransactWriteItemsInput{
+					TransactItems: []*dynamodb.TransactWriteItem{
+						{
+							Update: &dynamodb.Update{
+								ExpressionAttributeValues: fieldsToUpdate,
+								Key:                       key,
+								TableName:                 stringToPointer("lists-table"),
+								UpdateExpression:          updateExpression,
+								ExpressionAttributeNames:  expressionAttributeNames,
+								ConditionExpression:       stringToPointer("attribute_exists(Id) AND Version = :v"),
+							},
+						},
+						activityWrite(data.Activity{
+							ActivityKey: data.ActivityKey{ListID: listID, ID: "2020-01-23T09:59:14.939653100Z#activity-id"},
+							Actor:       testActor,
+							Action:      data.ActivityUpdateList,
+							Before:      before,
+							After:       after,
+							Timestamp:   timestamp,
+						}),
+					},
+				}
+				dbMocked.
+					On("TransactWriteItems", &input).
+					Return(&dynamodb.TransactWriteItemsOutput{}, transactErr).
+					Once()
+			}
+
+			d := dynamoDB{
+				session:      dbMocked,
+				conf:         testConfig,
+				generateID:   func() string { return "activity-id" },
+				getTimestamp: func() string { return timestamp },
+			}
+			gotRes, gotErr := d.UpdateList(WithActor(context.Background(), testActor), listID, tt.newName, tt.expectedVersion)
+
+			assert.Equal(t, tt.expectedErr, gotErr)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
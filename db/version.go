@@ -0,0 +1,54 @@
+package db
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// versionCondition returns the condition for writing to a record which must already exist
+// When expectedVersion is set the record must also still be at that version, the values to use
+// in the condition are returned alongside it
+func versionCondition(expectedVersion *int64) (*string, map[string]*dynamodb.AttributeValue) {
+	if expectedVersion == nil {
+		return aws.String("attribute_exists(Id)"), nil
+	}
+
+	// Records written before versions were added have no Version, they are treated as version 0
+	if *expectedVersion == 0 {
+		return aws.String("attribute_exists(Id) AND attribute_not_exists(Version)"), nil
+	}
+
+	values := map[string]*dynamodb.AttributeValue{
+		":v": {N: aws.String(strconv.FormatInt(*expectedVersion, 10))},
+	}
+	return aws.String("attribute_exists(Id) AND Version = :v"), values
+}
+
+// conditionFailedError is the error to return when the condition from versionCondition fails
+// A record which doesn't exist can't be at the expected version either, so that is a failed precondition too
+func conditionFailedError(expectedVersion *int64) error {
+	if expectedVersion != nil {
+		return ErrorPreconditionFailed
+	}
+	return ErrorNotFound
+}
+
+// checkVersion mirrors the condition from versionCondition, for a record which has been read before it's changed
+func checkVersion(exists bool, version int64, expectedVersion *int64) error {
+	if !exists {
+		return conditionFailedError(expectedVersion)
+	}
+	if expectedVersion != nil && *expectedVersion != version {
+		return ErrorPreconditionFailed
+	}
+	return nil
+}
+
+func mergeValues(values map[string]*dynamodb.AttributeValue, extra map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	for key, value := range extra {
+		values[key] = value
+	}
+	return values
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionCondition(t *testing.T) {
+	zero := int64(0)
+	three := int64(3)
+
+	tests := []struct {
+		name              string
+		expectedVersion   *int64
+		expectedCondition string
+		expectedValues    map[string]*dynamodb.AttributeValue
+		expectedErr       error
+	}{
+		{
+			name:              "Without an expected version the record only has to exist",
+			expectedVersion:   nil,
+			expectedCondition: "attribute_exists(Id)",
+			expectedValues:    nil,
+			expectedErr:       ErrorNotFound,
+		},
+		{
+			name:              "With an expected version the record must be at that version",
+			expectedVersion:   &three,
+			expectedCondition: "attribute_exists(Id) AND Version = :v",
+			expectedValues:    map[string]*dynamodb.AttributeValue{":v": {N: stringToPointer("3")}},
+			expectedErr:       ErrorPreconditionFailed,
+		},
+		{
+			name:              "Version 0 matches records written before versions were added",
+			expectedVersion:   &zero,
+			expectedCondition: "attribute_exists(Id) AND attribute_not_exists(Version)",
+			expectedValues:    nil,
+			expectedErr:       ErrorPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCondition, gotValues := versionCondition(tt.expectedVersion)
+
+			assert.Equal(t, tt.expectedCondition, *gotCondition)
+			assert.Equal(t, tt.expectedValues, gotValues)
+			assert.Equal(t, tt.expectedErr, conditionFailedError(tt.expectedVersion))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/logging"
+)
+
+// SubjectClaim is the JWT claim holding the caller's identity
+const SubjectClaim = "sub"
+
+// ErrorNoCaller is returned when the request doesn't say who made it
+var ErrorNoCaller = errors.New("Request has no caller identity")
+
+// CallerID returns the identity of the caller from the claims added by the JWT authorizer
+func CallerID(request events.APIGatewayV2HTTPRequest) (string, error) {
+	authorizer := request.RequestContext.Authorizer
+	if authorizer == nil || authorizer.JWT == nil || authorizer.JWT.Claims[SubjectClaim] == "" {
+		return "", ErrorNoCaller
+	}
+	return authorizer.JWT.Claims[SubjectClaim], nil
+}
+
+// CanAccess returns true if the caller owns the list or is one of its collaborators
+// Lists created before owners were recorded have no owner, so nobody can access them
+func CanAccess(list *data.List, callerID string) bool {
+	if list.OwnerID == "" || callerID == "" {
+		return false
+	}
+	if list.OwnerID == callerID {
+		return true
+	}
+	for _, collaborator := range list.Collaborators {
+		if collaborator == callerID {
+			return true
+		}
+	}
+	return false
+}
+
+// Restrict is middleware for routes under /lists/{listId}, the route is only called for callers who can access the list
+// The caller's ID is carried in the route's context so it's recorded in the list's activity
+func Restrict() iface.Middleware {
+	return restrictWith(db.Database())
+}
+
+// restrictWith checks access using database
+// A list which doesn't exist is 'Not Found', the same as the route would respond with
+func restrictWith(database db.DB) iface.Middleware {
+	return func(next iface.HandlerFunc) iface.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+			callerID, err := CallerID(request)
+			if err != nil {
+				logging.FromContext(ctx).Warn("Request failed", "error", err)
+				return problem.Respond(http.StatusUnauthorized, err.Error())
+			}
+
+			list, err := database.GetList(ctx, params.ListID)
+			if err != nil {
+				return problem.LogFromError(ctx, err)
+			}
+
+			if !CanAccess(list, callerID) {
+				logging.FromContext(ctx).Warn("Request failed", "error", fmt.Sprintf("%s can't access list %s", callerID, params.ListID))
+				return problem.Respond(http.StatusForbidden, "You don't have access to this list")
+			}
+
+			// The caller is recorded as the actor of any change the route makes to the list
+			return next(db.WithActor(ctx, callerID), request, params)
+		}
+	}
+}
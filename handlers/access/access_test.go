@@ -0,0 +1,174 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockHandler struct {
+	mock.Mock
+}
+
+func (m *mockHandler) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	args := m.Called(request, params)
+	return args.Get(0), args.Int(1)
+}
+
+func TestCallerID(t *testing.T) {
+	request := testhelpers.CreateAPIGatewayV2HTTPRequest("/lists/", "POST", "")
+
+	callerID, err := CallerID(testhelpers.WithCaller(request, "user-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", callerID)
+
+	_, err = CallerID(request)
+	assert.Equal(t, ErrorNoCaller, err)
+
+	_, err = CallerID(testhelpers.WithCaller(request, ""))
+	assert.Equal(t, ErrorNoCaller, err)
+}
+
+func TestCanAccess(t *testing.T) {
+	tests := []struct {
+		name        string
+		list        data.List
+		callerID    string
+		expectedRes bool
+	}{
+		{
+			name:        "The owner can access the list",
+			list:        data.List{OwnerID: "user-1"},
+			callerID:    "user-1",
+			expectedRes: true,
+		},
+		{
+			name:        "A collaborator can access the list",
+			list:        data.List{OwnerID: "user-1", Collaborators: []string{"user-2", "user-3"}},
+			callerID:    "user-3",
+			expectedRes: true,
+		},
+		{
+			name:        "Anyone else can't access the list",
+			list:        data.List{OwnerID: "user-1", Collaborators: []string{"user-2"}},
+			callerID:    "user-4",
+			expectedRes: false,
+		},
+		{
+			name:        "Nobody can access a list without an owner",
+			list:        data.List{},
+			callerID:    "",
+			expectedRes: false,
+		},
+		{
+			name:        "Collaborators can't access a list without an owner",
+			list:        data.List{Collaborators: []string{"user-2"}},
+			callerID:    "user-2",
+			expectedRes: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedRes, CanAccess(&tt.list, tt.callerID))
+		})
+	}
+}
+
+func TestRestrict(t *testing.T) {
+	type mockGetList struct {
+		res *data.List
+		err error
+	}
+	body := map[string]string{"route": "A"}
+
+	tests := []struct {
+		name               string
+		callerID           string
+		mockGetList        *mockGetList
+		shouldHandle       bool
+		expectedRes        interface{}
+		expectedStatusCode int
+	}{
+		{
+			name:               "Passes the request on when the caller owns the list",
+			callerID:           "user-1",
+			mockGetList:        &mockGetList{res: &data.List{ListKey: data.ListKey{ID: "list-1"}, OwnerID: "user-1"}},
+			shouldHandle:       true,
+			expectedRes:        body,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Forbidden' when the caller can't access the list",
+			callerID:           "user-2",
+			mockGetList:        &mockGetList{res: &data.List{ListKey: data.ListKey{ID: "list-1"}, OwnerID: "user-1"}},
+			expectedRes:        problem.ForStatus(403, "You don't have access to this list"),
+			expectedStatusCode: 403,
+		},
+		{
+			name:               "Returns 'Not Found' when the list doesn't exist",
+			callerID:           "user-1",
+			mockGetList:        &mockGetList{err: db.ErrorNotFound},
+			expectedRes:        problem.ForStatus(404, ""),
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Returns 'Internal Server Error' when the db returns an error",
+			callerID:           "user-1",
+			mockGetList:        &mockGetList{err: errors.New("It went wrong")},
+			expectedRes:        problem.ForStatus(500, ""),
+			expectedStatusCode: 500,
+		},
+		{
+			name:               "Returns 'Unauthorized' when there is no caller",
+			expectedRes:        problem.ForStatus(401, "Request has no caller identity"),
+			expectedStatusCode: 401,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &testhelpers.MockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			if tt.mockGetList != nil {
+				dbMocked.
+					On("GetList", "list-1").
+					Return(tt.mockGetList.res, tt.mockGetList.err).
+					Once()
+			}
+
+			input := testhelpers.CreateAPIGatewayV2HTTPRequest("/lists/list-1", "GET", "")
+			params := iface.PathParams{ListID: "list-1"}
+			if tt.callerID != "" {
+				input = testhelpers.WithCaller(input, tt.callerID)
+			}
+
+			next := &mockHandler{}
+			next.Test(t)
+			defer next.AssertExpectations(t)
+			if tt.shouldHandle {
+				next.
+					On("Handle", input, params).
+					Return(body, 200).
+					Once()
+			}
+
+			handle := restrictWith(dbMocked)(next.Handle)
+			gotRes, statusCode := handle(context.Background(), input, params)
+
+			assert.Equal(t, tt.expectedStatusCode, statusCode)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
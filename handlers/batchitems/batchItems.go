@@ -0,0 +1,90 @@
+package batchitems
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/validation"
+	"github.com/mount-joy/thelist-lambda/logging"
+)
+
+// maxOperations is the most operations accepted in a single request
+const maxOperations = 100
+
+type batchItems struct {
+	db db.DB
+}
+
+type batchResponse struct {
+	Results []data.BatchResult `json:"Results"`
+}
+
+// New returns an instance of batchItems satisfying the RouteHandler interface
+func New() iface.RouteHandler {
+	return &batchItems{
+		db: db.Database(),
+	}
+}
+
+// Handle runs every operation in the body and returns the outcome of each one and the status code
+func (b *batchItems) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	operations, err := getOperations(request.Body)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return validation.Respond(err)
+	}
+
+	results, err := b.db.BatchWriteItems(ctx, params.ListID, operations)
+	if err != nil {
+		return problem.LogFromError(ctx, err)
+	}
+
+	return &batchResponse{Results: results}, http.StatusOK
+}
+
+func getOperations(body string) ([]data.BatchOperation, error) {
+	type Input struct {
+		Operations []data.BatchOperation `json:"Operations"`
+	}
+
+	var input Input
+	if err := validation.Decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	v := validation.Validator{}
+	if len(input.Operations) == 0 {
+		v.Add("Operations", "is required")
+	}
+	if len(input.Operations) > maxOperations {
+		v.Add("Operations", fmt.Sprintf("must have at most %d operations, got %d", maxOperations, len(input.Operations)))
+		return nil, v.Err()
+	}
+
+	// dynamodb rejects a batch which touches the same item twice
+	deleted := map[string]bool{}
+	for i, operation := range input.Operations {
+		field := fmt.Sprintf("Operations[%d]", i)
+		switch operation.Action {
+		case data.BatchActionCreate:
+			v.Required(field+".Name", operation.Name)
+			input.Operations[i].Name = v.Name(field+".Name", operation.Name, validation.MaxItemNameLength)
+		case data.BatchActionDelete:
+			v.Required(field+".Id", operation.ID)
+			if operation.ID != "" && deleted[operation.ID] {
+				v.Add(field+".Id", fmt.Sprintf("%q is deleted more than once", operation.ID))
+			}
+			deleted[operation.ID] = true
+		default:
+			v.Add(field+".Action", fmt.Sprintf("must be %q or %q", data.BatchActionCreate, data.BatchActionDelete))
+		}
+	}
+
+	return input.Operations, v.Err()
+}
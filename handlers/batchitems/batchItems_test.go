@@ -0,0 +1,176 @@
+package batchitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBatchWriteItems struct {
+	res []data.BatchResult
+	err error
+}
+
+func TestBatchItemsHandle(t *testing.T) {
+	path := "/lists/test-list-id/items:batch"
+	operations := []data.BatchOperation{
+		{Action: data.BatchActionCreate, Name: "Milk"},
+		{Action: data.BatchActionDelete, ID: "888"},
+	}
+	body := `{"Operations": [{"Action": "create", "Name": "Milk"}, {"Action": "delete", "Id": "888"}]}`
+	results := []data.BatchResult{
+		{Action: data.BatchActionCreate, ID: "999", Succeeded: true, Item: &data.Item{Name: "Milk", ItemKey: data.ItemKey{ID: "999"}}},
+		{Action: data.BatchActionDelete, ID: "888", Succeeded: false, Error: "Not processed, try again later"},
+	}
+	tooMany := `{"Operations": [` + strings.TrimSuffix(strings.Repeat(`{"Action": "create", "Name": "Milk"},`, maxOperations+1), ",") + `]}`
+
+	tests := []struct {
+		name               string
+		path               string
+		body               string
+		operations         []data.BatchOperation
+		mockOutput         *mockBatchWriteItems
+		expectedRes        interface{}
+		expectedStatusCode int
+	}{
+		{
+			name:               "Returns 'OK' and the outcome of every operation",
+			path:               path,
+			body:               body,
+			mockOutput:         &mockBatchWriteItems{res: results},
+			expectedRes:        &batchResponse{Results: results},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Names to create are trimmed and normalised",
+			path:               path,
+			body:               `{"Operations": [{"Action": "create", "Name": " Cafe\u0301 "}]}`,
+			operations:         []data.BatchOperation{{Action: data.BatchActionCreate, Name: "Caf\u00e9"}},
+			mockOutput:         &mockBatchWriteItems{res: results[:1]},
+			expectedRes:        &batchResponse{Results: results[:1]},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Bad Request' when the db rejects an operation",
+			path:               path,
+			body:               body,
+			mockOutput:         &mockBatchWriteItems{err: fmt.Errorf("%w: nope", db.ErrorBadRequest)},
+			expectedRes:        problem.ForStatus(400, "Bad Request: nope"),
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Returns 'Internal Server Error' when the db returns an error",
+			path:               path,
+			body:               body,
+			mockOutput:         &mockBatchWriteItems{err: errors.New("Something bad happened")},
+			expectedRes:        problem.ForStatus(500, ""),
+			expectedStatusCode: 500,
+		},
+		{
+			name:               "Returns 'Bad Request' when the body is not valid json",
+			path:               path,
+			body:               `{"Operations": [`,
+			expectedRes:        problem.ForStatus(400, "unexpected EOF"),
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when there are no operations",
+			path:               path,
+			body:               `{"Operations": []}`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Operations", Message: "is required"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when there are too many operations",
+			path:               path,
+			body:               tooMany,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Operations", Message: "must have at most 100 operations, got 101"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when a create has no name",
+			path:               path,
+			body:               `{"Operations": [{"Action": "create"}]}`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Operations[0].Name", Message: "is required"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when a delete has no ID",
+			path:               path,
+			body:               `{"Operations": [{"Action": "delete"}]}`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Operations[0].Id", Message: "is required"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the same item is deleted twice",
+			path:               path,
+			body:               `{"Operations": [{"Action": "delete", "Id": "888"}, {"Action": "delete", "Id": "888"}]}`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Operations[1].Id", Message: `"888" is deleted more than once`}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when a name to create is too long",
+			path:               path,
+			body:               `{"Operations": [{"Action": "create", "Name": "` + strings.Repeat("a", 201) + `"}]}`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Operations[0].Name", Message: "must be at most 200 characters"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when a name to create has control characters",
+			path:               path,
+			body:               `{"Operations": [{"Action": "create", "Name": "Milk\u0007"}]}`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Operations[0].Name", Message: "must not contain control characters"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' for an unknown field",
+			path:               path,
+			body:               `{"Operations": [{"Action": "create", "Name": "Milk", "Colour": "white"}]}`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Colour", Message: "is not a known field"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' for an unknown action",
+			path:               path,
+			body:               `{"Operations": [{"Action": "update", "Id": "888"}]}`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Operations[0].Action", Message: `must be "create" or "delete"`}),
+			expectedStatusCode: 422,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &testhelpers.MockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			if tt.mockOutput != nil {
+				expectedOperations := operations
+				if tt.operations != nil {
+					expectedOperations = tt.operations
+				}
+				dbMocked.
+					On("BatchWriteItems", "test-list-id", expectedOperations).
+					Return(tt.mockOutput.res, tt.mockOutput.err).
+					Once()
+			}
+
+			b := batchItems{db: dbMocked}
+
+			input := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, "POST", tt.body)
+			gotRes, statusCode := b.Handle(context.Background(), input, iface.PathParams{ListID: "test-list-id"})
+
+			assert.Equal(t, tt.expectedStatusCode, statusCode)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package deletecompleteditems
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/logging"
+)
+
+type deleteCompletedItems struct {
+	db db.DB
+}
+
+type deleteResponse struct {
+	Deleted int `json:"Deleted"`
+}
+
+// New returns an instance of deleteCompletedItems satisfying the RouteHandler interface
+func New() iface.RouteHandler {
+	return &deleteCompletedItems{
+		db: db.Database(),
+	}
+}
+
+// Handle removes every completed item on the list and returns how many were removed and the status code
+func (d *deleteCompletedItems) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	// Only clearing completed items is supported, never delete everything by accident
+	if request.QueryStringParameters["completed"] != "true" {
+		logging.FromContext(ctx).Warn("Request failed", "error", "Deleting items requires completed=true")
+		return problem.Respond(http.StatusBadRequest, "Only completed items can be deleted", problem.Field{Name: "completed", Message: "must be true"})
+	}
+
+	deleted, err := d.db.DeleteCompletedItems(ctx, params.ListID)
+	if err != nil {
+		p, status := partialProblem(err, deleted)
+		logging.FromContext(ctx).Log(logging.LevelForStatus(status), "Request failed", "error", err, "deleted", deleted)
+		return p, status
+	}
+
+	return &deleteResponse{Deleted: deleted}, http.StatusOK
+}
+
+// partialProblem is the Problem for err, saying how many items were deleted before it, as those stay deleted
+// Sending the request again deletes the rest
+func partialProblem(err error, deleted int) (*problem.Problem, int) {
+	p, status := problem.FromError(err)
+	if deleted == 0 {
+		return p, status
+	}
+
+	detail := fmt.Sprintf("%d completed items were deleted before the request failed, try again to delete the rest", deleted)
+	if p.Detail != "" {
+		detail = p.Detail + ", " + detail
+	}
+	p.Detail = detail
+	return p, status
+}
@@ -0,0 +1,108 @@
+package deletecompleteditems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDeleteCompletedItems struct {
+	res int
+	err error
+}
+
+func TestDeleteCompletedItemsHandle(t *testing.T) {
+	tests := []struct {
+		name               string
+		path               string
+		query              map[string]string
+		mockOutput         *mockDeleteCompletedItems
+		expectedRes        interface{}
+		expectedStatusCode int
+	}{
+		{
+			name:               "Returns 'OK' and the number of items removed",
+			path:               "/lists/test-list-id/items",
+			query:              map[string]string{"completed": "true"},
+			mockOutput:         &mockDeleteCompletedItems{res: 3},
+			expectedRes:        &deleteResponse{Deleted: 3},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'OK' when nothing was completed",
+			path:               "/lists/test-list-id/items/",
+			query:              map[string]string{"completed": "true"},
+			mockOutput:         &mockDeleteCompletedItems{res: 0},
+			expectedRes:        &deleteResponse{Deleted: 0},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Internal Server Error' when the db returns an error",
+			path:               "/lists/test-list-id/items",
+			query:              map[string]string{"completed": "true"},
+			mockOutput:         &mockDeleteCompletedItems{err: errors.New("Something bad happened")},
+			expectedRes:        problem.ForStatus(500, ""),
+			expectedStatusCode: 500,
+		},
+		{
+			name:               "Returns 'Internal Server Error' with how many items were deleted when some were deleted before an error",
+			path:               "/lists/test-list-id/items",
+			query:              map[string]string{"completed": "true"},
+			mockOutput:         &mockDeleteCompletedItems{res: 2, err: errors.New("Something bad happened")},
+			expectedRes:        problem.ForStatus(500, "2 completed items were deleted before the request failed, try again to delete the rest"),
+			expectedStatusCode: 500,
+		},
+		{
+			name:               "Returns 'Precondition Failed' with how many items were deleted when the list kept changing",
+			path:               "/lists/test-list-id/items",
+			query:              map[string]string{"completed": "true"},
+			mockOutput:         &mockDeleteCompletedItems{res: 1, err: fmt.Errorf("%w: the list kept changing", db.ErrorPreconditionFailed)},
+			expectedRes:        problem.ForStatus(412, "Precondition Failed: the list kept changing, 1 completed items were deleted before the request failed, try again to delete the rest"),
+			expectedStatusCode: 412,
+		},
+		{
+			name:               "Returns 'Bad Request' without completed=true",
+			path:               "/lists/test-list-id/items",
+			expectedRes:        problem.ForStatus(400, "Only completed items can be deleted", problem.Field{Name: "completed", Message: "must be true"}),
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Returns 'Bad Request' with completed=false",
+			path:               "/lists/test-list-id/items",
+			query:              map[string]string{"completed": "false"},
+			expectedRes:        problem.ForStatus(400, "Only completed items can be deleted", problem.Field{Name: "completed", Message: "must be true"}),
+			expectedStatusCode: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &testhelpers.MockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			if tt.mockOutput != nil {
+				dbMocked.
+					On("DeleteCompletedItems", "test-list-id").
+					Return(tt.mockOutput.res, tt.mockOutput.err).
+					Once()
+			}
+
+			d := deleteCompletedItems{db: dbMocked}
+
+			input := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, "DELETE", "")
+			input.QueryStringParameters = tt.query
+			gotRes, statusCode := d.Handle(context.Background(), input, iface.PathParams{ListID: "test-list-id"})
+
+			assert.Equal(t, tt.expectedStatusCode, statusCode)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
@@ -1,15 +1,15 @@
 package deleteitem
 
 import (
-	"fmt"
-	"log"
+	"context"
 	"net/http"
-	"regexp"
-	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/etag"
 	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/logging"
 )
 
 type deleteItem struct {
@@ -19,38 +19,22 @@ type deleteItem struct {
 // New returns an instance of deleteItem satisfying the RouteHandler interface
 func New() iface.RouteHandler {
 	return &deleteItem{
-		db: db.DynamoDB(),
+		db: db.Database(),
 	}
 }
 
-// Match returns true if this RouteHandler should handle this request
-func (d *deleteItem) Match(request events.APIGatewayV2HTTPRequest) bool {
-	// DELETE /lists/<list_id>/items/<item_id>
-	var re = regexp.MustCompile(`^/lists/([\w-]+)/items/([\w-]+)/?$`)
-	return request.RequestContext.HTTP.Method == "DELETE" && re.MatchString(request.RequestContext.HTTP.Path)
-}
-
 // Handle handles this request and returns the response and status code
-func (d *deleteItem) Handle(request events.APIGatewayV2HTTPRequest) (interface{}, int) {
-	listID, itemID, err := getIDs(request.RequestContext.HTTP.Path)
+func (d *deleteItem) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	expectedVersion, err := etag.IfMatch(request)
 	if err != nil {
-		log.Printf("Error: %s", err.Error())
-		return nil, http.StatusBadRequest
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return problem.Respond(http.StatusPreconditionFailed, err.Error())
 	}
 
-	err = d.db.DeleteItem(listID, itemID)
+	err = d.db.DeleteItem(ctx, params.ListID, params.ItemID, expectedVersion)
 	if err != nil {
-		log.Printf("Error: %s", err.Error())
-		return nil, http.StatusInternalServerError
+		return problem.LogFromError(ctx, err)
 	}
 
 	return nil, http.StatusOK
 }
-
-func getIDs(path string) (string, string, error) {
-	parts := strings.SplitN(path, "/", 6)
-	if len(parts) < 5 {
-		return "", "", fmt.Errorf("Unable to match path: %s", path)
-	}
-	return parts[2], parts[4], nil
-}
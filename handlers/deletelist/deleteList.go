@@ -0,0 +1,40 @@
+package deletelist
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/etag"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/logging"
+)
+
+type deleteList struct {
+	db db.DB
+}
+
+// New returns an instance of deleteList satisfying the RouteHandler interface
+func New() iface.RouteHandler {
+	return &deleteList{
+		db: db.Database(),
+	}
+}
+
+// Handle deletes the list and all of the items on it, returning the response and status code
+func (d *deleteList) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	expectedVersion, err := etag.IfMatch(request)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return problem.Respond(http.StatusPreconditionFailed, err.Error())
+	}
+
+	err = d.db.DeleteList(ctx, params.ListID, expectedVersion)
+	if err != nil {
+		return problem.LogFromError(ctx, err)
+	}
+
+	return nil, http.StatusOK
+}
@@ -0,0 +1,109 @@
+package deletelist
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteListHandle(t *testing.T) {
+	version := int64(2)
+	tests := []struct {
+		name               string
+		path               string
+		listID             string
+		ifMatch            string
+		expectedVersion    *int64
+		callsDB            bool
+		mockErr            error
+		expectedRes        interface{}
+		expectedStatusCode int
+	}{
+		{
+			name:               "Returns 'OK' when the list is deleted",
+			path:               "/lists/test-list-id/",
+			listID:             "test-list-id",
+			callsDB:            true,
+			mockErr:            nil,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Not Found' when the list does not exist",
+			path:               "/lists/test-list-id",
+			listID:             "test-list-id",
+			callsDB:            true,
+			mockErr:            db.ErrorNotFound,
+			expectedRes:        problem.ForStatus(404, ""),
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Returns 'Internal Server Error' when the db returns an error",
+			path:               "/lists/test-list-id",
+			listID:             "test-list-id",
+			callsDB:            true,
+			mockErr:            errors.New("Something bad happened"),
+			expectedRes:        problem.ForStatus(500, ""),
+			expectedStatusCode: 500,
+		},
+		{
+			name:               "Returns 'OK' when the list is at the version in If-Match",
+			path:               "/lists/test-list-id",
+			listID:             "test-list-id",
+			ifMatch:            `"2"`,
+			expectedVersion:    &version,
+			callsDB:            true,
+			mockErr:            nil,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Precondition Failed' when the list is not at the version in If-Match",
+			path:               "/lists/test-list-id",
+			listID:             "test-list-id",
+			ifMatch:            `"2"`,
+			expectedVersion:    &version,
+			callsDB:            true,
+			mockErr:            db.ErrorPreconditionFailed,
+			expectedRes:        problem.ForStatus(412, ""),
+			expectedStatusCode: 412,
+		},
+		{
+			name:               "Returns 'Precondition Failed' when If-Match is not a valid ETag",
+			path:               "/lists/test-list-id",
+			ifMatch:            `W/"2"`,
+			expectedRes:        problem.ForStatus(412, "If-Match is not a valid ETag"),
+			expectedStatusCode: 412,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &testhelpers.MockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			if tt.callsDB {
+				dbMocked.
+					On("DeleteList", tt.listID, tt.expectedVersion).
+					Return(tt.mockErr).
+					Once()
+			}
+
+			d := deleteList{db: dbMocked}
+
+			input := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, "DELETE", "")
+			if tt.ifMatch != "" {
+				input.Headers = map[string]string{"if-match": tt.ifMatch}
+			}
+			gotRes, statusCode := d.Handle(context.Background(), input, iface.PathParams{ListID: "test-list-id"})
+
+			assert.Equal(t, tt.expectedStatusCode, statusCode)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
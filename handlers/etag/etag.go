@@ -0,0 +1,53 @@
+package etag
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+)
+
+// Header is the response header carrying the version of the returned record
+const Header = "ETag"
+
+// IfMatchHeader is the request header carrying the version the caller expects to be changing
+const IfMatchHeader = "If-Match"
+
+// ErrorInvalid is returned when the If-Match header is not an ETag this API could have produced
+var ErrorInvalid = errors.New("If-Match is not a valid ETag")
+
+// Format returns the ETag for a record at the given version
+func Format(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// Response wraps body so that it is returned with the ETag for version
+func Response(body interface{}, version int64) *iface.Response {
+	return &iface.Response{
+		Body:    body,
+		Headers: map[string]string{Header: Format(version)},
+	}
+}
+
+// IfMatch returns the version from the request's If-Match header
+// nil is returned when there is no header or it is "*", as then any version may be changed
+func IfMatch(request events.APIGatewayV2HTTPRequest) (*int64, error) {
+	value := strings.TrimSpace(iface.GetHeader(request.Headers, IfMatchHeader))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+
+	// If-Match always uses the strong comparison, so a weak ETag can never match
+	unquoted, err := strconv.Unquote(value)
+	if err != nil || !strings.HasPrefix(value, `"`) {
+		return nil, ErrorInvalid
+	}
+
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version < 0 {
+		return nil, ErrorInvalid
+	}
+	return &version, nil
+}
@@ -0,0 +1,90 @@
+package etag
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, `"0"`, Format(0))
+	assert.Equal(t, `"42"`, Format(42))
+}
+
+func TestResponse(t *testing.T) {
+	body := map[string]string{"Name": "Milk"}
+	assert.Equal(t, &iface.Response{Body: body, Headers: map[string]string{"ETag": `"3"`}}, Response(body, 3))
+}
+
+func TestIfMatch(t *testing.T) {
+	three := int64(3)
+	zero := int64(0)
+
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		expectedRes *int64
+		expectedErr error
+	}{
+		{
+			name:        "Returns nil when there is no If-Match header",
+			headers:     nil,
+			expectedRes: nil,
+			expectedErr: nil,
+		},
+		{
+			name:        "Returns nil for a wildcard",
+			headers:     map[string]string{"if-match": "*"},
+			expectedRes: nil,
+			expectedErr: nil,
+		},
+		{
+			name:        "Returns the version from a quoted ETag",
+			headers:     map[string]string{"if-match": `"3"`},
+			expectedRes: &three,
+			expectedErr: nil,
+		},
+		{
+			name:        "Ignores the case of the header name",
+			headers:     map[string]string{"If-Match": `"0"`},
+			expectedRes: &zero,
+			expectedErr: nil,
+		},
+		{
+			name:        "Rejects an unquoted ETag",
+			headers:     map[string]string{"if-match": "3"},
+			expectedRes: nil,
+			expectedErr: ErrorInvalid,
+		},
+		{
+			name:        "Rejects a weak ETag",
+			headers:     map[string]string{"if-match": `W/"3"`},
+			expectedRes: nil,
+			expectedErr: ErrorInvalid,
+		},
+		{
+			name:        "Rejects an ETag which isn't a version",
+			headers:     map[string]string{"if-match": `"abc"`},
+			expectedRes: nil,
+			expectedErr: ErrorInvalid,
+		},
+		{
+			name:        "Rejects a list of ETags",
+			headers:     map[string]string{"if-match": `"3", "4"`},
+			expectedRes: nil,
+			expectedErr: ErrorInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := events.APIGatewayV2HTTPRequest{Headers: tt.headers}
+			gotRes, gotErr := IfMatch(request)
+
+			assert.Equal(t, tt.expectedErr, gotErr)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
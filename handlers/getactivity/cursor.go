@@ -0,0 +1,23 @@
+package getactivity
+
+import (
+	"fmt"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/handlers/pagination"
+)
+
+// decodeCursor returns the key in cursor, rejecting anything that isn't a key in the requested list's activity
+func decodeCursor(cursor string, listID string) (*data.ActivityKey, error) {
+	var key data.ActivityKey
+	ok, err := pagination.DecodeCursor(cursor, &key)
+	if !ok || err != nil {
+		return nil, err
+	}
+
+	if key.ID == "" || key.ListID != listID {
+		return nil, fmt.Errorf("Cursor %q does not belong to list %q", cursor, listID)
+	}
+
+	return &key, nil
+}
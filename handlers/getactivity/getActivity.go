@@ -0,0 +1,62 @@
+package getactivity
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/pagination"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/logging"
+)
+
+type getActivity struct {
+	db db.DB
+}
+
+type activityPage struct {
+	Activity   []data.Activity `json:"Activity"`
+	NextCursor string          `json:"NextCursor,omitempty"`
+}
+
+// New returns an instance of getActivity satisfying the RouteHandler interface
+func New() iface.RouteHandler {
+	return &getActivity{
+		db: db.Database(),
+	}
+}
+
+// Handle handles this request and returns the response and status code
+// The list's activity is returned newest first, a page at a time
+func (g *getActivity) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	// A list's activity only grows, so it's always paginated
+	limit, err := pagination.ParseLimit(request.QueryStringParameters["limit"], pagination.DefaultPageSize)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return problem.Respond(http.StatusBadRequest, "Invalid query parameter", problem.Field{Name: "limit", Message: err.Error()})
+	}
+
+	startKey, err := decodeCursor(request.QueryStringParameters["cursor"], params.ListID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return problem.Respond(http.StatusBadRequest, "Invalid query parameter", problem.Field{Name: "cursor", Message: err.Error()})
+	}
+
+	activities, nextKey, err := g.db.GetActivity(ctx, params.ListID, limit, startKey)
+	if err != nil {
+		return problem.LogFromError(ctx, err)
+	}
+
+	page := &activityPage{Activity: *activities}
+	if nextKey != nil {
+		page.NextCursor, err = pagination.EncodeCursor(nextKey)
+		if err != nil {
+			return problem.LogFromError(ctx, err)
+		}
+	}
+
+	return page, http.StatusOK
+}
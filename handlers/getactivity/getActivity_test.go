@@ -0,0 +1,124 @@
+package getactivity
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/pagination"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockGetActivity struct {
+	limit    int64
+	startKey *data.ActivityKey
+	res      *[]data.Activity
+	nextKey  *data.ActivityKey
+	err      error
+}
+
+func TestGetActivityHandle(t *testing.T) {
+	listID := "test-list-id"
+	path := "/lists/test-list-id/activity"
+	key := data.ActivityKey{ListID: listID, ID: "2020-01-23T09:59:14.939653100Z#888"}
+	activity := data.Activity{ActivityKey: key, Actor: "user-1", Action: data.ActivityCreateItem, ItemID: "888", After: map[string]interface{}{"Name": "ABC"}}
+	cursor, _ := pagination.EncodeCursor(key)
+	otherListCursor, _ := pagination.EncodeCursor(data.ActivityKey{ListID: "other-list-id", ID: key.ID})
+
+	tests := []struct {
+		name               string
+		query              map[string]string
+		mockOutput         *mockGetActivity
+		expectedRes        interface{}
+		expectedStatusCode int
+	}{
+		{
+			name:  "Returns the first page using the default page size",
+			query: map[string]string{},
+			mockOutput: &mockGetActivity{
+				limit: pagination.DefaultPageSize,
+				res:   &[]data.Activity{activity},
+			},
+			expectedRes:        &activityPage{Activity: []data.Activity{activity}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:  "Returns a cursor when there may be more activity",
+			query: map[string]string{"limit": "1"},
+			mockOutput: &mockGetActivity{
+				limit:   1,
+				res:     &[]data.Activity{activity},
+				nextKey: &key,
+			},
+			expectedRes:        &activityPage{Activity: []data.Activity{activity}, NextCursor: cursor},
+			expectedStatusCode: 200,
+		},
+		{
+			name:  "Continues from the cursor",
+			query: map[string]string{"cursor": cursor, "limit": "10"},
+			mockOutput: &mockGetActivity{
+				limit:    10,
+				startKey: &key,
+				res:      &[]data.Activity{},
+			},
+			expectedRes:        &activityPage{Activity: []data.Activity{}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:  "Returns 'Internal Server Error' when the db returns an error",
+			query: map[string]string{},
+			mockOutput: &mockGetActivity{
+				limit: pagination.DefaultPageSize,
+				err:   errors.New("It went wrong"),
+			},
+			expectedRes:        problem.ForStatus(500, ""),
+			expectedStatusCode: 500,
+		},
+		{
+			name:               "Returns 'Bad Request' when the limit is too large",
+			query:              map[string]string{"limit": "101"},
+			expectedRes:        problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "limit", Message: "limit must be a number between 1 and 100, got \"101\""}),
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Returns 'Bad Request' when the limit is zero",
+			query:              map[string]string{"limit": "0"},
+			expectedRes:        problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "limit", Message: "limit must be a number between 1 and 100, got \"0\""}),
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Returns 'Bad Request' when the cursor is for another list",
+			query:              map[string]string{"cursor": otherListCursor},
+			expectedRes:        problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "cursor", Message: "Cursor \"" + otherListCursor + "\" does not belong to list \"test-list-id\""}),
+			expectedStatusCode: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &testhelpers.MockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			if tt.mockOutput != nil {
+				dbMocked.
+					On("GetActivity", listID, tt.mockOutput.limit, tt.mockOutput.startKey).
+					Return(tt.mockOutput.res, tt.mockOutput.nextKey, tt.mockOutput.err).
+					Once()
+			}
+
+			g := getActivity{db: dbMocked}
+
+			input := testhelpers.CreateAPIGatewayV2HTTPRequest(path, "GET", "")
+			input.QueryStringParameters = tt.query
+			gotRes, statusCode := g.Handle(context.Background(), input, iface.PathParams{ListID: listID})
+
+			assert.Equal(t, tt.expectedStatusCode, statusCode)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package getchanges
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/config"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/logging"
+)
+
+// overlap is how far before a token changes are fetched from again
+// The index of changes is only eventually consistent, and timestamps in the same second don't sort as strings,
+// so clients may see a change twice and should keep the copy with the highest Version
+const overlap = 5 * time.Second
+
+type getChanges struct {
+	db  db.DB
+	now func() time.Time
+	// retention is how long tombstones are kept, a token older than that may have missed deletions which have since been purged
+	retention time.Duration
+}
+
+type changes struct {
+	Items []data.Item `json:"Items"`
+	Token string      `json:"Token"`
+}
+
+// New returns an instance of getChanges satisfying the RouteHandler interface
+func New() iface.RouteHandler {
+	return &getChanges{
+		db:        db.Database(),
+		now:       time.Now,
+		retention: config.GetConfiguration().TombstoneRetention,
+	}
+}
+
+// Handle returns the items created, updated or deleted since the token in the since parameter, and a token to continue from
+// Without a token every item on the list is returned, deleted items are only returned once a client has a token
+func (g *getChanges) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	since, err := decodeToken(request.QueryStringParameters["since"], params.ListID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return problem.Respond(http.StatusBadRequest, "Invalid query parameter", problem.Field{Name: "since", Message: err.Error()})
+	}
+	if since != nil && g.expired(*since) {
+		logging.FromContext(ctx).Warn("Token has expired", "updated", since.Updated)
+		return problem.Respond(http.StatusGone, "The token is too old to continue from, fetch the list again without one")
+	}
+
+	res, err := g.getChanges(ctx, params.ListID, since)
+	if err != nil {
+		return problem.LogFromError(ctx, err)
+	}
+
+	return res, http.StatusOK
+}
+
+// expired returns true if tombstones deleted since the token may already have been purged
+func (g *getChanges) expired(since token) bool {
+	updated, _ := time.Parse(time.RFC3339Nano, since.Updated)
+	return updated.Before(g.now().Add(-g.retention))
+}
+
+func (g *getChanges) getChanges(ctx context.Context, listID string, since *token) (*changes, error) {
+	// Nothing has changed since now, but a change being written as we read may not have been seen yet
+	latest := g.now().UTC().Add(-overlap)
+	from := ""
+	if since != nil {
+		latest, _ = time.Parse(time.RFC3339Nano, since.Updated)
+		from = latest.Truncate(time.Second).Add(-overlap).Format(time.RFC3339)
+	}
+
+	items, err := g.db.GetItemChanges(ctx, listID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &changes{Items: []data.Item{}}
+	for _, item := range *items {
+		if since == nil && item.DeletedTimestamp != "" {
+			continue
+		}
+		if updated, err := time.Parse(time.RFC3339Nano, item.UpdatedTimestamp); err == nil && updated.After(latest) {
+			latest = updated
+		}
+		res.Items = append(res.Items, item)
+	}
+
+	res.Token, err = encodeToken(token{ListID: listID, Updated: latest.Format(time.RFC3339Nano)})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
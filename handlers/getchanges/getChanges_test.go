@@ -0,0 +1,119 @@
+package getchanges
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockGetItemChanges struct {
+	since string
+	res   *[]data.Item
+	err   error
+}
+
+func TestGetChangesHandle(t *testing.T) {
+	listID := "test-list-id"
+	path := "/lists/test-list-id/changes"
+	now := time.Date(2020, 1, 23, 10, 0, 0, 0, time.UTC)
+	tokenFor := func(listID string, updated string) string {
+		value, _ := encodeToken(token{ListID: listID, Updated: updated})
+		return value
+	}
+
+	item := data.Item{Name: "ABC", ItemKey: data.ItemKey{ID: "888", ListID: listID}, UpdatedTimestamp: "2020-01-23T09:59:14.5Z"}
+	tombstone := data.Item{Name: "DEF", ItemKey: data.ItemKey{ID: "999", ListID: listID}, UpdatedTimestamp: "2020-01-23T09:59:14.25Z", DeletedTimestamp: "2020-01-23T09:59:14.25Z"}
+
+	tests := []struct {
+		name               string
+		query              map[string]string
+		mockOutput         *mockGetItemChanges
+		expectedRes        interface{}
+		expectedStatusCode int
+	}{
+		{
+			name:       "Without a token returns every item which hasn't been deleted",
+			mockOutput: &mockGetItemChanges{since: "", res: &[]data.Item{tombstone, item}},
+			expectedRes: &changes{
+				Items: []data.Item{item},
+				Token: tokenFor(listID, "2020-01-23T09:59:55Z"),
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:       "With a token returns changes including deletions and the latest timestamp as the next token",
+			query:      map[string]string{"since": tokenFor(listID, "2020-01-23T09:59:10.75Z")},
+			mockOutput: &mockGetItemChanges{since: "2020-01-23T09:59:05Z", res: &[]data.Item{tombstone, item}},
+			expectedRes: &changes{
+				Items: []data.Item{tombstone, item},
+				Token: tokenFor(listID, "2020-01-23T09:59:14.5Z"),
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:       "With a token and no changes returns the same token",
+			query:      map[string]string{"since": tokenFor(listID, "2020-01-23T09:59:10.75Z")},
+			mockOutput: &mockGetItemChanges{since: "2020-01-23T09:59:05Z", res: &[]data.Item{}},
+			expectedRes: &changes{
+				Items: []data.Item{},
+				Token: tokenFor(listID, "2020-01-23T09:59:10.75Z"),
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Internal Server Error' when the db returns an error",
+			mockOutput:         &mockGetItemChanges{since: "", err: errors.New("It went wrong")},
+			expectedRes:        problem.ForStatus(500, ""),
+			expectedStatusCode: 500,
+		},
+		{
+			name:               "Returns 'Bad Request' when the token is malformed",
+			query:              map[string]string{"since": "not a token"},
+			expectedRes:        problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "since", Message: "Malformed cursor: illegal base64 data at input byte 3"}),
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Returns 'Gone' when the token is older than tombstones are kept for",
+			query:              map[string]string{"since": tokenFor(listID, "2020-01-22T09:59:59Z")},
+			expectedRes:        problem.New(410, problem.CodeTokenExpired, "The token is too old to continue from, fetch the list again without one"),
+			expectedStatusCode: 410,
+		},
+		{
+			name:               "Returns 'Bad Request' when the token is for another list",
+			query:              map[string]string{"since": tokenFor("other-list-id", "2020-01-23T09:59:10Z")},
+			expectedRes:        problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "since", Message: "Token \"" + tokenFor("other-list-id", "2020-01-23T09:59:10Z") + "\" does not belong to list \"test-list-id\""}),
+			expectedStatusCode: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &testhelpers.MockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			if tt.mockOutput != nil {
+				dbMocked.
+					On("GetItemChanges", listID, tt.mockOutput.since).
+					Return(tt.mockOutput.res, tt.mockOutput.err).
+					Once()
+			}
+
+			g := getChanges{db: dbMocked, now: func() time.Time { return now }, retention: 24 * time.Hour}
+
+			input := testhelpers.CreateAPIGatewayV2HTTPRequest(path, "GET", "")
+			input.QueryStringParameters = tt.query
+			gotRes, statusCode := g.Handle(context.Background(), input, iface.PathParams{ListID: listID})
+
+			assert.Equal(t, tt.expectedStatusCode, statusCode)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package getchanges
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mount-joy/thelist-lambda/handlers/pagination"
+)
+
+// token records how far through a list's changes a client has got
+type token struct {
+	ListID  string `json:"ListId"`
+	Updated string `json:"Updated"`
+}
+
+// encodeToken turns the token into an opaque string clients can pass back to us
+func encodeToken(t token) (string, error) {
+	return pagination.EncodeCursor(t)
+}
+
+// decodeToken reverses encodeToken, rejecting anything that isn't a token for the requested list
+func decodeToken(value string, listID string) (*token, error) {
+	var t token
+	ok, err := pagination.DecodeCursor(value, &t)
+	if !ok || err != nil {
+		return nil, err
+	}
+
+	if _, err := time.Parse(time.RFC3339Nano, t.Updated); err != nil {
+		return nil, fmt.Errorf("Malformed token: %s", err.Error())
+	}
+
+	if t.ListID != listID {
+		return nil, fmt.Errorf("Token %q does not belong to list %q", value, listID)
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,55 @@
+package getchanges
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeToken(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name        string
+		value       string
+		expectedRes *token
+		wantErr     bool
+	}{
+		{
+			name:        "Empty token returns no token",
+			value:       "",
+			expectedRes: nil,
+			wantErr:     false,
+		},
+		{
+			name:    "Token without a timestamp is rejected",
+			value:   encode(`{"ListId": "474c2Fff7"}`),
+			wantErr: true,
+		},
+		{
+			name:    "Token for a different list is rejected",
+			value:   encode(`{"ListId": "other-list", "Updated": "2020-01-23T09:59:14Z"}`),
+			wantErr: true,
+		},
+		{
+			name:        "Valid token is returned",
+			value:       encode(`{"ListId": "474c2Fff7", "Updated": "2020-01-23T09:59:14Z"}`),
+			expectedRes: &token{ListID: "474c2Fff7", Updated: "2020-01-23T09:59:14Z"},
+			wantErr:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRes, gotErr := decodeToken(tt.value, "474c2Fff7")
+
+			assert.Equal(t, tt.expectedRes, gotRes)
+			if tt.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
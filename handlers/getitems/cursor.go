@@ -0,0 +1,23 @@
+package getitems
+
+import (
+	"fmt"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/handlers/pagination"
+)
+
+// decodeCursor returns the key in cursor, rejecting anything that isn't a key on the requested list
+func decodeCursor(cursor string, listID string) (*data.ItemPositionKey, error) {
+	var key data.ItemPositionKey
+	ok, err := pagination.DecodeCursor(cursor, &key)
+	if !ok || err != nil {
+		return nil, err
+	}
+
+	if key.ID == "" || key.ListID != listID {
+		return nil, fmt.Errorf("Cursor %q does not belong to list %q", cursor, listID)
+	}
+
+	return &key, nil
+}
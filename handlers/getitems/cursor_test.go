@@ -0,0 +1,74 @@
+package getitems
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/handlers/pagination"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	key := data.ItemPositionKey{ItemKey: data.ItemKey{ID: "1c2fa0a1", ListID: "474c2Fff7"}, Position: 1579773554939.5}
+
+	cursor, err := pagination.EncodeCursor(key)
+	assert.NoError(t, err)
+
+	gotKey, gotErr := decodeCursor(cursor, "474c2Fff7")
+
+	assert.NoError(t, gotErr)
+	assert.Equal(t, &key, gotKey)
+}
+
+func TestDecodeCursor(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name        string
+		cursor      string
+		expectedRes *data.ItemPositionKey
+		wantErr     bool
+	}{
+		{
+			name:        "Empty cursor returns no key",
+			cursor:      "",
+			expectedRes: nil,
+			wantErr:     false,
+		},
+		{
+			name:    "Cursor with unknown fields is rejected",
+			cursor:  encode(`{"Id": "1c2fa0a1", "ListId": "474c2Fff7", "Name": "Apples"}`),
+			wantErr: true,
+		},
+		{
+			name:    "Cursor without an item ID is rejected",
+			cursor:  encode(`{"ListId": "474c2Fff7"}`),
+			wantErr: true,
+		},
+		{
+			name:    "Cursor for a different list is rejected",
+			cursor:  encode(`{"Id": "1c2fa0a1", "ListId": "someone-elses"}`),
+			wantErr: true,
+		},
+		{
+			name:        "Valid cursor returns the key",
+			cursor:      encode(`{"Id": "1c2fa0a1", "ListId": "474c2Fff7", "Position": 2048}`),
+			expectedRes: &data.ItemPositionKey{ItemKey: data.ItemKey{ID: "1c2fa0a1", ListID: "474c2Fff7"}, Position: 2048},
+			wantErr:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRes, gotErr := decodeCursor(tt.cursor, "474c2Fff7")
+
+			assert.Equal(t, tt.expectedRes, gotRes)
+			if tt.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
@@ -1,61 +1,108 @@
 package getitems
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"net/http"
-	"regexp"
-	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/mount-joy/thelist-lambda/data"
 	"github.com/mount-joy/thelist-lambda/db"
 	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/pagination"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/logging"
 )
 
 type getItems struct {
 	db db.DB
 }
 
+type itemsPage struct {
+	Items      []data.Item `json:"Items"`
+	NextCursor string      `json:"NextCursor,omitempty"`
+}
+
 // New returns an instance of getItems satisfying the RouteHandler interface
 func New() iface.RouteHandler {
 	return &getItems{
-		db: db.DynamoDB(),
+		db: db.Database(),
 	}
 }
 
-// Match returns true if this RouteHandler should handle this request
-func (g *getItems) Match(request events.APIGatewayV2HTTPRequest) bool {
-	// GET /lists/<list_id>/items
-	var re = regexp.MustCompile(`^/lists/([\w-]+)/items/?$`)
-	return request.RequestContext.HTTP.Method == "GET" && re.MatchString(request.RequestContext.HTTP.Path)
-}
-
 // Handle handles this request and returns the response and status code
-func (g *getItems) Handle(request events.APIGatewayV2HTTPRequest) (interface{}, int) {
-	items, err := g.getItems(request.RequestContext.HTTP.Path)
+// When neither a limit nor a cursor is passed every item on the list is returned
+// With groupBy=category every item on the list is returned grouped by category
+func (g *getItems) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	if groupBy, ok := request.QueryStringParameters["groupBy"]; ok {
+		return g.handleGrouped(ctx, params.ListID, groupBy, request.QueryStringParameters)
+	}
+
+	limit, err := pagination.ParseLimit(request.QueryStringParameters["limit"], 0)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return problem.Respond(http.StatusBadRequest, "Invalid query parameter", problem.Field{Name: "limit", Message: err.Error()})
+	}
+
+	startKey, err := decodeCursor(request.QueryStringParameters["cursor"], params.ListID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return problem.Respond(http.StatusBadRequest, "Invalid query parameter", problem.Field{Name: "cursor", Message: err.Error()})
+	}
 
+	page, err := g.getItems(ctx, params.ListID, limit, startKey)
 	if err != nil {
-		log.Printf("Error: %s", err.Error())
-		return nil, http.StatusInternalServerError
+		return problem.LogFromError(ctx, err)
 	}
 
-	return items, http.StatusOK
+	return page, http.StatusOK
 }
 
-func (g *getItems) getItems(path string) (*[]data.Item, error) {
-	listID, err := getListID(path)
+func (g *getItems) handleGrouped(ctx context.Context, listID string, groupBy string, query map[string]string) (interface{}, int) {
+	if groupBy != groupByCategory {
+		message := fmt.Sprintf("Unable to group items by %q", groupBy)
+		logging.FromContext(ctx).Warn("Request failed", "error", message)
+		return problem.Respond(http.StatusBadRequest, "Invalid query parameter", problem.Field{Name: "groupBy", Message: message})
+	}
+
+	// Groups are built from the whole list, a page would split them in unpredictable places
+	if query["limit"] != "" || query["cursor"] != "" {
+		logging.FromContext(ctx).Warn("Request failed", "error", "groupBy can't be used with limit or cursor")
+		return problem.Respond(http.StatusBadRequest, "groupBy can't be used with limit or cursor")
+	}
+
+	items, err := g.db.GetItemsOnList(ctx, listID)
 	if err != nil {
-		return nil, err
+		return problem.LogFromError(ctx, err)
 	}
 
-	return g.db.GetItemsOnList(listID)
+	return groupItemsByCategory(*items), http.StatusOK
 }
 
-func getListID(path string) (string, error) {
-	parts := strings.SplitN(path, "/", 4)
-	if len(parts) < 4 {
-		return "", fmt.Errorf("Unable to match path: %s", path)
+func (g *getItems) getItems(ctx context.Context, listID string, limit int64, startKey *data.ItemPositionKey) (*itemsPage, error) {
+	if limit == 0 && startKey == nil {
+		items, err := g.db.GetItemsOnList(ctx, listID)
+		if err != nil {
+			return nil, err
+		}
+		return &itemsPage{Items: *items}, nil
+	}
+
+	if limit == 0 {
+		limit = pagination.DefaultPageSize
+	}
+
+	items, nextKey, err := g.db.GetItemsOnListPage(ctx, listID, limit, startKey)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &itemsPage{Items: *items}
+	if nextKey != nil {
+		page.NextCursor, err = pagination.EncodeCursor(nextKey)
+		if err != nil {
+			return nil, err
+		}
 	}
-	return parts[2], nil
+	return page, nil
 }
@@ -1,92 +1,149 @@
 package getitems
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/pagination"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
 	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestGetItemsMatch(t *testing.T) {
+func TestGetItemsHandle(t *testing.T) {
 	tests := []struct {
-		name        string
-		path        string
-		expectedRes bool
-		method      string
+		name               string
+		path               string
+		listID             string
+		output             *[]data.Item
+		outputErr          error
+		expectedRes        interface{}
+		expectedStatusCode int
+		shouldCallDB       bool
 	}{
 		{
-			name:        "Returns true for a matching path",
-			path:        "/lists/b6cf642d-7a72-4969-bcc9-73bb82c4b3f6/items/",
-			expectedRes: true,
-			method:      "GET",
-		},
-		{
-			name:        "Returns false for a PATCH request",
-			path:        "/lists/b6cf642d-7a72-4969-bcc9-73bb82c4b3f6/items/",
-			expectedRes: false,
-			method:      "PATCH",
-		},
-		{
-			name:        "Returns false for a POST request",
-			path:        "/lists/b6cf642d-7a72-4969-bcc9-73bb82c4b3f6/items/",
-			expectedRes: false,
-			method:      "POST",
-		},
-		{
-			name:        "Returns false for a DELETE request",
-			path:        "/lists/b6cf642d-7a72-4969-bcc9-73bb82c4b3f6/items/",
-			expectedRes: false,
-			method:      "DELETE",
+			name:               "Returns 'OK' and results when the path matches",
+			path:               "/lists/test-list-id/items",
+			listID:             "test-list-id",
+			output:             &[]data.Item{data.Item{Name: "ABC", ItemKey: data.ItemKey{ID: "888"}}},
+			outputErr:          nil,
+			expectedRes:        &itemsPage{Items: []data.Item{data.Item{Name: "ABC", ItemKey: data.ItemKey{ID: "888"}}}},
+			expectedStatusCode: 200,
+			shouldCallDB:       true,
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &testhelpers.MockDB{}
+			dbMocked.Test(t)
+			if tt.shouldCallDB {
+				defer dbMocked.AssertExpectations(t)
+			}
+
+			dbMocked.
+				On("GetItemsOnList", tt.listID).
+				Return(tt.output, tt.outputErr)
+
+			d := getItems{db: dbMocked}
+
+			input := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, "GET", "")
+			gotRes, statusCode := d.Handle(context.Background(), input, iface.PathParams{ListID: "test-list-id"})
+
+			assert.Equal(t, tt.expectedStatusCode, statusCode)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
+
+type mockGetItemsOnListPage struct {
+	limit    int64
+	startKey *data.ItemPositionKey
+	res      *[]data.Item
+	nextKey  *data.ItemPositionKey
+	err      error
+}
+
+func TestGetItemsHandlePagination(t *testing.T) {
+	listID := "test-list-id"
+	path := "/lists/test-list-id/items"
+	cursor, _ := pagination.EncodeCursor(data.ItemPositionKey{ItemKey: data.ItemKey{ID: "888", ListID: listID}, Position: 1024})
+	otherListCursor, _ := pagination.EncodeCursor(data.ItemPositionKey{ItemKey: data.ItemKey{ID: "888", ListID: "other-list-id"}, Position: 1024})
+
+	tests := []struct {
+		name               string
+		query              map[string]string
+		mockOutput         *mockGetItemsOnListPage
+		expectedRes        interface{}
+		expectedStatusCode int
+	}{
 		{
-			name:        "Returns true for a uppercase ID",
-			path:        "/lists/4BBA7AB4-1D3A-4694-990B-6F78DEFC84C1/items/",
-			expectedRes: true,
-			method:      "GET",
+			name:  "Returns the first page and a cursor when a limit is passed",
+			query: map[string]string{"limit": "1"},
+			mockOutput: &mockGetItemsOnListPage{
+				limit:   1,
+				res:     &[]data.Item{{Name: "ABC", ItemKey: data.ItemKey{ID: "888", ListID: listID}}},
+				nextKey: &data.ItemPositionKey{ItemKey: data.ItemKey{ID: "888", ListID: listID}, Position: 1024},
+			},
+			expectedRes: &itemsPage{
+				Items:      []data.Item{{Name: "ABC", ItemKey: data.ItemKey{ID: "888", ListID: listID}}},
+				NextCursor: cursor,
+			},
+			expectedStatusCode: 200,
 		},
 		{
-			name:        "Returns true without trailing slash",
-			path:        "/lists/b6cf642d-7a72-4969-bcc9-73bb82c4b3f6/items",
-			expectedRes: true,
-			method:      "GET",
+			name:  "Continues from the cursor using the default page size",
+			query: map[string]string{"cursor": cursor},
+			mockOutput: &mockGetItemsOnListPage{
+				limit:    pagination.DefaultPageSize,
+				startKey: &data.ItemPositionKey{ItemKey: data.ItemKey{ID: "888", ListID: listID}, Position: 1024},
+				res:      &[]data.Item{{Name: "DEF", ItemKey: data.ItemKey{ID: "999", ListID: listID}}},
+			},
+			expectedRes:        &itemsPage{Items: []data.Item{{Name: "DEF", ItemKey: data.ItemKey{ID: "999", ListID: listID}}}},
+			expectedStatusCode: 200,
 		},
 		{
-			name:        "Returns false for list path",
-			path:        "/lists/b6cf642d-7a72-4969-bcc9-73bb82c4b3f6/",
-			expectedRes: false,
-			method:      "GET",
+			name:  "Returns 'Internal Server Error' when the db returns an error",
+			query: map[string]string{"limit": "10"},
+			mockOutput: &mockGetItemsOnListPage{
+				limit: 10,
+				err:   errors.New("It went wrong"),
+			},
+			expectedRes:        problem.ForStatus(500, ""),
+			expectedStatusCode: 500,
 		},
 		{
-			name:        "Returns false for list path without trailing slash",
-			path:        "/lists/b6cf642d-7a72-4969-bcc9-73bb82c4b3f6",
-			expectedRes: false,
-			method:      "GET",
+			name:               "Returns 'Bad Request' when the limit is not a number",
+			query:              map[string]string{"limit": "ten"},
+			expectedRes:        problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "limit", Message: "limit must be a number between 1 and 100, got \"ten\""}),
+			expectedStatusCode: 400,
 		},
 		{
-			name:        "Returns false for lists path",
-			path:        "/lists/",
-			expectedRes: false,
-			method:      "GET",
+			name:               "Returns 'Bad Request' when the limit is too large",
+			query:              map[string]string{"limit": "101"},
+			expectedRes:        problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "limit", Message: "limit must be a number between 1 and 100, got \"101\""}),
+			expectedStatusCode: 400,
 		},
 		{
-			name:        "Returns false for lists path without trailing slash",
-			path:        "/lists",
-			expectedRes: false,
-			method:      "GET",
+			name:               "Returns 'Bad Request' when the limit is zero",
+			query:              map[string]string{"limit": "0"},
+			expectedRes:        problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "limit", Message: "limit must be a number between 1 and 100, got \"0\""}),
+			expectedStatusCode: 400,
 		},
 		{
-			name:        "Returns false for item path",
-			path:        "/lists/b6cf642d-7a72-4969-bcc9-73bb82c4b3f6/items/14b58681-b5c3-4e5a-a928-12e9dc63cdb3",
-			expectedRes: false,
-			method:      "GET",
+			name:               "Returns 'Bad Request' when the cursor is malformed",
+			query:              map[string]string{"cursor": "not a cursor"},
+			expectedRes:        problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "cursor", Message: "Malformed cursor: illegal base64 data at input byte 3"}),
+			expectedStatusCode: 400,
 		},
 		{
-			name:        "Returns false when path is empty",
-			path:        "",
-			expectedRes: false,
-			method:      "GET",
+			name:               "Returns 'Bad Request' when the cursor is for another list",
+			query:              map[string]string{"cursor": otherListCursor},
+			expectedRes:        problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "cursor", Message: "Cursor \"eyJJZCI6Ijg4OCIsIkxpc3RJZCI6Im90aGVyLWxpc3QtaWQiLCJQb3NpdGlvbiI6MTAyNH0\" does not belong to list \"test-list-id\""}),
+			expectedStatusCode: 400,
 		},
 	}
 
@@ -96,65 +153,79 @@ func TestGetItemsMatch(t *testing.T) {
 			dbMocked.Test(t)
 			defer dbMocked.AssertExpectations(t)
 
-			input := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, tt.method, "")
+			if tt.mockOutput != nil {
+				dbMocked.
+					On("GetItemsOnListPage", listID, tt.mockOutput.limit, tt.mockOutput.startKey).
+					Return(tt.mockOutput.res, tt.mockOutput.nextKey, tt.mockOutput.err).
+					Once()
+			}
+
 			d := getItems{db: dbMocked}
-			gotRes := d.Match(input)
 
+			input := testhelpers.CreateAPIGatewayV2HTTPRequest(path, "GET", "")
+			input.QueryStringParameters = tt.query
+			gotRes, statusCode := d.Handle(context.Background(), input, iface.PathParams{ListID: "test-list-id"})
+
+			assert.Equal(t, tt.expectedStatusCode, statusCode)
 			assert.Equal(t, tt.expectedRes, gotRes)
 		})
 	}
 }
 
-func TestGetItemsHandle(t *testing.T) {
+type mockGetItemsOnList struct {
+	res *[]data.Item
+	err error
+}
+
+func TestGetItemsHandleGroupBy(t *testing.T) {
+	listID := "test-list-id"
+	path := "/lists/test-list-id/items"
+	items := &[]data.Item{
+		{Name: "Milk", Category: "dairy"},
+		{Name: "Apples", Category: "produce"},
+	}
+
 	tests := []struct {
 		name               string
-		path               string
-		listID             string
-		output             *[]data.Item
-		outputErr          error
+		query              map[string]string
+		mockOutput         *mockGetItemsOnList
 		expectedRes        interface{}
 		expectedStatusCode int
-		shouldCallDB       bool
 	}{
 		{
-			name:               "Returns 'Internal Server Error' when the path is empty",
-			path:               "",
-			listID:             "test-list-id",
-			output:             nil,
-			outputErr:          nil,
-			expectedRes:        nil,
-			expectedStatusCode: 500,
-			shouldCallDB:       false,
+			name:       "Returns the items grouped by category",
+			query:      map[string]string{"groupBy": "category"},
+			mockOutput: &mockGetItemsOnList{res: items},
+			expectedRes: &groupedItems{Groups: []categoryGroup{
+				{Category: "produce", Items: []data.Item{{Name: "Apples", Category: "produce"}}},
+				{Category: "dairy", Items: []data.Item{{Name: "Milk", Category: "dairy"}}},
+			}},
+			expectedStatusCode: 200,
 		},
 		{
-			name:               "Returns 'Internal Server Error' when the path is not in the correct format",
-			path:               "/lists/test-list-id",
-			listID:             "test-list-id",
-			output:             nil,
-			outputErr:          nil,
-			expectedRes:        nil,
+			name:               "Returns 'Internal Server Error' when the db returns an error",
+			query:              map[string]string{"groupBy": "category"},
+			mockOutput:         &mockGetItemsOnList{err: errors.New("It went wrong")},
+			expectedRes:        problem.ForStatus(500, ""),
 			expectedStatusCode: 500,
-			shouldCallDB:       false,
 		},
 		{
-			name:               "Returns 'OK' and results when the path matches",
-			path:               "/lists/test-list-id/items",
-			listID:             "test-list-id",
-			output:             &[]data.Item{data.Item{Name: "ABC", ItemKey: data.ItemKey{ID: "888"}}},
-			outputErr:          nil,
-			expectedRes:        &[]data.Item{data.Item{Name: "ABC", ItemKey: data.ItemKey{ID: "888"}}},
-			expectedStatusCode: 200,
-			shouldCallDB:       true,
+			name:               "Returns 'Bad Request' when grouping by something unknown",
+			query:              map[string]string{"groupBy": "colour"},
+			expectedRes:        problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "groupBy", Message: "Unable to group items by \"colour\""}),
+			expectedStatusCode: 400,
 		},
 		{
-			name:               "Returns 'Internal Server Error' when the path is not in the correct format",
-			path:               "/lists/test-list-id/items",
-			listID:             "test-list-id",
-			output:             nil,
-			outputErr:          errors.New("It went wrong"),
-			expectedRes:        nil,
-			expectedStatusCode: 500,
-			shouldCallDB:       true,
+			name:               "Returns 'Bad Request' when grouping with a limit",
+			query:              map[string]string{"groupBy": "category", "limit": "10"},
+			expectedRes:        problem.ForStatus(400, "groupBy can't be used with limit or cursor"),
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Returns 'Bad Request' when grouping with a cursor",
+			query:              map[string]string{"groupBy": "category", "cursor": "abc"},
+			expectedRes:        problem.ForStatus(400, "groupBy can't be used with limit or cursor"),
+			expectedStatusCode: 400,
 		},
 	}
 
@@ -162,18 +233,20 @@ func TestGetItemsHandle(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			dbMocked := &testhelpers.MockDB{}
 			dbMocked.Test(t)
-			if tt.shouldCallDB {
-				defer dbMocked.AssertExpectations(t)
-			}
+			defer dbMocked.AssertExpectations(t)
 
-			dbMocked.
-				On("GetItemsOnList", tt.listID).
-				Return(tt.output, tt.outputErr)
+			if tt.mockOutput != nil {
+				dbMocked.
+					On("GetItemsOnList", listID).
+					Return(tt.mockOutput.res, tt.mockOutput.err).
+					Once()
+			}
 
 			d := getItems{db: dbMocked}
 
-			input := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, "GET", "")
-			gotRes, statusCode := d.Handle(input)
+			input := testhelpers.CreateAPIGatewayV2HTTPRequest(path, "GET", "")
+			input.QueryStringParameters = tt.query
+			gotRes, statusCode := d.Handle(context.Background(), input, iface.PathParams{ListID: "test-list-id"})
 
 			assert.Equal(t, tt.expectedStatusCode, statusCode)
 			assert.Equal(t, tt.expectedRes, gotRes)
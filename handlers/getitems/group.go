@@ -0,0 +1,50 @@
+package getitems
+
+import "github.com/mount-joy/thelist-lambda/data"
+
+// groupByCategory is the value of groupBy which groups items by their category
+const groupByCategory = "category"
+
+type categoryGroup struct {
+	Category string      `json:"Category"`
+	Items    []data.Item `json:"Items"`
+}
+
+type groupedItems struct {
+	Groups []categoryGroup `json:"Groups"`
+}
+
+// groupItemsByCategory splits items into one group per category, items keep their order within a group
+// Empty groups are left out and items without a category are put in a last group with an empty Category
+func groupItemsByCategory(items []data.Item) *groupedItems {
+	byCategory := map[string][]data.Item{}
+	for _, item := range items {
+		byCategory[item.Category] = append(byCategory[item.Category], item)
+	}
+
+	groups := []categoryGroup{}
+	for _, category := range categoryOrder(items) {
+		if len(byCategory[category]) > 0 {
+			groups = append(groups, categoryGroup{Category: category, Items: byCategory[category]})
+		}
+	}
+	return &groupedItems{Groups: groups}
+}
+
+// categoryOrder returns data.Categories followed by any other categories the items are in, then no category
+func categoryOrder(items []data.Item) []string {
+	order := append([]string{}, data.Categories...)
+	seen := map[string]bool{"": true}
+	for _, category := range data.Categories {
+		seen[category] = true
+	}
+
+	// Items may still be in a category which has since been removed from data.Categories
+	for _, item := range items {
+		if !seen[item.Category] {
+			order = append(order, item.Category)
+			seen[item.Category] = true
+		}
+	}
+	return append(order, "")
+}
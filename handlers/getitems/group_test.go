@@ -0,0 +1,57 @@
+package getitems
+
+import (
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupItemsByCategory(t *testing.T) {
+	tests := []struct {
+		name        string
+		items       []data.Item
+		expectedRes *groupedItems
+	}{
+		{
+			name:        "No items gives no groups",
+			items:       []data.Item{},
+			expectedRes: &groupedItems{Groups: []categoryGroup{}},
+		},
+		{
+			name: "Groups follow the category order and keep the item order",
+			items: []data.Item{
+				{Name: "Milk", Category: "dairy"},
+				{Name: "Apples", Category: "produce"},
+				{Name: "Cheese", Category: "dairy"},
+				{Name: "Bread", Category: "bakery"},
+			},
+			expectedRes: &groupedItems{Groups: []categoryGroup{
+				{Category: "produce", Items: []data.Item{{Name: "Apples", Category: "produce"}}},
+				{Category: "bakery", Items: []data.Item{{Name: "Bread", Category: "bakery"}}},
+				{Category: "dairy", Items: []data.Item{{Name: "Milk", Category: "dairy"}, {Name: "Cheese", Category: "dairy"}}},
+			}},
+		},
+		{
+			name: "Items without a category come last, after categories which are no longer known",
+			items: []data.Item{
+				{Name: "Batteries"},
+				{Name: "Socks", Category: "clothes"},
+				{Name: "Apples", Category: "produce"},
+			},
+			expectedRes: &groupedItems{Groups: []categoryGroup{
+				{Category: "produce", Items: []data.Item{{Name: "Apples", Category: "produce"}}},
+				{Category: "clothes", Items: []data.Item{{Name: "Socks", Category: "clothes"}}},
+				{Category: "", Items: []data.Item{{Name: "Batteries"}}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRes := groupItemsByCategory(tt.items)
+
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
@@ -1,16 +1,14 @@
 package getlist
 
 import (
-	"fmt"
-	"log"
+	"context"
 	"net/http"
-	"regexp"
-	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/mount-joy/thelist-lambda/data"
 	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/etag"
 	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
 )
 
 type getList struct {
@@ -20,42 +18,16 @@ type getList struct {
 // New returns an instance of deleteItem satisfying the RouteHandler interface
 func New() iface.RouteHandler {
 	return &getList{
-		db: db.DynamoDB(),
+		db: db.Database(),
 	}
 }
 
-// Match returns true if this RouteHandler should handle this request
-func (g *getList) Match(request events.APIGatewayV2HTTPRequest) bool {
-	// GET /lists/<list_id>
-	var re = regexp.MustCompile(`^/lists/([\w-]+)/?$`)
-	return request.RequestContext.HTTP.Method == "GET" && re.MatchString(request.RequestContext.HTTP.Path)
-}
-
 // Handle handles this request and returns the response and status code
-func (g *getList) Handle(request events.APIGatewayV2HTTPRequest) (interface{}, int) {
-	item, err := g.getList(request.RequestContext.HTTP.Path)
-
+func (g *getList) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	item, err := g.db.GetList(ctx, params.ListID)
 	if err != nil {
-		log.Printf("Error: %s", err.Error())
-		return nil, http.StatusInternalServerError
+		return problem.LogFromError(ctx, err)
 	}
 
-	return item, http.StatusOK
-}
-
-func (g *getList) getList(path string) (*data.List, error) {
-	listID, err := getID(path)
-	if err != nil {
-		return nil, err
-	}
-
-	return g.db.GetList(listID)
-}
-
-func getID(path string) (string, error) {
-	parts := strings.SplitN(path, "/", 4)
-	if len(parts) < 3 {
-		return "", fmt.Errorf("Unable to match path: %s", path)
-	}
-	return parts[2], nil
+	return etag.Response(item, item.Version), http.StatusOK
 }
@@ -0,0 +1,23 @@
+package getlists
+
+import (
+	"fmt"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/handlers/pagination"
+)
+
+// decodeCursor returns the key in cursor, rejecting anything that isn't a key for the caller's lists
+func decodeCursor(cursor string, ownerID string) (*data.OwnerListKey, error) {
+	var key data.OwnerListKey
+	ok, err := pagination.DecodeCursor(cursor, &key)
+	if !ok || err != nil {
+		return nil, err
+	}
+
+	if key.ID == "" || key.OwnerID != ownerID {
+		return nil, fmt.Errorf("Cursor %q does not belong to owner %q", cursor, ownerID)
+	}
+
+	return &key, nil
+}
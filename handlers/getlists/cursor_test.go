@@ -0,0 +1,74 @@
+package getlists
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/handlers/pagination"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	key := data.OwnerListKey{ListKey: data.ListKey{ID: "474c2Fff7"}, OwnerID: "user-1", UpdatedTimestamp: "2020-01-23T09:59:14.9396531Z"}
+
+	cursor, err := pagination.EncodeCursor(key)
+	assert.NoError(t, err)
+
+	gotKey, gotErr := decodeCursor(cursor, "user-1")
+
+	assert.NoError(t, gotErr)
+	assert.Equal(t, &key, gotKey)
+}
+
+func TestDecodeCursor(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name        string
+		cursor      string
+		expectedRes *data.OwnerListKey
+		wantErr     bool
+	}{
+		{
+			name:        "Empty cursor returns no key",
+			cursor:      "",
+			expectedRes: nil,
+			wantErr:     false,
+		},
+		{
+			name:    "Cursor with unknown fields is rejected",
+			cursor:  encode(`{"Id": "474c2Fff7", "OwnerId": "user-1", "Name": "Groceries"}`),
+			wantErr: true,
+		},
+		{
+			name:    "Cursor without a list ID is rejected",
+			cursor:  encode(`{"OwnerId": "user-1"}`),
+			wantErr: true,
+		},
+		{
+			name:    "Cursor for a different owner is rejected",
+			cursor:  encode(`{"Id": "474c2Fff7", "OwnerId": "user-2"}`),
+			wantErr: true,
+		},
+		{
+			name:        "Valid cursor returns the key",
+			cursor:      encode(`{"Id": "474c2Fff7", "OwnerId": "user-1", "Updated": "2020-01-23T09:59:14.9396531Z"}`),
+			expectedRes: &data.OwnerListKey{ListKey: data.ListKey{ID: "474c2Fff7"}, OwnerID: "user-1", UpdatedTimestamp: "2020-01-23T09:59:14.9396531Z"},
+			wantErr:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRes, gotErr := decodeCursor(tt.cursor, "user-1")
+
+			assert.Equal(t, tt.expectedRes, gotRes)
+			if tt.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package getlists
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/access"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/pagination"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/logging"
+)
+
+// listsPageSize is used when no limit is passed, lists are shown a screenful at a time
+const listsPageSize int64 = 20
+
+type getLists struct {
+	db db.DB
+}
+
+type listsPage struct {
+	Lists      []data.List `json:"Lists"`
+	NextCursor string      `json:"NextCursor,omitempty"`
+}
+
+// New returns an instance of getLists satisfying the RouteHandler interface
+func New() iface.RouteHandler {
+	return &getLists{
+		db: db.Database(),
+	}
+}
+
+// Handle returns a page of the lists owned by the caller, most recently updated first
+func (g *getLists) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	ownerID, err := access.CallerID(request)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return problem.Respond(http.StatusUnauthorized, err.Error())
+	}
+
+	limit, err := pagination.ParseLimit(request.QueryStringParameters["limit"], listsPageSize)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return problem.Respond(http.StatusBadRequest, "Invalid query parameter", problem.Field{Name: "limit", Message: err.Error()})
+	}
+
+	startKey, err := decodeCursor(request.QueryStringParameters["cursor"], ownerID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return problem.Respond(http.StatusBadRequest, "Invalid query parameter", problem.Field{Name: "cursor", Message: err.Error()})
+	}
+
+	page, err := g.getLists(ctx, ownerID, limit, startKey)
+	if err != nil {
+		return problem.LogFromError(ctx, err)
+	}
+
+	return page, http.StatusOK
+}
+
+func (g *getLists) getLists(ctx context.Context, ownerID string, limit int64, startKey *data.OwnerListKey) (*listsPage, error) {
+	lists, nextKey, err := g.db.GetListsForOwner(ctx, ownerID, limit, startKey)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &listsPage{Lists: *lists}
+	if nextKey != nil {
+		page.NextCursor, err = pagination.EncodeCursor(nextKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
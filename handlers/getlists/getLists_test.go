@@ -0,0 +1,135 @@
+package getlists
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/pagination"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockGetListsForOwner struct {
+	limit    int64
+	startKey *data.OwnerListKey
+	res      *[]data.List
+	nextKey  *data.OwnerListKey
+	err      error
+}
+
+func TestGetListsHandle(t *testing.T) {
+	ownerID := "user-1"
+	key := data.OwnerListKey{ListKey: data.ListKey{ID: "888"}, OwnerID: ownerID, UpdatedTimestamp: "2020-01-23T09:59:14.9396531Z"}
+	cursor, _ := pagination.EncodeCursor(key)
+	otherOwnerCursor, _ := pagination.EncodeCursor(data.OwnerListKey{ListKey: data.ListKey{ID: "888"}, OwnerID: "user-2"})
+
+	tests := []struct {
+		name               string
+		query              map[string]string
+		noCaller           bool
+		mockOutput         *mockGetListsForOwner
+		expectedRes        interface{}
+		expectedStatusCode int
+	}{
+		{
+			name:  "Returns the first page using the default page size",
+			query: map[string]string{},
+			mockOutput: &mockGetListsForOwner{
+				limit: listsPageSize,
+				res:   &[]data.List{{Name: "ABC", ListKey: data.ListKey{ID: "888"}, OwnerID: ownerID}},
+			},
+			expectedRes:        &listsPage{Lists: []data.List{{Name: "ABC", ListKey: data.ListKey{ID: "888"}, OwnerID: ownerID}}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:  "Returns a cursor when there are more lists",
+			query: map[string]string{"limit": "1"},
+			mockOutput: &mockGetListsForOwner{
+				limit:   1,
+				res:     &[]data.List{{Name: "ABC", ListKey: data.ListKey{ID: "888"}, OwnerID: ownerID}},
+				nextKey: &key,
+			},
+			expectedRes: &listsPage{
+				Lists:      []data.List{{Name: "ABC", ListKey: data.ListKey{ID: "888"}, OwnerID: ownerID}},
+				NextCursor: cursor,
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:  "Continues from the cursor",
+			query: map[string]string{"cursor": cursor},
+			mockOutput: &mockGetListsForOwner{
+				limit:    listsPageSize,
+				startKey: &key,
+				res:      &[]data.List{},
+			},
+			expectedRes:        &listsPage{Lists: []data.List{}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:  "Returns 'Internal Server Error' when the db returns an error",
+			query: map[string]string{},
+			mockOutput: &mockGetListsForOwner{
+				limit: listsPageSize,
+				err:   errors.New("It went wrong"),
+			},
+			expectedRes:        problem.ForStatus(500, ""),
+			expectedStatusCode: 500,
+		},
+		{
+			name:               "Returns 'Unauthorized' when there is no caller",
+			noCaller:           true,
+			expectedRes:        problem.ForStatus(401, "Request has no caller identity"),
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "Returns 'Bad Request' when the limit is too large",
+			query:              map[string]string{"limit": "101"},
+			expectedRes:        problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "limit", Message: "limit must be a number between 1 and 100, got \"101\""}),
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Returns 'Bad Request' when the limit is not a number",
+			query:              map[string]string{"limit": "ten"},
+			expectedRes:        problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "limit", Message: "limit must be a number between 1 and 100, got \"ten\""}),
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Returns 'Bad Request' when the cursor is for another owner",
+			query:              map[string]string{"cursor": otherOwnerCursor},
+			expectedRes:        problem.ForStatus(400, "Invalid query parameter", problem.Field{Name: "cursor", Message: "Cursor \"eyJJZCI6Ijg4OCIsIk93bmVySWQiOiJ1c2VyLTIiLCJVcGRhdGVkIjoiIn0\" does not belong to owner \"user-1\""}),
+			expectedStatusCode: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &testhelpers.MockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			if tt.mockOutput != nil {
+				dbMocked.
+					On("GetListsForOwner", ownerID, tt.mockOutput.limit, tt.mockOutput.startKey).
+					Return(tt.mockOutput.res, tt.mockOutput.nextKey, tt.mockOutput.err).
+					Once()
+			}
+
+			g := getLists{db: dbMocked}
+
+			input := testhelpers.CreateAPIGatewayV2HTTPRequest("/lists", "GET", "")
+			input.QueryStringParameters = tt.query
+			if !tt.noCaller {
+				input = testhelpers.WithCaller(input, ownerID)
+			}
+			gotRes, statusCode := g.Handle(context.Background(), input, iface.PathParams{})
+
+			assert.Equal(t, tt.expectedStatusCode, statusCode)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package helloworld
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
@@ -15,11 +16,12 @@ func New() iface.RouteHandler {
 	return &helloWorld{}
 }
 
-func (h *helloWorld) Match(request events.APIGatewayV2HTTPRequest) bool {
-	return request.RequestContext.HTTP.Path == "/hello"
+// Public returns true as anyone can be greeted
+func (h *helloWorld) Public() bool {
+	return true
 }
 
-func (h *helloWorld) Handle(request events.APIGatewayV2HTTPRequest) (interface{}, int) {
+func (h *helloWorld) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
 	name := request.QueryStringParameters["name"]
 	return map[string]string{"message": fmt.Sprintf("Hello, %v", name)}, http.StatusOK
 }
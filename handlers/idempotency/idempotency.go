@@ -0,0 +1,152 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/access"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/logging"
+)
+
+// Header is the request header a client sends the same key in each time it retries a request
+const Header = "Idempotency-Key"
+
+// ReplayedHeader is set on a response which was stored when the request was first made
+const ReplayedHeader = "Idempotent-Replayed"
+
+// maxKeyLength is the longest key accepted, a UUID is far shorter
+const maxKeyLength = 255
+
+// Middleware makes the route idempotent for requests with an Idempotency-Key header
+func Middleware() iface.Middleware {
+	return middlewareWith(db.Database())
+}
+
+// middlewareWith stores responses in database
+// The first request with a key is handled and its response stored, a repeat of it gets the same response back
+// A key can't be reused for a different request, and only successful responses are stored so failed requests can be retried
+func middlewareWith(database db.DB) iface.Middleware {
+	return func(next iface.HandlerFunc) iface.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+			key := strings.TrimSpace(iface.GetHeader(request.Headers, Header))
+			if key == "" {
+				return next(ctx, request, params)
+			}
+			if len(key) > maxKeyLength {
+				message := fmt.Sprintf("%s can be at most %d characters", Header, maxKeyLength)
+				logging.FromContext(ctx).Warn("Request failed", "error", message)
+				return problem.Respond(http.StatusBadRequest, "Invalid header", problem.Field{Name: Header, Message: message})
+			}
+
+			key = scopeKey(request, key)
+			requestFingerprint := fingerprint(request.Body)
+			record, err := claim(ctx, database, key, requestFingerprint)
+			if errors.Is(err, db.ErrorIDExists) {
+				return replay(ctx, record, requestFingerprint)
+			}
+			if err != nil {
+				return problem.LogFromError(ctx, err)
+			}
+
+			result, statusCode := next(ctx, request, params)
+			if statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
+				release(ctx, database, *record)
+				return result, statusCode
+			}
+
+			response, err := storedResponse(result, statusCode)
+			if err == nil {
+				err = database.CompleteIdempotentRequest(ctx, *record, *response)
+			}
+			if err != nil {
+				// The request has been handled, so it still succeeds, but a retry would be handled again
+				logging.FromContext(ctx).Error("Unable to store the response to replay", "error", err)
+				release(ctx, database, *record)
+			}
+			return result, statusCode
+		}
+	}
+}
+
+// claim claims key for the request and returns the claim, or returns the record of the request holding it
+// A key held without a record was released or expired before it could be read, so claiming it is tried once more
+func claim(ctx context.Context, database db.DB, key string, requestFingerprint string) (*data.IdempotencyRecord, error) {
+	record, err := database.StartIdempotentRequest(ctx, key, requestFingerprint)
+	if errors.Is(err, db.ErrorIDExists) && record == nil {
+		return database.StartIdempotentRequest(ctx, key, requestFingerprint)
+	}
+	return record, err
+}
+
+// scopeKey makes a key only match repeats of the same route by the same caller, so nobody else's response can be replayed
+func scopeKey(request events.APIGatewayV2HTTPRequest, key string) string {
+	callerID, _ := access.CallerID(request)
+	return strings.Join([]string{callerID, request.RequestContext.HTTP.Method, request.RequestContext.HTTP.Path, key}, " ")
+}
+
+// fingerprint identifies the request body, a retry sends exactly the same one
+func fingerprint(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// replay responds to a repeated request with the response to the request which first used the key
+func replay(ctx context.Context, record *data.IdempotencyRecord, requestFingerprint string) (interface{}, int) {
+	if record != nil && record.Fingerprint != requestFingerprint {
+		message := fmt.Sprintf("%s has already been used for a different request", Header)
+		logging.FromContext(ctx).Warn("Request failed", "error", message)
+		return problem.Respond(http.StatusUnprocessableEntity, "Invalid header", problem.Field{Name: Header, Message: message})
+	}
+	if record == nil || record.Response == nil {
+		message := fmt.Sprintf("A request with this %s is still being handled", Header)
+		logging.FromContext(ctx).Warn("Request failed", "error", message)
+		return problem.RespondWithCode(http.StatusConflict, problem.CodeRequestInProgress, message)
+	}
+
+	headers := map[string]string{ReplayedHeader: "true"}
+	for name, value := range record.Response.Headers {
+		headers[name] = value
+	}
+	var body interface{}
+	if record.Response.Body != "" {
+		body = json.RawMessage(record.Response.Body)
+	}
+	return &iface.Response{Body: body, Headers: headers}, record.Response.StatusCode
+}
+
+// storedResponse serialises the route's result the way it is sent, so it can be sent again
+func storedResponse(result interface{}, statusCode int) (*data.IdempotentResponse, error) {
+	response := &data.IdempotentResponse{StatusCode: statusCode}
+	if r, ok := result.(*iface.Response); ok {
+		response.Headers = r.Headers
+		result = r.Body
+	}
+	if statusCode == http.StatusNoContent {
+		return response, nil
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	response.Body = string(body)
+	return response, nil
+}
+
+// release gives up the key held by claim so the request can be retried, if that fails the key is held until it expires
+func release(ctx context.Context, database db.DB, claim data.IdempotencyRecord) {
+	if err := database.ReleaseIdempotencyKey(ctx, claim); err != nil {
+		logging.FromContext(ctx).Error("Unable to release the idempotency key", "error", err)
+	}
+}
@@ -0,0 +1,192 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockHandler struct {
+	mock.Mock
+}
+
+func (m *mockHandler) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	args := m.Called(request, params)
+	return args.Get(0), args.Int(1)
+}
+
+type mockStart struct {
+	res *data.IdempotencyRecord
+	err error
+}
+
+type mockHandle struct {
+	res        interface{}
+	statusCode int
+}
+
+func TestMiddleware(t *testing.T) {
+	body := `{"Name":"Peaches"}`
+	bodyFingerprint := fingerprint(body)
+	key := "user-1 POST /lists/list-1/items abc"
+	claim := &data.IdempotencyRecord{Key: key, Fingerprint: bodyFingerprint, CreatedTimestamp: "2020-01-23T09:59:14.9396531Z", ExpiresAt: 1579773614}
+	item := &data.Item{ItemKey: data.ItemKey{ID: "1", ListID: "list-1"}, Name: "Peaches", Version: 1}
+	created := &iface.Response{Body: item, Headers: map[string]string{"ETag": `"1"`}}
+	stored := data.IdempotentResponse{StatusCode: 200, Headers: map[string]string{"ETag": `"1"`}, Body: `{"Id":"1","ListId":"list-1","Name":"Peaches","IsCompleted":false,"Position":0,"Version":1,"Created":"","Updated":""}`}
+
+	tests := []struct {
+		name               string
+		headers            map[string]string
+		mockStarts         []mockStart
+		mockHandle         *mockHandle
+		completeErr        error
+		expectComplete     bool
+		expectRelease      bool
+		expectedRes        interface{}
+		expectedStatusCode int
+	}{
+		{
+			name:               "Without a key the request is handled as usual",
+			mockHandle:         &mockHandle{res: created, statusCode: 200},
+			expectedRes:        created,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "The first request with a key is handled and its response stored",
+			headers:            map[string]string{"idempotency-key": "abc"},
+			mockStarts:         []mockStart{{res: claim}},
+			mockHandle:         &mockHandle{res: created, statusCode: 200},
+			expectComplete:     true,
+			expectedRes:        created,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "A repeated request gets the stored response",
+			headers:            map[string]string{"Idempotency-Key": "abc"},
+			mockStarts:         []mockStart{{res: &data.IdempotencyRecord{Key: key, Fingerprint: bodyFingerprint, Response: &stored}, err: db.ErrorIDExists}},
+			expectedRes:        &iface.Response{Body: json.RawMessage(stored.Body), Headers: map[string]string{"ETag": `"1"`, ReplayedHeader: "true"}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the key was used with a different body",
+			headers:            map[string]string{"Idempotency-Key": "abc"},
+			mockStarts:         []mockStart{{res: &data.IdempotencyRecord{Key: key, Fingerprint: fingerprint(`{"Name":"Pears"}`), Response: &stored}, err: db.ErrorIDExists}},
+			expectedRes:        problem.ForStatus(422, "Invalid header", problem.Field{Name: Header, Message: "Idempotency-Key has already been used for a different request"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Conflict' when the first request with the key is still being handled",
+			headers:            map[string]string{"Idempotency-Key": "abc"},
+			mockStarts:         []mockStart{{res: &data.IdempotencyRecord{Key: key, Fingerprint: bodyFingerprint}, err: db.ErrorIDExists}},
+			expectedRes:        problem.New(409, problem.CodeRequestInProgress, "A request with this Idempotency-Key is still being handled"),
+			expectedStatusCode: 409,
+		},
+		{
+			name:               "When the key is released before its record is read, it is claimed again",
+			headers:            map[string]string{"Idempotency-Key": "abc"},
+			mockStarts:         []mockStart{{err: db.ErrorIDExists}, {res: claim}},
+			mockHandle:         &mockHandle{res: created, statusCode: 200},
+			expectComplete:     true,
+			expectedRes:        created,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "When the key is held by another request by the time it's claimed again, it gets that request's response",
+			headers:            map[string]string{"Idempotency-Key": "abc"},
+			mockStarts:         []mockStart{{err: db.ErrorIDExists}, {res: &data.IdempotencyRecord{Key: key, Fingerprint: bodyFingerprint, Response: &stored}, err: db.ErrorIDExists}},
+			expectedRes:        &iface.Response{Body: json.RawMessage(stored.Body), Headers: map[string]string{"ETag": `"1"`, ReplayedHeader: "true"}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "A failed request releases the key so it can be retried",
+			headers:            map[string]string{"Idempotency-Key": "abc"},
+			mockStarts:         []mockStart{{res: claim}},
+			mockHandle:         &mockHandle{res: problem.ForStatus(500, ""), statusCode: 500},
+			expectRelease:      true,
+			expectedRes:        problem.ForStatus(500, ""),
+			expectedStatusCode: 500,
+		},
+		{
+			name:               "When the response can't be stored it is still returned and the key released",
+			headers:            map[string]string{"Idempotency-Key": "abc"},
+			mockStarts:         []mockStart{{res: claim}},
+			mockHandle:         &mockHandle{res: created, statusCode: 200},
+			expectComplete:     true,
+			completeErr:        errors.New("It went wrong"),
+			expectRelease:      true,
+			expectedRes:        created,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Internal Server Error' when the key can't be claimed",
+			headers:            map[string]string{"Idempotency-Key": "abc"},
+			mockStarts:         []mockStart{{err: errors.New("It went wrong")}},
+			expectedRes:        problem.ForStatus(500, ""),
+			expectedStatusCode: 500,
+		},
+		{
+			name:               "Returns 'Bad Request' when the key is too long",
+			headers:            map[string]string{"Idempotency-Key": strings.Repeat("a", 256)},
+			expectedRes:        problem.ForStatus(400, "Invalid header", problem.Field{Name: Header, Message: "Idempotency-Key can be at most 255 characters"}),
+			expectedStatusCode: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &testhelpers.MockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			for _, start := range tt.mockStarts {
+				dbMocked.
+					On("StartIdempotentRequest", key, bodyFingerprint).
+					Return(start.res, start.err).
+					Once()
+			}
+			if tt.expectComplete {
+				dbMocked.
+					On("CompleteIdempotentRequest", *claim, stored).
+					Return(tt.completeErr).
+					Once()
+			}
+			if tt.expectRelease {
+				dbMocked.
+					On("ReleaseIdempotencyKey", *claim).
+					Return(nil).
+					Once()
+			}
+
+			input := testhelpers.WithCaller(testhelpers.CreateAPIGatewayV2HTTPRequest("/lists/list-1/items", "POST", body), "user-1")
+			input.Headers = tt.headers
+			params := iface.PathParams{ListID: "list-1"}
+
+			next := &mockHandler{}
+			next.Test(t)
+			defer next.AssertExpectations(t)
+			if tt.mockHandle != nil {
+				next.
+					On("Handle", input, params).
+					Return(tt.mockHandle.res, tt.mockHandle.statusCode).
+					Once()
+			}
+
+			handle := middlewareWith(dbMocked)(next.Handle)
+			gotRes, statusCode := handle(context.Background(), input, params)
+
+			assert.Equal(t, tt.expectedStatusCode, statusCode)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
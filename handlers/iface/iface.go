@@ -1,14 +1,77 @@
 package iface
 
-import "github.com/aws/aws-lambda-go/events"
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
 
 // Router - interface for routing requests to the right handler
 type Router interface {
-	Route(events.APIGatewayV2HTTPRequest) (interface{}, int)
+	Route(context.Context, events.APIGatewayV2HTTPRequest) (interface{}, int)
+	// Match returns the path template of the route the request would be handled by and the params it would be given,
+	// the template is empty when no route matches
+	Match(events.APIGatewayV2HTTPRequest) (string, PathParams)
+}
+
+// PathParams - the values of the parameters in the path template a request was routed with
+type PathParams struct {
+	ListID string
+	ItemID string
 }
 
-// RouteHandler - interface for matching and handling a particular request
+// RouteHandler - interface for handling the requests routed to a path template
 type RouteHandler interface {
-	Match(events.APIGatewayV2HTTPRequest) bool
-	Handle(events.APIGatewayV2HTTPRequest) (interface{}, int)
+	Handle(context.Context, events.APIGatewayV2HTTPRequest, PathParams) (interface{}, int)
+}
+
+// PublicRouteHandler - implemented by a RouteHandler which callers don't need to authenticate with
+type PublicRouteHandler interface {
+	RouteHandler
+	Public() bool
+}
+
+// HandlerFunc - handles a request, returning the response body and status code like RouteHandler.Handle
+type HandlerFunc func(context.Context, events.APIGatewayV2HTTPRequest, PathParams) (interface{}, int)
+
+// Middleware - wraps a HandlerFunc with behaviour shared between routes
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Response - returned by a RouteHandler which needs to set response headers as well as the body
+type Response struct {
+	Body    interface{}
+	Headers map[string]string
+}
+
+// WithHeaders returns result as a Response with headers added
+// Headers already on result take precedence, as they were set closer to the route
+func WithHeaders(result interface{}, headers map[string]string) interface{} {
+	if len(headers) == 0 {
+		return result
+	}
+
+	merged := make(map[string]string, len(headers))
+	for key, value := range headers {
+		merged[key] = value
+	}
+
+	response, ok := result.(*Response)
+	if !ok {
+		return &Response{Body: result, Headers: merged}
+	}
+
+	for key, value := range response.Headers {
+		merged[key] = value
+	}
+	return &Response{Body: response.Body, Headers: merged}
+}
+
+// GetHeader looks up a header ignoring case, API Gateway lower cases header names but other callers may not
+func GetHeader(headers map[string]string, name string) string {
+	h := http.Header{}
+	for key, value := range headers {
+		h.Add(key, value)
+	}
+	return h.Get(name)
 }
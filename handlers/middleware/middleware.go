@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+)
+
+// Chain wraps handler in the middlewares, the first middleware is the outermost so it sees the request first
+func Chain(handler iface.HandlerFunc, middlewares ...iface.Middleware) iface.HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+type route struct {
+	iface.RouteHandler
+	handle iface.HandlerFunc
+}
+
+// Route returns a RouteHandler which handles requests with r once they have passed through the middlewares
+func Route(r iface.RouteHandler, middlewares ...iface.Middleware) iface.RouteHandler {
+	return &route{
+		RouteHandler: r,
+		handle:       Chain(r.Handle, middlewares...),
+	}
+}
+
+// Handle handles the request with the wrapped route once it has passed through the middlewares
+func (r *route) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	return r.handle(ctx, request, params)
+}
+
+// Public returns true if the wrapped route is public
+func (r *route) Public() bool {
+	return IsPublic(r.RouteHandler)
+}
+
+// IsPublic returns true if callers don't need to authenticate with the route
+func IsPublic(r iface.RouteHandler) bool {
+	public, ok := r.(iface.PublicRouteHandler)
+	return ok && public.Public()
+}
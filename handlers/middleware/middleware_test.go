@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRoute struct {
+	public bool
+}
+
+func (r *testRoute) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	return request.Body + params.ListID, 200
+}
+
+func (r *testRoute) Public() bool {
+	return r.public
+}
+
+// appendToBody is middleware which adds suffix to the request body on the way in
+func appendToBody(suffix string) iface.Middleware {
+	return func(next iface.HandlerFunc) iface.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+			request.Body += suffix
+			return next(ctx, request, params)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	handler := (&testRoute{}).Handle
+
+	gotRes, gotStatus := Chain(handler, appendToBody(" a"), appendToBody(" b"))(context.Background(), events.APIGatewayV2HTTPRequest{Body: "request"}, iface.PathParams{})
+
+	assert.Equal(t, "request a b", gotRes)
+	assert.Equal(t, 200, gotStatus)
+
+	gotRes, _ = Chain(handler)(context.Background(), events.APIGatewayV2HTTPRequest{Body: "request"}, iface.PathParams{})
+	assert.Equal(t, "request", gotRes)
+}
+
+func TestRoute(t *testing.T) {
+	request := events.APIGatewayV2HTTPRequest{Body: "request"}
+
+	r := Route(&testRoute{public: true}, appendToBody(" a "))
+
+	assert.True(t, IsPublic(r))
+	gotRes, gotStatus := r.Handle(context.Background(), request, iface.PathParams{ListID: "list-1"})
+	assert.Equal(t, "request a list-1", gotRes)
+	assert.Equal(t, 200, gotStatus)
+
+	assert.False(t, IsPublic(Route(&testRoute{public: false})))
+}
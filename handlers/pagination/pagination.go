@@ -0,0 +1,60 @@
+package pagination
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// DefaultPageSize is the number of results in a page when the client doesn't pass a limit
+const DefaultPageSize int64 = 50
+
+// MaxPageSize is the largest limit a client can ask for
+const MaxPageSize int64 = 100
+
+// ParseLimit returns the page size asked for in value, or defaultLimit when value is empty
+func ParseLimit(value string, defaultLimit int64) (int64, error) {
+	if value == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit < 1 || limit > MaxPageSize {
+		return 0, fmt.Errorf("limit must be a number between 1 and %d, got %q", MaxPageSize, value)
+	}
+	return limit, nil
+}
+
+// EncodeCursor turns the key to continue from into an opaque string clients can pass back to us
+func EncodeCursor(key interface{}) (string, error) {
+	res, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(res), nil
+}
+
+// DecodeCursor reverses EncodeCursor into key, which must be a pointer to the type of key which was encoded
+// It returns false, leaving key alone, when there is no cursor
+// The caller still has to check the key belongs to what's being paged through
+func DecodeCursor(cursor string, key interface{}) (bool, error) {
+	if cursor == "" {
+		return false, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return false, fmt.Errorf("Malformed cursor: %s", err.Error())
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(key)
+	if err != nil {
+		return false, fmt.Errorf("Malformed cursor: %s", err.Error())
+	}
+	return true, nil
+}
@@ -0,0 +1,124 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expectedRes int64
+		wantErr     bool
+	}{
+		{
+			name:        "No limit gives the default",
+			value:       "",
+			expectedRes: 20,
+		},
+		{
+			name:        "A limit in range is returned",
+			value:       "100",
+			expectedRes: 100,
+		},
+		{
+			name:    "A limit which isn't a number is rejected",
+			value:   "ten",
+			wantErr: true,
+		},
+		{
+			name:    "A limit below 1 is rejected",
+			value:   "0",
+			wantErr: true,
+		},
+		{
+			name:    "A limit above the maximum is rejected",
+			value:   "101",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRes, gotErr := ParseLimit(tt.value, 20)
+
+			assert.Equal(t, tt.expectedRes, gotRes)
+			if tt.wantErr {
+				assert.EqualError(t, gotErr, `limit must be a number between 1 and 100, got "`+tt.value+`"`)
+			} else {
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	key := data.ItemKey{ID: "1c2fa0a1", ListID: "474c2Fff7"}
+
+	cursor, err := EncodeCursor(key)
+	assert.NoError(t, err)
+
+	var gotKey data.ItemKey
+	ok, gotErr := DecodeCursor(cursor, &gotKey)
+
+	assert.True(t, ok)
+	assert.NoError(t, gotErr)
+	assert.Equal(t, key, gotKey)
+}
+
+func TestDecodeCursor(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name        string
+		cursor      string
+		expectedRes data.ItemKey
+		expectedOK  bool
+		wantErr     bool
+	}{
+		{
+			name:   "Empty cursor returns no key",
+			cursor: "",
+		},
+		{
+			name:    "Cursor which isn't base64 is rejected",
+			cursor:  "!!!",
+			wantErr: true,
+		},
+		{
+			name:    "Cursor which isn't json is rejected",
+			cursor:  encode("hello"),
+			wantErr: true,
+		},
+		{
+			name:    "Cursor with unknown fields is rejected",
+			cursor:  encode(`{"Id": "1c2fa0a1", "ListId": "474c2Fff7", "Name": "Apples"}`),
+			wantErr: true,
+		},
+		{
+			name:        "Valid cursor returns the key",
+			cursor:      encode(`{"Id": "1c2fa0a1", "ListId": "474c2Fff7"}`),
+			expectedRes: data.ItemKey{ID: "1c2fa0a1", ListID: "474c2Fff7"},
+			expectedOK:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRes data.ItemKey
+			gotOK, gotErr := DecodeCursor(tt.cursor, &gotRes)
+
+			assert.Equal(t, tt.expectedOK, gotOK)
+			if tt.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.NoError(t, gotErr)
+				assert.Equal(t, tt.expectedRes, gotRes)
+			}
+		})
+	}
+}
@@ -1,17 +1,20 @@
 package patchitem
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"log"
 	"net/http"
-	"regexp"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/data"
 	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/etag"
 	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/validation"
+	"github.com/mount-joy/thelist-lambda/logging"
 )
 
 type patchItem struct {
@@ -21,63 +24,75 @@ type patchItem struct {
 // New returns an instance of patchItem satisfying the RouteHandler interface
 func New() iface.RouteHandler {
 	return &patchItem{
-		db: db.DynamoDB(),
+		db: db.Database(),
 	}
 }
 
-// Match returns true if this RouteHandler should handle this request
-func (p *patchItem) Match(request events.APIGatewayV2HTTPRequest) bool {
-	// PATCH /lists/<list_id>/items/<item_id>
-	var re = regexp.MustCompile(`^/lists/([\w-]+)/items/([\w-]+)/?$`)
-	return request.RequestContext.HTTP.Method == "PATCH" && re.MatchString(request.RequestContext.HTTP.Path)
-}
-
 // Handle handles this request and returns the response and status code
-func (p *patchItem) Handle(request events.APIGatewayV2HTTPRequest) (interface{}, int) {
-	listID, itemID, err := getIDs(request.RequestContext.HTTP.Path)
+func (p *patchItem) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	update, err := getFields(request.Body)
 	if err != nil {
-		log.Printf("Error: %s", err.Error())
-		return nil, http.StatusBadRequest
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return validation.Respond(err)
 	}
 
-	newName, isCompleted, err := getFields(request.Body)
+	expectedVersion, err := etag.IfMatch(request)
 	if err != nil {
-		log.Printf("Error: %s", err.Error())
-		return nil, http.StatusBadRequest
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return problem.Respond(http.StatusPreconditionFailed, err.Error())
 	}
 
-	item, err := p.db.UpdateItem(listID, itemID, newName, isCompleted)
+	item, err := p.db.UpdateItem(ctx, params.ListID, params.ItemID, update, expectedVersion)
+	var fieldErrors data.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		v := validation.Validator{}
+		v.Fields(fieldErrors)
+		return validation.Respond(v.Err())
+	}
 	if err != nil {
-		if errors.Is(err, db.ErrorNotFound) {
-			return nil, http.StatusNotFound
-		}
-		if errors.Is(err, db.ErrorBadRequest) {
-			log.Printf("Error: %s", err.Error())
-			return nil, http.StatusBadRequest
-		}
-		log.Printf("Error: %s", err.Error())
-		return nil, http.StatusInternalServerError
+		return problem.LogFromError(ctx, err)
 	}
 
-	return item, http.StatusOK
+	return etag.Response(item, item.Version), http.StatusOK
 }
 
-func getFields(body string) (string, *bool, error) {
-	type Input struct {
-		Name        string `json:"Name"`
-		IsCompleted *bool  `json:"IsCompleted"`
+func getFields(body string) (data.ItemUpdate, error) {
+	var input data.ItemUpdate
+	if err := validation.Decode(body, &input); err != nil {
+		return data.ItemUpdate{}, err
 	}
 
-	var input Input
-	err := json.Unmarshal([]byte(body), &input)
+	if err := setClearedFields(body, &input); err != nil {
+		return data.ItemUpdate{}, err
+	}
 
-	return input.Name, input.IsCompleted, err
+	v := validation.Validator{}
+	input.Name = v.Name("Name", input.Name, validation.MaxItemNameLength)
+	v.Fields(input.Validate())
+	return input, v.Err()
 }
 
-func getIDs(path string) (string, string, error) {
-	parts := strings.SplitN(path, "/", 6)
-	if len(parts) < 5 {
-		return "", "", fmt.Errorf("Unable to match path: %s", path)
+// setClearedFields marks the quantity, unit and category to be cleared when body sets them to null,
+// as decoding leaves a null field the same as one which wasn't sent
+func setClearedFields(body string, update *data.ItemUpdate) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &fields); err != nil {
+		return err
+	}
+
+	for name, value := range fields {
+		if string(value) != "null" {
+			continue
+		}
+		switch {
+		case strings.EqualFold(name, "Quantity"):
+			update.ClearQuantity = true
+		case strings.EqualFold(name, "Unit"):
+			update.ClearUnit = true
+		case strings.EqualFold(name, "Category"):
+			update.ClearCategory = true
+		}
 	}
-	return parts[2], parts[4], nil
+	return nil
 }
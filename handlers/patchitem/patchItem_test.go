@@ -1,118 +1,27 @@
 package patchitem
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
 
 	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
 	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestPatchItemMatch(t *testing.T) {
-	tests := []struct {
-		name        string
-		path        string
-		method      string
-		expectedRes bool
-	}{
-		{
-			name:        "Returns true for a matching path",
-			path:        "/lists/b6cf642d/items/73bb82c4/",
-			method:      "PATCH",
-			expectedRes: true,
-		},
-		{
-			name:        "Returns true for a uppercase ID",
-			path:        "/lists/B6CF642D/items/73BB82C4/",
-			method:      "PATCH",
-			expectedRes: true,
-		},
-		{
-			name:        "Returns true without trailing slash",
-			path:        "/lists/b6cf642d/items/73bb82c4",
-			method:      "PATCH",
-			expectedRes: true,
-		},
-		{
-			name:        "Returns false for list path",
-			path:        "/lists/b6cf642d/",
-			method:      "PATCH",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for list path without trailing slash",
-			path:        "/lists/b6cf642d",
-			method:      "PATCH",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for lists path",
-			path:        "/lists/",
-			method:      "PATCH",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for lists path without trailing slash",
-			path:        "/lists",
-			method:      "PATCH",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for items path",
-			path:        "/lists/b6cf642d/items/",
-			method:      "PATCH",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false when path is empty",
-			path:        "",
-			method:      "PATCH",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for a GET request",
-			path:        "/lists/b6cf642d/items/73bb82c4/",
-			method:      "GET",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for a POST request",
-			path:        "/lists/b6cf642d/items/73bb82c4/",
-			method:      "POST",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for a DELETE request",
-			path:        "/lists/b6cf642d/items/73bb82c4/",
-			method:      "DELETE",
-			expectedRes: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			dbMocked := &testhelpers.MockDB{}
-			dbMocked.Test(t)
-			defer dbMocked.AssertExpectations(t)
-
-			input := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, tt.method, "")
-			d := patchItem{db: dbMocked}
-			gotRes := d.Match(input)
-
-			assert.Equal(t, tt.expectedRes, gotRes)
-		})
-	}
-}
-
 type mockUpdateItem struct {
 	res *data.Item
 	err error
 }
 
 func TestPatchItemHandle(t *testing.T) {
+	version := int64(4)
+	quantity := 2.0
 	tests := []struct {
 		name               string
 		path               string
@@ -120,25 +29,18 @@ func TestPatchItemHandle(t *testing.T) {
 		itemID             string
 		newName            string
 		isCompleted        *bool
+		quantity           *float64
+		unit               string
+		clearQuantity      bool
+		clearUnit          bool
+		clearCategory      bool
 		body               string
+		ifMatch            string
+		expectedVersion    *int64
 		expectedRes        interface{}
 		expectedStatusCode int
 		mockOutput         *mockUpdateItem
 	}{
-		{
-			name:               "Returns 'Bad Request' when the path is empty",
-			path:               "",
-			listID:             "test-list-id",
-			expectedRes:        nil,
-			expectedStatusCode: 400,
-		},
-		{
-			name:               "Returns 'Bad Request' when the path is not in the correct format",
-			path:               "/lists/test-list-id",
-			listID:             "test-list-id",
-			expectedRes:        nil,
-			expectedStatusCode: 400,
-		},
 		{
 			name:               "Returns 'OK' and item when the path matches",
 			path:               "/lists/test-list-id/items/test-item-id/",
@@ -147,8 +49,8 @@ func TestPatchItemHandle(t *testing.T) {
 			newName:            "Apples",
 			isCompleted:        testhelpers.BoolToPointer(false),
 			body:               `{ "Name": "Apples", "IsCompleted": false }`,
-			mockOutput:         &mockUpdateItem{res: &data.Item{Name: "Apples", ItemKey: data.ItemKey{ID: "888"}, IsCompleted: false}, err: nil},
-			expectedRes:        &data.Item{Name: "Apples", IsCompleted: false, ItemKey: data.ItemKey{ID: "888"}},
+			mockOutput:         &mockUpdateItem{res: &data.Item{Name: "Apples", ItemKey: data.ItemKey{ID: "888"}, IsCompleted: false, Version: 5}, err: nil},
+			expectedRes:        &iface.Response{Body: &data.Item{Name: "Apples", IsCompleted: false, ItemKey: data.ItemKey{ID: "888"}, Version: 5}, Headers: map[string]string{"ETag": `"5"`}},
 			expectedStatusCode: 200,
 		},
 		{
@@ -159,8 +61,8 @@ func TestPatchItemHandle(t *testing.T) {
 			newName:            "Apples",
 			isCompleted:        nil,
 			body:               `{ "Name": "Apples" }`,
-			mockOutput:         &mockUpdateItem{res: &data.Item{Name: "Apples", ItemKey: data.ItemKey{ID: "888"}, IsCompleted: false}, err: nil},
-			expectedRes:        &data.Item{Name: "Apples", IsCompleted: false, ItemKey: data.ItemKey{ID: "888"}},
+			mockOutput:         &mockUpdateItem{res: &data.Item{Name: "Apples", ItemKey: data.ItemKey{ID: "888"}, IsCompleted: false, Version: 5}, err: nil},
+			expectedRes:        &iface.Response{Body: &data.Item{Name: "Apples", IsCompleted: false, ItemKey: data.ItemKey{ID: "888"}, Version: 5}, Headers: map[string]string{"ETag": `"5"`}},
 			expectedStatusCode: 200,
 		},
 		{
@@ -171,10 +73,92 @@ func TestPatchItemHandle(t *testing.T) {
 			newName:            "",
 			isCompleted:        testhelpers.BoolToPointer(true),
 			body:               `{ "IsCompleted": true }`,
-			mockOutput:         &mockUpdateItem{res: &data.Item{Name: "Bananas", ItemKey: data.ItemKey{ID: "888"}, IsCompleted: true}, err: nil},
-			expectedRes:        &data.Item{Name: "Bananas", IsCompleted: true, ItemKey: data.ItemKey{ID: "888"}},
+			mockOutput:         &mockUpdateItem{res: &data.Item{Name: "Bananas", ItemKey: data.ItemKey{ID: "888"}, IsCompleted: true, Version: 5}, err: nil},
+			expectedRes:        &iface.Response{Body: &data.Item{Name: "Bananas", IsCompleted: true, ItemKey: data.ItemKey{ID: "888"}, Version: 5}, Headers: map[string]string{"ETag": `"5"`}},
 			expectedStatusCode: 200,
 		},
+		{
+			name:               "Returns 'OK' and item when the quantity and unit are changed",
+			path:               "/lists/test-list-id/items/test-item-id/",
+			listID:             "test-list-id",
+			itemID:             "test-item-id",
+			quantity:           &quantity,
+			unit:               "pack",
+			body:               `{ "Quantity": 2, "Unit": "pack" }`,
+			mockOutput:         &mockUpdateItem{res: &data.Item{Name: "Eggs", ItemKey: data.ItemKey{ID: "888"}, Quantity: &quantity, Unit: "pack", Version: 5}, err: nil},
+			expectedRes:        &iface.Response{Body: &data.Item{Name: "Eggs", ItemKey: data.ItemKey{ID: "888"}, Quantity: &quantity, Unit: "pack", Version: 5}, Headers: map[string]string{"ETag": `"5"`}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'OK' and item when the quantity, unit and category are cleared",
+			path:               "/lists/test-list-id/items/test-item-id/",
+			listID:             "test-list-id",
+			itemID:             "test-item-id",
+			clearQuantity:      true,
+			clearUnit:          true,
+			clearCategory:      true,
+			body:               `{ "Quantity": null, "Unit": null, "Category": null }`,
+			mockOutput:         &mockUpdateItem{res: &data.Item{Name: "Eggs", ItemKey: data.ItemKey{ID: "888"}, Version: 5}, err: nil},
+			expectedRes:        &iface.Response{Body: &data.Item{Name: "Eggs", ItemKey: data.ItemKey{ID: "888"}, Version: 5}, Headers: map[string]string{"ETag": `"5"`}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the item would have a unit without a quantity",
+			path:               "/lists/test-list-id/items/test-item-id/",
+			listID:             "test-list-id",
+			itemID:             "test-item-id",
+			unit:               "kg",
+			body:               `{ "Unit": "kg" }`,
+			mockOutput:         &mockUpdateItem{res: nil, err: data.FieldErrors{{Field: "Unit", Err: data.ErrorUnitWithoutQuantity}}},
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Unit", Message: `can only be set along with "Quantity"`}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the unit is not known",
+			path:               "/lists/test-list-id/items/test-item-id/",
+			body:               `{ "Unit": "dozen" }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Unit", Message: "must be one of [pcs g kg ml l pack]"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the quantity is zero",
+			path:               "/lists/test-list-id/items/test-item-id/",
+			body:               `{ "Quantity": 0 }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Quantity", Message: "must be greater than 0"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'OK' and item with the name trimmed and normalised",
+			path:               "/lists/test-list-id/items/test-item-id/",
+			listID:             "test-list-id",
+			itemID:             "test-item-id",
+			newName:            "Cr\u00e8me fra\u00eeche",
+			body:               "{ \"Name\": \"  Cre\\u0300me frai\\u0302che \\n\" }",
+			mockOutput:         &mockUpdateItem{res: &data.Item{Name: "Cr\u00e8me fra\u00eeche", ItemKey: data.ItemKey{ID: "888"}, Version: 5}, err: nil},
+			expectedRes:        &iface.Response{Body: &data.Item{Name: "Cr\u00e8me fra\u00eeche", ItemKey: data.ItemKey{ID: "888"}, Version: 5}, Headers: map[string]string{"ETag": `"5"`}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the name is blank",
+			path:               "/lists/test-list-id/items/test-item-id/",
+			body:               `{ "Name": "   " }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Name", Message: "must not be blank"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the name has control characters in it",
+			path:               "/lists/test-list-id/items/test-item-id/",
+			body:               `{ "Name": "Apples\u0007" }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Name", Message: "must not contain control characters"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' for a field which isn't known",
+			path:               "/lists/test-list-id/items/test-item-id/",
+			body:               `{ "Name": "Apples", "Colour": "red" }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Colour", Message: "is not a known field"}),
+			expectedStatusCode: 422,
+		},
 		{
 			name:               "Returns 'Bad Request' when name is empty",
 			path:               "/lists/test-list-id/items/test-item-id/",
@@ -184,7 +168,7 @@ func TestPatchItemHandle(t *testing.T) {
 			isCompleted:        nil,
 			body:               `{ "Name": "" }`,
 			mockOutput:         &mockUpdateItem{res: nil, err: db.ErrorBadRequest},
-			expectedRes:        nil,
+			expectedRes:        problem.ForStatus(400, ""),
 			expectedStatusCode: 400,
 		},
 		{
@@ -194,7 +178,7 @@ func TestPatchItemHandle(t *testing.T) {
 			itemID:             "test-item-id",
 			newName:            "Apples",
 			body:               "",
-			expectedRes:        nil,
+			expectedRes:        problem.ForStatus(400, "The request body is empty"),
 			expectedStatusCode: 400,
 		},
 		{
@@ -205,7 +189,7 @@ func TestPatchItemHandle(t *testing.T) {
 			newName:            "Apples",
 			body:               "{ \"Name\": \"Apples\" }",
 			mockOutput:         &mockUpdateItem{res: nil, err: db.ErrorNotFound},
-			expectedRes:        nil,
+			expectedRes:        problem.ForStatus(404, ""),
 			expectedStatusCode: 404,
 		},
 		{
@@ -216,9 +200,43 @@ func TestPatchItemHandle(t *testing.T) {
 			newName:            "Apples",
 			body:               "{ \"Name\": \"Apples\" }",
 			mockOutput:         &mockUpdateItem{res: nil, err: errors.New("Something bad happened")},
-			expectedRes:        nil,
+			expectedRes:        problem.ForStatus(500, ""),
 			expectedStatusCode: 500,
 		},
+		{
+			name:               "Returns 'OK' and item when it is at the version in If-Match",
+			path:               "/lists/test-list-id/items/test-item-id/",
+			listID:             "test-list-id",
+			itemID:             "test-item-id",
+			newName:            "Apples",
+			body:               "{ \"Name\": \"Apples\" }",
+			ifMatch:            `"4"`,
+			expectedVersion:    &version,
+			mockOutput:         &mockUpdateItem{res: &data.Item{Name: "Apples", ItemKey: data.ItemKey{ID: "888"}, Version: 5}, err: nil},
+			expectedRes:        &iface.Response{Body: &data.Item{Name: "Apples", ItemKey: data.ItemKey{ID: "888"}, Version: 5}, Headers: map[string]string{"ETag": `"5"`}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Precondition Failed' when the item is not at the version in If-Match",
+			path:               "/lists/test-list-id/items/test-item-id/",
+			listID:             "test-list-id",
+			itemID:             "test-item-id",
+			newName:            "Apples",
+			body:               "{ \"Name\": \"Apples\" }",
+			ifMatch:            `"4"`,
+			expectedVersion:    &version,
+			mockOutput:         &mockUpdateItem{res: nil, err: db.ErrorPreconditionFailed},
+			expectedRes:        problem.ForStatus(412, ""),
+			expectedStatusCode: 412,
+		},
+		{
+			name:               "Returns 'Precondition Failed' when If-Match is not a valid ETag",
+			path:               "/lists/test-list-id/items/test-item-id/",
+			body:               "{ \"Name\": \"Apples\" }",
+			ifMatch:            "4",
+			expectedRes:        problem.ForStatus(412, "If-Match is not a valid ETag"),
+			expectedStatusCode: 412,
+		},
 	}
 
 	for _, tt := range tests {
@@ -229,7 +247,7 @@ func TestPatchItemHandle(t *testing.T) {
 
 			if tt.mockOutput != nil {
 				dbMocked.
-					On("UpdateItem", tt.listID, tt.itemID, tt.newName, tt.isCompleted).
+					On("UpdateItem", tt.listID, tt.itemID, data.ItemUpdate{Name: tt.newName, IsCompleted: tt.isCompleted, Quantity: tt.quantity, Unit: tt.unit, ClearQuantity: tt.clearQuantity, ClearUnit: tt.clearUnit, ClearCategory: tt.clearCategory}, tt.expectedVersion).
 					Return(tt.mockOutput.res, tt.mockOutput.err).
 					Once()
 			}
@@ -237,7 +255,10 @@ func TestPatchItemHandle(t *testing.T) {
 			d := patchItem{db: dbMocked}
 
 			input := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, "PATCH", tt.body)
-			gotRes, statusCode := d.Handle(input)
+			if tt.ifMatch != "" {
+				input.Headers = map[string]string{"if-match": tt.ifMatch}
+			}
+			gotRes, statusCode := d.Handle(context.Background(), input, iface.PathParams{ListID: "test-list-id", ItemID: "test-item-id"})
 
 			assert.Equal(t, tt.expectedStatusCode, statusCode)
 			assert.Equal(t, tt.expectedRes, gotRes)
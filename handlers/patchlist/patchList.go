@@ -0,0 +1,64 @@
+package patchlist
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/etag"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/validation"
+	"github.com/mount-joy/thelist-lambda/logging"
+)
+
+type patchList struct {
+	db db.DB
+}
+
+// New returns an instance of patchList satisfying the RouteHandler interface
+func New() iface.RouteHandler {
+	return &patchList{
+		db: db.Database(),
+	}
+}
+
+// Handle handles this request and returns the response and status code
+func (p *patchList) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	newName, err := getFields(request.Body)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return validation.Respond(err)
+	}
+
+	expectedVersion, err := etag.IfMatch(request)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return problem.Respond(http.StatusPreconditionFailed, err.Error())
+	}
+
+	list, err := p.db.UpdateList(ctx, params.ListID, newName, expectedVersion)
+	if err != nil {
+		return problem.LogFromError(ctx, err)
+	}
+
+	return etag.Response(list, list.Version), http.StatusOK
+}
+
+// getFields validates the new name the same way as a list's name is validated when it's created
+func getFields(body string) (string, error) {
+	type Input struct {
+		Name string `json:"Name"`
+	}
+
+	var input Input
+	if err := validation.Decode(body, &input); err != nil {
+		return "", err
+	}
+
+	v := validation.Validator{}
+	v.Required("Name", input.Name)
+	name := v.Name("Name", input.Name, validation.MaxListNameLength)
+	return name, v.Err()
+}
@@ -0,0 +1,173 @@
+package patchlist
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockUpdateList struct {
+	res *data.List
+	err error
+}
+
+func TestPatchListHandle(t *testing.T) {
+	version := int64(2)
+	tests := []struct {
+		name               string
+		path               string
+		listID             string
+		newName            string
+		body               string
+		ifMatch            string
+		expectedVersion    *int64
+		mockOutput         *mockUpdateList
+		expectedRes        interface{}
+		expectedStatusCode int
+	}{
+		{
+			name:               "Returns 'Bad Request' when the body is not valid json",
+			path:               "/lists/test-list-id",
+			body:               `{ "Name": `,
+			expectedRes:        problem.ForStatus(400, "unexpected EOF"),
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Returns 'OK' and the list when it is renamed",
+			path:               "/lists/test-list-id/",
+			listID:             "test-list-id",
+			newName:            "Groceries",
+			body:               `{ "Name": "Groceries" }`,
+			mockOutput:         &mockUpdateList{res: &data.List{Name: "Groceries", ListKey: data.ListKey{ID: "test-list-id"}, Version: 3}, err: nil},
+			expectedRes:        &iface.Response{Body: &data.List{Name: "Groceries", ListKey: data.ListKey{ID: "test-list-id"}, Version: 3}, Headers: map[string]string{"ETag": `"3"`}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'OK' and renames the list with the name trimmed and normalised",
+			path:               "/lists/test-list-id/",
+			listID:             "test-list-id",
+			newName:            "Caf\u00e9",
+			body:               `{ "Name": "  Cafe\u0301 " }`,
+			mockOutput:         &mockUpdateList{res: &data.List{Name: "Caf\u00e9", ListKey: data.ListKey{ID: "test-list-id"}, Version: 3}, err: nil},
+			expectedRes:        &iface.Response{Body: &data.List{Name: "Caf\u00e9", ListKey: data.ListKey{ID: "test-list-id"}, Version: 3}, Headers: map[string]string{"ETag": `"3"`}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the name is missing",
+			path:               "/lists/test-list-id/",
+			body:               `{ "Name": "" }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Name", Message: "is required"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the name is too long",
+			path:               "/lists/test-list-id/",
+			body:               `{ "Name": "` + strings.Repeat("a", 101) + `" }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Name", Message: "must be at most 100 characters"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the body has unknown fields",
+			path:               "/lists/test-list-id/",
+			body:               `{ "Name": "Groceries", "OwnerId": "user-2" }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "OwnerId", Message: "is not a known field"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Bad Request' when the db rejects the update",
+			path:               "/lists/test-list-id/",
+			listID:             "test-list-id",
+			newName:            "Groceries",
+			body:               `{ "Name": "Groceries" }`,
+			mockOutput:         &mockUpdateList{res: nil, err: db.ErrorBadRequest},
+			expectedRes:        problem.ForStatus(400, ""),
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Returns 'Not Found' when the list does not exist",
+			path:               "/lists/test-list-id/",
+			listID:             "test-list-id",
+			newName:            "Groceries",
+			body:               `{ "Name": "Groceries" }`,
+			mockOutput:         &mockUpdateList{res: nil, err: db.ErrorNotFound},
+			expectedRes:        problem.ForStatus(404, ""),
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Returns 'Internal Server Error' when the db returns an error",
+			path:               "/lists/test-list-id/",
+			listID:             "test-list-id",
+			newName:            "Groceries",
+			body:               `{ "Name": "Groceries" }`,
+			mockOutput:         &mockUpdateList{res: nil, err: errors.New("Something bad happened")},
+			expectedRes:        problem.ForStatus(500, ""),
+			expectedStatusCode: 500,
+		},
+		{
+			name:               "Returns 'OK' and the list when it is at the version in If-Match",
+			path:               "/lists/test-list-id/",
+			listID:             "test-list-id",
+			newName:            "Groceries",
+			body:               `{ "Name": "Groceries" }`,
+			ifMatch:            `"2"`,
+			expectedVersion:    &version,
+			mockOutput:         &mockUpdateList{res: &data.List{Name: "Groceries", ListKey: data.ListKey{ID: "test-list-id"}, Version: 3}, err: nil},
+			expectedRes:        &iface.Response{Body: &data.List{Name: "Groceries", ListKey: data.ListKey{ID: "test-list-id"}, Version: 3}, Headers: map[string]string{"ETag": `"3"`}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Precondition Failed' when the list is not at the version in If-Match",
+			path:               "/lists/test-list-id/",
+			listID:             "test-list-id",
+			newName:            "Groceries",
+			body:               `{ "Name": "Groceries" }`,
+			ifMatch:            `"2"`,
+			expectedVersion:    &version,
+			mockOutput:         &mockUpdateList{res: nil, err: db.ErrorPreconditionFailed},
+			expectedRes:        problem.ForStatus(412, ""),
+			expectedStatusCode: 412,
+		},
+		{
+			name:               "Returns 'Precondition Failed' when If-Match is not a valid ETag",
+			path:               "/lists/test-list-id/",
+			body:               `{ "Name": "Groceries" }`,
+			ifMatch:            "two",
+			expectedRes:        problem.ForStatus(412, "If-Match is not a valid ETag"),
+			expectedStatusCode: 412,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &testhelpers.MockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			if tt.mockOutput != nil {
+				dbMocked.
+					On("UpdateList", tt.listID, tt.newName, tt.expectedVersion).
+					Return(tt.mockOutput.res, tt.mockOutput.err).
+					Once()
+			}
+
+			p := patchList{db: dbMocked}
+
+			input := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, "PATCH", tt.body)
+			if tt.ifMatch != "" {
+				input.Headers = map[string]string{"if-match": tt.ifMatch}
+			}
+			gotRes, statusCode := p.Handle(context.Background(), input, iface.PathParams{ListID: "test-list-id"})
+
+			assert.Equal(t, tt.expectedStatusCode, statusCode)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
@@ -1,16 +1,17 @@
 package postitem
 
 import (
-	"fmt"
-	"log"
+	"context"
 	"net/http"
-	"regexp"
-	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/mount-joy/thelist-lambda/data"
 	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/etag"
 	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/validation"
+	"github.com/mount-joy/thelist-lambda/logging"
 )
 
 type postItem struct {
@@ -20,44 +21,35 @@ type postItem struct {
 // New returns an instance of postItem satisfying the RouteHandler interface
 func New() iface.RouteHandler {
 	return &postItem{
-		db: db.DynamoDB(),
+		db: db.Database(),
 	}
 }
 
-// Match returns true if this RouteHandler should handle this request
-func (p *postItem) Match(request events.APIGatewayV2HTTPRequest) bool {
-	// POST /lists/<list_id>/items
-	var re = regexp.MustCompile(`^/lists/([\w-]+)/items/?$`)
-	return request.RequestContext.HTTP.Method == "POST" && re.MatchString(request.RequestContext.HTTP.Path)
-}
-
 // Handle handles this request and returns the response and status code
-func (p *postItem) Handle(request events.APIGatewayV2HTTPRequest) (interface{}, int) {
-	listID, err := getListID(request.RequestContext.HTTP.Path)
-	if err != nil {
-		log.Printf("Error: %s", err.Error())
-		return nil, http.StatusInternalServerError
-	}
-
-	name, err := data.GetNameFieldInJson(request.Body)
+func (p *postItem) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	fields, err := getFields(request.Body)
 	if err != nil {
-		log.Printf("Error: %s", err.Error())
-		return nil, http.StatusBadRequest
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return validation.Respond(err)
 	}
 
-	item, err := p.db.CreateItem(listID, name)
+	item, err := p.db.CreateItem(ctx, params.ListID, fields)
 	if err != nil {
-		log.Printf("Error: %s", err.Error())
-		return nil, http.StatusInternalServerError
+		return problem.LogFromError(ctx, err)
 	}
 
-	return item, http.StatusOK
+	return etag.Response(item, item.Version), http.StatusOK
 }
 
-func getListID(path string) (string, error) {
-	parts := strings.SplitN(path, "/", 4)
-	if len(parts) < 4 {
-		return "", fmt.Errorf("Unable to match path: %s", path)
+func getFields(body string) (data.NewItem, error) {
+	var input data.NewItem
+	if err := validation.Decode(body, &input); err != nil {
+		return data.NewItem{}, err
 	}
-	return parts[2], nil
+
+	v := validation.Validator{}
+	v.Required("Name", input.Name)
+	input.Name = v.Name("Name", input.Name, validation.MaxItemNameLength)
+	v.Fields(input.Validate())
+	return input, v.Err()
 }
@@ -1,170 +1,155 @@
 package postitem
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
 	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
 
 	"github.com/mount-joy/thelist-lambda/data"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestPostItemMatch(t *testing.T) {
-	tests := []struct {
-		name        string
-		path        string
-		method      string
-		expectedRes bool
-	}{
-		{
-			name:        "Returns true for a matching path",
-			path:        "/lists/b6cf642d/items/",
-			method:      "POST",
-			expectedRes: true,
-		},
-		{
-			name:        "Returns true for a uppercase ID",
-			path:        "/lists/B6CF642D/items/",
-			method:      "POST",
-			expectedRes: true,
-		},
-		{
-			name:        "Returns true without trailing slash",
-			path:        "/lists/b6cf642d/items",
-			method:      "POST",
-			expectedRes: true,
-		},
-		{
-			name:        "Returns false for list path",
-			path:        "/lists/b6cf642d/",
-			method:      "POST",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for list path without trailing slash",
-			path:        "/lists/b6cf642d",
-			method:      "POST",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for lists path",
-			path:        "/lists/",
-			method:      "POST",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for lists path without trailing slash",
-			path:        "/lists",
-			method:      "POST",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for item path",
-			path:        "/lists/b6cf642d-7a72-4969-bcc9-73bb82c4b3f6/items/73bb82c4",
-			method:      "POST",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false when path is empty",
-			path:        "",
-			method:      "POST",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for a GET request",
-			path:        "/lists/b6cf642d/items/",
-			method:      "GET",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for a DELETE request",
-			path:        "/lists/b6cf642d/items/",
-			method:      "DELETE",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for a PATCH request",
-			path:        "/lists/b6cf642d/items/",
-			method:      "PATCH",
-			expectedRes: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			dbMocked := &testhelpers.MockDB{}
-			dbMocked.Test(t)
-			defer dbMocked.AssertExpectations(t)
-
-			input := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, tt.method, "")
-			d := postItem{db: dbMocked}
-			gotRes := d.Match(input)
-
-			assert.Equal(t, tt.expectedRes, gotRes)
-		})
-	}
-}
-
 type mockPostItem struct {
 	res *data.Item
 	err error
 }
 
 func TestPostItemHandle(t *testing.T) {
+	quantity := 1.5
 	tests := []struct {
 		name               string
 		path               string
 		listID             string
-		itemName           string
+		fields             data.NewItem
 		body               string
 		mockOutput         *mockPostItem
 		expectedRes        interface{}
 		expectedStatusCode int
 	}{
-		{
-			name:               "Returns 'Internal Server Error' when the path is empty",
-			path:               "",
-			listID:             "test-list-id",
-			expectedRes:        nil,
-			expectedStatusCode: 500,
-		},
-		{
-			name:               "Returns 'Internal Server Error' when the path is not in the correct format",
-			path:               "/lists/test-list-id",
-			listID:             "test-list-id",
-			expectedRes:        nil,
-			expectedStatusCode: 500,
-		},
 		{
 			name:               "Returns 'OK' and results when the path matches",
 			path:               "/lists/test-list-id/items/",
 			listID:             "test-list-id",
-			itemName:           "my item",
+			fields:             data.NewItem{Name: "my item"},
 			body:               "{ \"Name\": \"my item\" }",
-			mockOutput:         &mockPostItem{res: &data.Item{Name: "ABC", ItemKey: data.ItemKey{ID: "888"}}, err: nil},
-			expectedRes:        &data.Item{Name: "ABC", ItemKey: data.ItemKey{ID: "888"}},
+			mockOutput:         &mockPostItem{res: &data.Item{Name: "ABC", ItemKey: data.ItemKey{ID: "888"}, Version: 1}, err: nil},
+			expectedRes:        &iface.Response{Body: &data.Item{Name: "ABC", ItemKey: data.ItemKey{ID: "888"}, Version: 1}, Headers: map[string]string{"ETag": `"1"`}},
 			expectedStatusCode: 200,
 		},
 		{
-			name:     "Returns 'internal server error' if database errors",
-			path:     "/lists/test-list-id/items/",
-			listID:   "test-list-id",
-			itemName: "my item",
-			body:     "{ \"Name\": \"my item\" }",
+			name:   "Returns 'internal server error' if database errors",
+			path:   "/lists/test-list-id/items/",
+			listID: "test-list-id",
+			fields: data.NewItem{Name: "my item"},
+			body:   "{ \"Name\": \"my item\" }",
 			mockOutput: &mockPostItem{
 				res: &data.Item{Name: "ABC", ItemKey: data.ItemKey{ID: "888"}},
 				err: fmt.Errorf("broken"),
 			},
-			expectedRes:        nil,
+			expectedRes:        problem.ForStatus(500, ""),
 			expectedStatusCode: 500,
 		},
+		{
+			name:               "Returns 'OK' and results when a quantity and unit are given",
+			path:               "/lists/test-list-id/items/",
+			listID:             "test-list-id",
+			fields:             data.NewItem{Name: "milk", Quantity: &quantity, Unit: "l"},
+			body:               `{ "Name": "milk", "Quantity": 1.5, "Unit": "l" }`,
+			mockOutput:         &mockPostItem{res: &data.Item{Name: "milk", Quantity: &quantity, Unit: "l", ItemKey: data.ItemKey{ID: "888"}, Version: 1}, err: nil},
+			expectedRes:        &iface.Response{Body: &data.Item{Name: "milk", Quantity: &quantity, Unit: "l", ItemKey: data.ItemKey{ID: "888"}, Version: 1}, Headers: map[string]string{"ETag": `"1"`}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'OK' and results when a category is given",
+			path:               "/lists/test-list-id/items/",
+			listID:             "test-list-id",
+			fields:             data.NewItem{Name: "milk", Category: "dairy"},
+			body:               `{ "Name": "milk", "Category": "dairy" }`,
+			mockOutput:         &mockPostItem{res: &data.Item{Name: "milk", Category: "dairy", ItemKey: data.ItemKey{ID: "888"}, Version: 1}, err: nil},
+			expectedRes:        &iface.Response{Body: &data.Item{Name: "milk", Category: "dairy", ItemKey: data.ItemKey{ID: "888"}, Version: 1}, Headers: map[string]string{"ETag": `"1"`}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the category is not known",
+			path:               "/lists/test-list-id/items",
+			listID:             "test-list-id",
+			body:               `{ "Name": "milk", "Category": "cows" }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Category", Message: "must be one of [produce bakery dairy meat fish frozen pantry snacks drinks household other]"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the unit is not known",
+			path:               "/lists/test-list-id/items",
+			listID:             "test-list-id",
+			body:               `{ "Name": "milk", "Quantity": 1.5, "Unit": "pints" }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Unit", Message: "must be one of [pcs g kg ml l pack]"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the quantity is not positive",
+			path:               "/lists/test-list-id/items",
+			listID:             "test-list-id",
+			body:               `{ "Name": "milk", "Quantity": -2 }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Quantity", Message: "must be greater than 0"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the quantity is not a number",
+			path:               "/lists/test-list-id/items",
+			listID:             "test-list-id",
+			body:               `{ "Name": "milk", "Quantity": "two" }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Quantity", Message: "must be a number"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'OK' and results with the name trimmed",
+			path:               "/lists/test-list-id/items/",
+			listID:             "test-list-id",
+			fields:             data.NewItem{Name: "milk"},
+			body:               `{ "Name": "\t milk " }`,
+			mockOutput:         &mockPostItem{res: &data.Item{Name: "milk", ItemKey: data.ItemKey{ID: "888"}, Version: 1}, err: nil},
+			expectedRes:        &iface.Response{Body: &data.Item{Name: "milk", ItemKey: data.ItemKey{ID: "888"}, Version: 1}, Headers: map[string]string{"ETag": `"1"`}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the name is missing",
+			path:               "/lists/test-list-id/items",
+			listID:             "test-list-id",
+			body:               `{ "Quantity": 2 }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Name", Message: "is required"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the name is too long",
+			path:               "/lists/test-list-id/items",
+			listID:             "test-list-id",
+			body:               fmt.Sprintf(`{ "Name": %q }`, strings.Repeat("a", 201)),
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Name", Message: "must be at most 200 characters"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:   "Returns 'Unprocessable Entity' with every invalid field",
+			path:   "/lists/test-list-id/items",
+			listID: "test-list-id",
+			body:   `{ "Name": " ", "Quantity": 0, "Category": "cows" }`,
+			expectedRes: problem.ForStatus(422, "The request body has invalid fields",
+				problem.Field{Name: "Name", Message: "must not be blank"},
+				problem.Field{Name: "Quantity", Message: "must be greater than 0"},
+				problem.Field{Name: "Category", Message: "must be one of [produce bakery dairy meat fish frozen pantry snacks drinks household other]"},
+			),
+			expectedStatusCode: 422,
+		},
 		{
 			name:               "Returns 'Bad Request' when the body is empty",
 			path:               "/lists/test-list-id/items",
 			listID:             "test-list-id",
 			body:               "",
-			expectedRes:        nil,
+			expectedRes:        problem.ForStatus(400, "The request body is empty"),
 			expectedStatusCode: 400,
 		},
 	}
@@ -177,7 +162,7 @@ func TestPostItemHandle(t *testing.T) {
 
 			if tt.mockOutput != nil {
 				dbMocked.
-					On("CreateItem", tt.listID, tt.itemName).
+					On("CreateItem", tt.listID, tt.fields).
 					Return(tt.mockOutput.res, tt.mockOutput.err).
 					Once()
 			}
@@ -185,7 +170,7 @@ func TestPostItemHandle(t *testing.T) {
 			d := postItem{db: dbMocked}
 
 			input := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, "POST", tt.body)
-			gotRes, statusCode := d.Handle(input)
+			gotRes, statusCode := d.Handle(context.Background(), input, iface.PathParams{ListID: "test-list-id"})
 
 			assert.Equal(t, tt.expectedStatusCode, statusCode)
 			assert.Equal(t, tt.expectedRes, gotRes)
@@ -1,14 +1,17 @@
 package postlist
 
 import (
-	"log"
+	"context"
 	"net/http"
-	"regexp"
 
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/mount-joy/thelist-lambda/data"
 	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/access"
+	"github.com/mount-joy/thelist-lambda/handlers/etag"
 	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/validation"
+	"github.com/mount-joy/thelist-lambda/logging"
 )
 
 type postList struct {
@@ -18,30 +21,45 @@ type postList struct {
 // New returns an instance of postList satisfying the RouteHandler interface
 func New() iface.RouteHandler {
 	return &postList{
-		db: db.DynamoDB(),
+		db: db.Database(),
 	}
 }
 
-// Match returns true if this RouteHandler should handle this request
-func (p *postList) Match(request events.APIGatewayV2HTTPRequest) bool {
-	// POST /lists AND /lists/
-	var re = regexp.MustCompile(`^/lists\/?$`)
-	return request.RequestContext.HTTP.Method == "POST" && re.MatchString(request.RequestContext.HTTP.Path)
-}
-
 // Handle handles creat list requests and returns the response body and status code
-func (p *postList) Handle(request events.APIGatewayV2HTTPRequest) (interface{}, int) {
-	name, err := data.GetNameFieldInJson(request.Body)
+// The caller becomes the owner of the new list
+func (p *postList) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	ownerID, err := access.CallerID(request)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return problem.Respond(http.StatusUnauthorized, err.Error())
+	}
+
+	name, err := getName(request.Body)
 	if err != nil {
-		log.Printf("Error: %s", err.Error())
-		return nil, http.StatusBadRequest
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return validation.Respond(err)
 	}
 
-	list, err := p.db.CreateList(name)
+	list, err := p.db.CreateList(ctx, name, ownerID)
 	if err != nil {
-		log.Printf("Error: %s", err.Error())
-		return nil, http.StatusInternalServerError
+		return problem.LogFromError(ctx, err)
+	}
+
+	return etag.Response(list, list.Version), http.StatusOK
+}
+
+func getName(body string) (string, error) {
+	type Input struct {
+		Name string `json:"Name"`
+	}
+
+	var input Input
+	if err := validation.Decode(body, &input); err != nil {
+		return "", err
 	}
 
-	return list, http.StatusOK
+	v := validation.Validator{}
+	v.Required("Name", input.Name)
+	name := v.Name("Name", input.Name, validation.MaxListNameLength)
+	return name, v.Err()
 }
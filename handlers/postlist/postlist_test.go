@@ -1,96 +1,18 @@
 package postlist
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
 	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestPostListMatch(t *testing.T) {
-	tests := []struct {
-		name        string
-		path        string
-		method      string
-		expectedRes bool
-	}{
-		{
-			name:        "Returns true for a matching path",
-			path:        "/lists",
-			method:      "POST",
-			expectedRes: true,
-		},
-		{
-			name:        "Returns true with trailing slash",
-			path:        "/lists/",
-			method:      "POST",
-			expectedRes: true,
-		},
-		{
-			name:        "Returns false fot PUT request",
-			path:        "/lists/",
-			method:      "PUT",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false fot DELETE request",
-			path:        "/lists/",
-			method:      "DELETE",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false fot GET request",
-			path:        "/lists/",
-			method:      "GET",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false fot PATCH request",
-			path:        "/lists/",
-			method:      "PATCH",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false when path is empty",
-			path:        "",
-			method:      "POST",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for list path",
-			path:        "/lists/b6cf642d/",
-			method:      "POST",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for list path without trailing slash",
-			path:        "/lists/b6cf642d",
-			method:      "POST",
-			expectedRes: false,
-		},
-		{
-			name:        "Returns false for item path",
-			path:        "/lists/b6cf642d/items/",
-			method:      "POST",
-			expectedRes: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			input := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, tt.method, "")
-
-			handler := &postList{}
-
-			gotRes := handler.Match(input)
-
-			assert.Equal(t, tt.expectedRes, gotRes)
-		})
-	}
-}
-
 func TestPostListHandle(t *testing.T) {
 	type mockPostList struct {
 		res *data.List
@@ -99,8 +21,11 @@ func TestPostListHandle(t *testing.T) {
 	tests := []struct {
 		name               string
 		listName           string
+		expectedName       string
+		body               string
 		mockPostList       *mockPostList
 		badJsonInput       bool
+		noCaller           bool
 		expectedRes        interface{}
 		expectedStatusCode int
 	}{
@@ -108,10 +33,10 @@ func TestPostListHandle(t *testing.T) {
 			name:     "Returns 'OK' if database succeeds",
 			listName: "myeList",
 			mockPostList: &mockPostList{
-				res: &data.List{Name: "myList", ListKey: data.ListKey{ID: "1234"}},
+				res: &data.List{Name: "myList", ListKey: data.ListKey{ID: "1234"}, Version: 1},
 				err: nil,
 			},
-			expectedRes:        &data.List{Name: "myList", ListKey: data.ListKey{ID: "1234"}},
+			expectedRes:        &iface.Response{Body: &data.List{Name: "myList", ListKey: data.ListKey{ID: "1234"}, Version: 1}, Headers: map[string]string{"ETag": `"1"`}},
 			expectedStatusCode: 200,
 		},
 		{
@@ -121,13 +46,61 @@ func TestPostListHandle(t *testing.T) {
 				res: nil,
 				err: fmt.Errorf("uh oh"),
 			},
-			expectedRes:        nil,
+			expectedRes:        problem.ForStatus(500, ""),
 			expectedStatusCode: 500,
 		},
+		{
+			name:               "Returns 'Unauthorized' when there is no caller",
+			listName:           "myeList",
+			noCaller:           true,
+			expectedRes:        problem.ForStatus(401, "Request has no caller identity"),
+			expectedStatusCode: 401,
+		},
+		{
+			name:     "Returns 'OK' and creates the list with the name trimmed and normalised",
+			listName: "  Cafe\u0301 ",
+			mockPostList: &mockPostList{
+				res: &data.List{Name: "Caf\u00e9", ListKey: data.ListKey{ID: "1234"}, Version: 1},
+				err: nil,
+			},
+			expectedName:       "Caf\u00e9",
+			expectedRes:        &iface.Response{Body: &data.List{Name: "Caf\u00e9", ListKey: data.ListKey{ID: "1234"}, Version: 1}, Headers: map[string]string{"ETag": `"1"`}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the name is empty",
+			listName:           "",
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Name", Message: "is required"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the name is all whitespace",
+			listName:           " \t\n",
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Name", Message: "must not be blank"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the name is too long",
+			listName:           strings.Repeat("\u00e9", 101),
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Name", Message: "must be at most 100 characters"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' when the name has control characters in it",
+			body:               `{ "Name": "my\u0000list" }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Name", Message: "must not contain control characters"}),
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "Returns 'Unprocessable Entity' for a field which isn't known",
+			body:               `{ "Name": "myList", "Owner": "user-2" }`,
+			expectedRes:        problem.ForStatus(422, "The request body has invalid fields", problem.Field{Name: "Owner", Message: "is not a known field"}),
+			expectedStatusCode: 422,
+		},
 		{
 			name:               "Returns error for bad json in body",
 			badJsonInput:       true,
-			expectedRes:        nil,
+			expectedRes:        problem.ForStatus(400, "invalid character 'b' looking for beginning of value"),
 			expectedStatusCode: 400,
 		},
 	}
@@ -138,25 +111,32 @@ func TestPostListHandle(t *testing.T) {
 			dbMocked.Test(t)
 			defer dbMocked.AssertExpectations(t)
 
+			expectedName := tt.listName
+			if tt.expectedName != "" {
+				expectedName = tt.expectedName
+			}
 			if tt.mockPostList != nil {
 				dbMocked.
-					On("CreateList", tt.listName).
+					On("CreateList", expectedName, "user-1").
 					Return(tt.mockPostList.res, tt.mockPostList.err).
 					Once()
 			}
 
 			d := postList{db: &dbMocked}
 
-			var body string
+			body := tt.body
 			if tt.badJsonInput {
 				body = `badjson,`
-			} else {
+			} else if body == "" {
 				body = fmt.Sprintf("{ \"Name\": %q }", tt.listName)
 			}
 			// Fine to hard code path and method as they aren't used in this function
 			input := testhelpers.CreateAPIGatewayV2HTTPRequest("/lists/", "POST", body)
+			if !tt.noCaller {
+				input = testhelpers.WithCaller(input, "user-1")
+			}
 
-			gotRes, statusCode := d.Handle(input)
+			gotRes, statusCode := d.Handle(context.Background(), input, iface.PathParams{})
 
 			assert.Equal(t, tt.expectedStatusCode, statusCode)
 			assert.Equal(t, tt.expectedRes, gotRes)
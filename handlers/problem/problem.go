@@ -0,0 +1,144 @@
+package problem
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/logging"
+)
+
+// ContentType is the media type error responses are served as
+const ContentType = "application/problem+json"
+
+// Code - stable, machine readable identifier for the kind of problem, clients should switch on it rather than the detail
+type Code string
+
+const (
+	CodeBadRequest         Code = "bad_request"
+	CodeUnauthorized       Code = "unauthorized"
+	CodeForbidden          Code = "forbidden"
+	CodeNotFound           Code = "not_found"
+	CodeMethodNotAllowed   Code = "method_not_allowed"
+	CodeIDExists           Code = "id_exists"
+	CodeRequestInProgress  Code = "request_in_progress"
+	CodePreconditionFailed Code = "precondition_failed"
+	CodeValidationFailed   Code = "validation_failed"
+	CodeTokenExpired       Code = "token_expired"
+	CodeInternal           Code = "internal_error"
+)
+
+// statusCodes are the codes used for a status when nothing more specific is known
+var statusCodes = map[int]Code{
+	http.StatusBadRequest:          CodeBadRequest,
+	http.StatusUnauthorized:        CodeUnauthorized,
+	http.StatusForbidden:           CodeForbidden,
+	http.StatusNotFound:            CodeNotFound,
+	http.StatusMethodNotAllowed:    CodeMethodNotAllowed,
+	http.StatusConflict:            CodeIDExists,
+	http.StatusGone:                CodeTokenExpired,
+	http.StatusPreconditionFailed:  CodePreconditionFailed,
+	http.StatusUnprocessableEntity: CodeValidationFailed,
+	http.StatusInternalServerError: CodeInternal,
+}
+
+// Field - a problem with one field or query parameter of the request
+type Field struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// Problem - the body of every error response, an RFC 7807 problem with a code, the request ID and field details
+type Problem struct {
+	Type      string  `json:"type"`
+	Title     string  `json:"title"`
+	Status    int     `json:"status"`
+	Code      Code    `json:"code"`
+	Detail    string  `json:"detail,omitempty"`
+	RequestID string  `json:"requestId,omitempty"`
+	Fields    []Field `json:"fields,omitempty"`
+}
+
+// New returns a Problem for status
+func New(status int, code Code, detail string, fields ...Field) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+		Fields: fields,
+	}
+}
+
+// ForStatus returns a Problem for status with the code usually used for it
+func ForStatus(status int, detail string, fields ...Field) *Problem {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = Code(strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_")))
+	}
+	return New(status, code, detail, fields...)
+}
+
+// Respond returns a Problem for status along with the status, ready to be returned from a handler
+func Respond(status int, detail string, fields ...Field) (*Problem, int) {
+	return ForStatus(status, detail, fields...), status
+}
+
+// RespondWithCode returns a Problem for status with code along with the status, for when the status' usual code says too little
+func RespondWithCode(status int, code Code, detail string, fields ...Field) (*Problem, int) {
+	return New(status, code, detail, fields...), status
+}
+
+// dbErrors are the statuses for the errors returned by the database
+var dbErrors = []struct {
+	err    error
+	status int
+}{
+	{err: db.ErrorNotFound, status: http.StatusNotFound},
+	{err: db.ErrorBadRequest, status: http.StatusBadRequest},
+	{err: db.ErrorIDExists, status: http.StatusConflict},
+	{err: db.ErrorPreconditionFailed, status: http.StatusPreconditionFailed},
+}
+
+// FromError returns the Problem and status for an error returned by the database
+// Unexpected errors are an internal error, their detail isn't shown to the caller
+func FromError(err error) (*Problem, int) {
+	for _, known := range dbErrors {
+		if !errors.Is(err, known.err) {
+			continue
+		}
+		// Only a wrapped error says more than the code already does
+		detail := ""
+		if err != known.err {
+			detail = err.Error()
+		}
+		return Respond(known.status, detail)
+	}
+	return Respond(http.StatusInternalServerError, "")
+}
+
+// LogFromError logs err as the reason the request failed and returns its Problem and status like FromError
+// Errors the caller made are logged as warnings, only unexpected ones as errors
+func LogFromError(ctx context.Context, err error) (*Problem, int) {
+	p, status := FromError(err)
+	logging.FromContext(ctx).Log(logging.LevelForStatus(status), "Request failed", "error", err)
+	return p, status
+}
+
+// FromResult returns the Problem a handler responded with, or one for status if it responded with something else
+func FromResult(result interface{}, status int) *Problem {
+	if p, ok := result.(*Problem); ok && p != nil {
+		return p
+	}
+	return ForStatus(status, "")
+}
+
+// WithRequestID returns a copy of the Problem for the request with ID requestID
+func (p *Problem) WithRequestID(requestID string) *Problem {
+	withID := *p
+	withID.RequestID = requestID
+	return &withID
+}
@@ -0,0 +1,152 @@
+package problem
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		detail      string
+		fields      []Field
+		expectedRes *Problem
+	}{
+		{
+			name:        "Uses the code for the status",
+			status:      404,
+			detail:      "No route",
+			expectedRes: &Problem{Type: "about:blank", Title: "Not Found", Status: 404, Code: CodeNotFound, Detail: "No route"},
+		},
+		{
+			name:        "Keeps the fields",
+			status:      400,
+			fields:      []Field{{Name: "limit", Message: "too big"}},
+			expectedRes: &Problem{Type: "about:blank", Title: "Bad Request", Status: 400, Code: CodeBadRequest, Fields: []Field{{Name: "limit", Message: "too big"}}},
+		},
+		{
+			name:        "Derives a code from the status text for other statuses",
+			status:      429,
+			expectedRes: &Problem{Type: "about:blank", Title: "Too Many Requests", Status: 429, Code: "too_many_requests"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedRes, ForStatus(tt.status, tt.detail, tt.fields...))
+		})
+	}
+}
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedRes    *Problem
+		expectedStatus int
+	}{
+		{
+			name:           "ErrorNotFound is 'Not Found'",
+			err:            db.ErrorNotFound,
+			expectedRes:    ForStatus(404, ""),
+			expectedStatus: 404,
+		},
+		{
+			name:           "ErrorBadRequest is 'Bad Request' with the detail of a wrapped error",
+			err:            fmt.Errorf("%w: item %q is not on the list", db.ErrorBadRequest, "a"),
+			expectedRes:    ForStatus(400, `Bad Request: item "a" is not on the list`),
+			expectedStatus: 400,
+		},
+		{
+			name:           "ErrorIDExists is 'Conflict'",
+			err:            db.ErrorIDExists,
+			expectedRes:    New(409, CodeIDExists, ""),
+			expectedStatus: 409,
+		},
+		{
+			name:           "ErrorPreconditionFailed is 'Precondition Failed'",
+			err:            db.ErrorPreconditionFailed,
+			expectedRes:    ForStatus(412, ""),
+			expectedStatus: 412,
+		},
+		{
+			name:           "Other errors are 'Internal Server Error' without their detail",
+			err:            errors.New("Connection refused"),
+			expectedRes:    ForStatus(500, ""),
+			expectedStatus: 500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRes, gotStatus := FromError(tt.err)
+
+			assert.Equal(t, tt.expectedRes, gotRes)
+			assert.Equal(t, tt.expectedStatus, gotStatus)
+		})
+	}
+}
+
+func TestRespondWithCode(t *testing.T) {
+	p, status := RespondWithCode(409, CodeRequestInProgress, "Still going")
+
+	assert.Equal(t, 409, status)
+	assert.Equal(t, &Problem{Type: "about:blank", Title: "Conflict", Status: 409, Code: CodeRequestInProgress, Detail: "Still going"}, p)
+}
+
+func TestLogFromError(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		expectedLevel string
+	}{
+		{
+			name:          "Errors the caller made are logged as warnings",
+			err:           db.ErrorNotFound,
+			expectedLevel: logging.LevelWarn,
+		},
+		{
+			name:          "Unexpected errors are logged as errors",
+			err:           errors.New("Something bad happened"),
+			expectedLevel: logging.LevelError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			ctx := logging.NewContext(context.Background(), logging.New(out))
+
+			gotRes, gotStatus := LogFromError(ctx, tt.err)
+
+			expectedRes, expectedStatus := FromError(tt.err)
+			assert.Equal(t, expectedRes, gotRes)
+			assert.Equal(t, expectedStatus, gotStatus)
+			assert.Contains(t, out.String(), `"level":"`+tt.expectedLevel+`"`)
+		})
+	}
+}
+
+func TestFromResult(t *testing.T) {
+	p := ForStatus(403, "No access")
+	assert.Same(t, p, FromResult(p, 403))
+	assert.Equal(t, ForStatus(404, ""), FromResult(nil, 404))
+	assert.Equal(t, ForStatus(400, ""), FromResult(map[string]string{"error": "nope"}, 400))
+}
+
+func TestWithRequestID(t *testing.T) {
+	p := ForStatus(404, "")
+
+	withID := p.WithRequestID("request-1")
+
+	assert.Equal(t, "request-1", withID.RequestID)
+	assert.Equal(t, "", p.RequestID)
+}
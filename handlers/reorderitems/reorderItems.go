@@ -0,0 +1,59 @@
+package reorderitems
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/logging"
+)
+
+type reorderItems struct {
+	db db.DB
+}
+
+// New returns an instance of reorderItems satisfying the RouteHandler interface
+func New() iface.RouteHandler {
+	return &reorderItems{
+		db: db.Database(),
+	}
+}
+
+// Handle moves the items into the order given in the body and returns the reordered items and status code
+func (r *reorderItems) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	itemIDs, err := getItemIDs(request.Body)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Request failed", "error", err)
+		return problem.Respond(http.StatusBadRequest, err.Error())
+	}
+
+	items, err := r.db.ReorderItems(ctx, params.ListID, itemIDs)
+	if err != nil {
+		return problem.LogFromError(ctx, err)
+	}
+
+	return items, http.StatusOK
+}
+
+func getItemIDs(body string) ([]string, error) {
+	type Input struct {
+		ItemIDs []string `json:"ItemIds"`
+	}
+
+	var input Input
+	err := json.Unmarshal([]byte(body), &input)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(input.ItemIDs) == 0 {
+		return nil, fmt.Errorf("No \"ItemIds\" field in the json")
+	}
+
+	return input.ItemIDs, nil
+}
@@ -0,0 +1,113 @@
+package reorderitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReorderItems struct {
+	res *[]data.Item
+	err error
+}
+
+func TestReorderItemsHandle(t *testing.T) {
+	tests := []struct {
+		name               string
+		path               string
+		listID             string
+		itemIDs            []string
+		body               string
+		mockOutput         *mockReorderItems
+		expectedRes        interface{}
+		expectedStatusCode int
+	}{
+		{
+			name:               "Returns 'Bad Request' when the body is not valid json",
+			path:               "/lists/test-list-id/items/reorder",
+			body:               `{"ItemIds": [`,
+			expectedRes:        problem.ForStatus(400, "unexpected end of JSON input"),
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Returns 'Bad Request' when no items are given",
+			path:               "/lists/test-list-id/items/reorder",
+			body:               `{"ItemIds": []}`,
+			expectedRes:        problem.ForStatus(400, "No \"ItemIds\" field in the json"),
+			expectedStatusCode: 400,
+		},
+		{
+			name:    "Returns 'OK' and the items in their new order",
+			path:    "/lists/test-list-id/items/reorder",
+			listID:  "test-list-id",
+			itemIDs: []string{"b", "a"},
+			body:    `{"ItemIds": ["b", "a"]}`,
+			mockOutput: &mockReorderItems{
+				res: &[]data.Item{{ItemKey: data.ItemKey{ID: "b"}, Position: 1}, {ItemKey: data.ItemKey{ID: "a"}, Position: 2}},
+			},
+			expectedRes:        &[]data.Item{{ItemKey: data.ItemKey{ID: "b"}, Position: 1}, {ItemKey: data.ItemKey{ID: "a"}, Position: 2}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Bad Request' when an item isn't on the list",
+			path:               "/lists/test-list-id/items/reorder",
+			listID:             "test-list-id",
+			itemIDs:            []string{"z"},
+			body:               `{"ItemIds": ["z"]}`,
+			mockOutput:         &mockReorderItems{err: fmt.Errorf("%w: nope", db.ErrorBadRequest)},
+			expectedRes:        problem.ForStatus(400, "Bad Request: nope"),
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Returns 'Not Found' when an item is deleted while reordering",
+			path:               "/lists/test-list-id/items/reorder",
+			listID:             "test-list-id",
+			itemIDs:            []string{"b", "a"},
+			body:               `{"ItemIds": ["b", "a"]}`,
+			mockOutput:         &mockReorderItems{err: db.ErrorNotFound},
+			expectedRes:        problem.ForStatus(404, ""),
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Returns 'Internal Server Error' when the db returns an error",
+			path:               "/lists/test-list-id/items/reorder",
+			listID:             "test-list-id",
+			itemIDs:            []string{"b", "a"},
+			body:               `{"ItemIds": ["b", "a"]}`,
+			mockOutput:         &mockReorderItems{err: errors.New("Something bad happened")},
+			expectedRes:        problem.ForStatus(500, ""),
+			expectedStatusCode: 500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &testhelpers.MockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			if tt.mockOutput != nil {
+				dbMocked.
+					On("ReorderItems", tt.listID, tt.itemIDs).
+					Return(tt.mockOutput.res, tt.mockOutput.err).
+					Once()
+			}
+
+			r := reorderItems{db: dbMocked}
+
+			input := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, "POST", tt.body)
+			gotRes, statusCode := r.Handle(context.Background(), input, iface.PathParams{ListID: "test-list-id"})
+
+			assert.Equal(t, tt.expectedStatusCode, statusCode)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
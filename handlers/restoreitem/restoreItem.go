@@ -0,0 +1,33 @@
+package restoreitem
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/etag"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+)
+
+type restoreItem struct {
+	db db.DB
+}
+
+// New returns an instance of restoreItem satisfying the RouteHandler interface
+func New() iface.RouteHandler {
+	return &restoreItem{
+		db: db.Database(),
+	}
+}
+
+// Handle undoes the deletion of an item, it's not found once the item's tombstone has expired
+func (r *restoreItem) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	item, err := r.db.RestoreItem(ctx, params.ListID, params.ItemID)
+	if err != nil {
+		return problem.LogFromError(ctx, err)
+	}
+
+	return etag.Response(item, item.Version), http.StatusOK
+}
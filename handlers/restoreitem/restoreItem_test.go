@@ -0,0 +1,68 @@
+package restoreitem
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/db"
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRestoreItem struct {
+	res *data.Item
+	err error
+}
+
+func TestRestoreItemHandle(t *testing.T) {
+	tests := []struct {
+		name               string
+		mockOutput         *mockRestoreItem
+		expectedRes        interface{}
+		expectedStatusCode int
+	}{
+		{
+			name:               "Returns 'OK' and the restored item",
+			mockOutput:         &mockRestoreItem{res: &data.Item{Name: "ABC", ItemKey: data.ItemKey{ID: "888"}, Version: 4}},
+			expectedRes:        &iface.Response{Body: &data.Item{Name: "ABC", ItemKey: data.ItemKey{ID: "888"}, Version: 4}, Headers: map[string]string{"ETag": `"4"`}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Returns 'Not Found' when the item does not exist or its tombstone has expired",
+			mockOutput:         &mockRestoreItem{err: db.ErrorNotFound},
+			expectedRes:        problem.ForStatus(404, ""),
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Returns 'Internal Server Error' when db returns an error",
+			mockOutput:         &mockRestoreItem{err: errors.New("It went bad")},
+			expectedRes:        problem.ForStatus(500, ""),
+			expectedStatusCode: 500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbMocked := &testhelpers.MockDB{}
+			dbMocked.Test(t)
+			defer dbMocked.AssertExpectations(t)
+
+			dbMocked.
+				On("RestoreItem", "test-list-id", "test-item-id").
+				Return(tt.mockOutput.res, tt.mockOutput.err).
+				Once()
+
+			r := restoreItem{db: dbMocked}
+
+			input := testhelpers.CreateAPIGatewayV2HTTPRequest("/lists/test-list-id/items/test-item-id/restore", "POST", "")
+			gotRes, statusCode := r.Handle(context.Background(), input, iface.PathParams{ListID: "test-list-id", ItemID: "test-item-id"})
+
+			assert.Equal(t, tt.expectedStatusCode, statusCode)
+			assert.Equal(t, tt.expectedRes, gotRes)
+		})
+	}
+}
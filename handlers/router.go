@@ -1,48 +1,172 @@
 package handlers
 
 import (
-	"log"
+	"context"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/mount-joy/thelist-lambda/auth"
+	"github.com/mount-joy/thelist-lambda/cors"
+	"github.com/mount-joy/thelist-lambda/handlers/access"
+	"github.com/mount-joy/thelist-lambda/handlers/batchitems"
+	"github.com/mount-joy/thelist-lambda/handlers/deletecompleteditems"
 	"github.com/mount-joy/thelist-lambda/handlers/deleteitem"
+	"github.com/mount-joy/thelist-lambda/handlers/deletelist"
+	"github.com/mount-joy/thelist-lambda/handlers/getactivity"
+	"github.com/mount-joy/thelist-lambda/handlers/getchanges"
 	"github.com/mount-joy/thelist-lambda/handlers/getitem"
 	"github.com/mount-joy/thelist-lambda/handlers/getitems"
 	"github.com/mount-joy/thelist-lambda/handlers/getlist"
+	"github.com/mount-joy/thelist-lambda/handlers/getlists"
 	"github.com/mount-joy/thelist-lambda/handlers/helloworld"
+	"github.com/mount-joy/thelist-lambda/handlers/idempotency"
 	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/middleware"
 	"github.com/mount-joy/thelist-lambda/handlers/patchitem"
+	"github.com/mount-joy/thelist-lambda/handlers/patchlist"
 	"github.com/mount-joy/thelist-lambda/handlers/postitem"
 	"github.com/mount-joy/thelist-lambda/handlers/postlist"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/reorderitems"
+	"github.com/mount-joy/thelist-lambda/handlers/restoreitem"
+	"github.com/mount-joy/thelist-lambda/logging"
+	"github.com/mount-joy/thelist-lambda/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const allowHeader = "Allow"
+
 type router struct {
-	routes []iface.RouteHandler
+	routes *node
+	// middleware runs for every request, before it is matched to a route
+	middleware []iface.Middleware
+	// routeMiddleware runs for every matched route which isn't public
+	routeMiddleware []iface.Middleware
 }
 
 // NewRouter return the default implementation of Router
 func NewRouter() iface.Router {
-	routes := []iface.RouteHandler{
-		deleteitem.New(),
-		getitem.New(),
-		getitems.New(),
-		getlist.New(),
-		postitem.New(),
-		postlist.New(),
-		helloworld.New(),
-		patchitem.New(),
+	r := &router{
+		routes:          newNode(),
+		middleware:      []iface.Middleware{cors.Middleware(cors.NewOriginChecker())},
+		routeMiddleware: []iface.Middleware{auth.Middleware(auth.NewAuthenticator())},
+	}
+
+	restrict := access.Restrict()
+	idempotent := idempotency.Middleware()
+	r.handle("GET /hello", helloworld.New())
+	r.handle("GET /lists", getlists.New())
+	r.handle("POST /lists", postlist.New(), idempotent)
+	r.handle("DELETE /lists/{listId}", deletelist.New(), restrict)
+	r.handle("GET /lists/{listId}", getlist.New(), restrict)
+	r.handle("PATCH /lists/{listId}", patchlist.New(), restrict)
+	r.handle("GET /lists/{listId}/activity", getactivity.New(), restrict)
+	r.handle("GET /lists/{listId}/changes", getchanges.New(), restrict)
+	r.handle("DELETE /lists/{listId}/items", deletecompleteditems.New(), restrict)
+	r.handle("GET /lists/{listId}/items", getitems.New(), restrict)
+	r.handle("POST /lists/{listId}/items", postitem.New(), restrict, idempotent)
+	r.handle("POST /lists/{listId}/items:batch", batchitems.New(), restrict)
+	r.handle("POST /lists/{listId}/items/reorder", reorderitems.New(), restrict)
+	r.handle("DELETE /lists/{listId}/items/{itemId}", deleteitem.New(), restrict)
+	r.handle("GET /lists/{listId}/items/{itemId}", getitem.New(), restrict)
+	r.handle("PATCH /lists/{listId}/items/{itemId}", patchitem.New(), restrict)
+	r.handle("POST /lists/{listId}/items/{itemId}/restore", restoreitem.New(), restrict)
+	return r
+}
+
+// handle registers handler for a template such as `GET /lists/{listId}/items/{itemId}`, behind the middlewares
+// The handler's span is the innermost middleware, so it only covers the handler and not the middlewares in front of it
+func (r *router) handle(template string, handler iface.RouteHandler, middlewares ...iface.Middleware) {
+	traced := middleware.Route(handler, traceHandler(template))
+	r.routes.add(template, middleware.Route(traced, middlewares...))
+}
+
+// traceHandler returns a middleware which spans the handler registered for template
+func traceHandler(template string) iface.Middleware {
+	return func(next iface.HandlerFunc) iface.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+			ctx, span := tracing.Start(ctx, "RouteHandler.Handle", trace.WithAttributes(attribute.String("http.route", template)))
+			result, statusCode := next(ctx, request, params)
+			tracing.EndWithStatus(span, statusCode)
+			return result, statusCode
+		}
+	}
+}
+
+// Route call the appropriate handler for a request based on its method and path
+func (r *router) Route(ctx context.Context, request events.APIGatewayV2HTTPRequest) (interface{}, int) {
+	ctx, span := tracing.Start(ctx, "router.Route",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.method", request.RequestContext.HTTP.Method),
+			attribute.String("http.target", request.RequestContext.HTTP.Path),
+		),
+	)
+	result, statusCode := middleware.Chain(r.dispatch, r.middleware...)(ctx, request, iface.PathParams{})
+	tracing.EndWithStatus(span, statusCode)
+	return result, statusCode
+}
+
+// Match returns the template of the route for the request's method and path along with its params
+func (r *router) Match(request events.APIGatewayV2HTTPRequest) (string, iface.PathParams) {
+	found, params, _ := r.find(request)
+	if found == nil {
+		return "", iface.PathParams{}
+	}
+	return found.template, params
+}
+
+// find returns the route registered for the request's method and path, along with every node its path matched
+func (r *router) find(request events.APIGatewayV2HTTPRequest) (*route, iface.PathParams, []match) {
+	matches := r.routes.lookup(request.RequestContext.HTTP.Path)
+	for _, m := range matches {
+		if found, ok := m.node.routes[request.RequestContext.HTTP.Method]; ok {
+			return found, m.params, matches
+		}
 	}
-	return &router{routes: routes}
+	return nil, iface.PathParams{}, matches
 }
 
-// Route call the appropriate handler for a request based on its path
-func (r *router) Route(request events.APIGatewayV2HTTPRequest) (interface{}, int) {
-	for _, route := range r.routes {
-		if route.Match(request) {
-			return route.Handle(request)
+// dispatch hands the request to the route registered for its method and path, the caller must authenticate unless the route is public
+// When the path is known but has no route for the method it returns 405, with the methods it does have in the Allow header
+func (r *router) dispatch(ctx context.Context, request events.APIGatewayV2HTTPRequest, _ iface.PathParams) (interface{}, int) {
+	method, path := request.RequestContext.HTTP.Method, request.RequestContext.HTTP.Path
+
+	found, params, matches := r.find(request)
+	if found != nil {
+		handle := found.handler.Handle
+		if !middleware.IsPublic(found.handler) {
+			handle = middleware.Chain(handle, r.routeMiddleware...)
 		}
+		return handle(ctx, request, params)
 	}
 
-	log.Printf("Unable to match %s %s", request.RequestContext.HTTP.Method, request.RequestContext.HTTP.Path)
-	return nil, http.StatusNotFound
+	if len(matches) == 0 {
+		logging.FromContext(ctx).Warn("No route matched", "method", method, "path", path)
+		return problem.Respond(http.StatusNotFound, fmt.Sprintf("No route for %s %s", method, path))
+	}
+
+	logging.FromContext(ctx).Warn("Method not allowed", "method", method, "path", path)
+	body, status := problem.Respond(http.StatusMethodNotAllowed, fmt.Sprintf("%s isn't allowed for %s", method, path))
+	return &iface.Response{Body: body, Headers: map[string]string{allowHeader: allowedMethods(matches)}}, status
+}
+
+// allowedMethods returns the methods with a route on any of matches, comma separated
+func allowedMethods(matches []match) string {
+	seen := map[string]bool{}
+	methods := []string{}
+	for _, m := range matches {
+		for method := range m.node.routes {
+			if !seen[method] {
+				seen[method] = true
+				methods = append(methods, method)
+			}
+		}
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
 }
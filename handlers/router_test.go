@@ -1,101 +1,435 @@
 package handlers
 
 import (
+	"context"
 	"testing"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/mount-joy/thelist-lambda/handlers/iface"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
+	"github.com/mount-joy/thelist-lambda/tracing"
+	"github.com/mount-joy/thelist-lambda/tracing/tracingtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type mockRoute struct {
 	mock.Mock
 }
 
-func (m *mockRoute) Match(request events.APIGatewayV2HTTPRequest) bool {
-	args := m.Called(request)
-	return args.Bool(0)
+func (m *mockRoute) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	args := m.Called(request, params)
+	return args.Get(0), args.Int(1)
 }
 
-func (m *mockRoute) Handle(request events.APIGatewayV2HTTPRequest) (interface{}, int) {
-	args := m.Called(request)
-	return args.Get(0), args.Int(1)
+// namedRoute responds with the template it was registered for and the params it was given
+type namedRoute struct {
+	template string
 }
 
-func TestRoute(t *testing.T) {
-	bodyA := map[string]string{"route": "A"}
-	bodyB := map[string]string{"route": "B"}
+type namedResult struct {
+	template string
+	params   iface.PathParams
+}
+
+func (n *namedRoute) Handle(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+	return namedResult{template: n.template, params: params}, 200
+}
+
+// templates are the routes registered by NewRouter
+var templates = NewRouter().(*router).routes.templates()
 
+func TestRoute(t *testing.T) {
 	tests := []struct {
 		name           string
-		matchResA      bool
-		matchResB      bool
-		expectedBody   interface{}
+		method         string
+		path           string
+		expectedRes    interface{}
 		expectedStatus int
 	}{
 		{
-			name:           "No routes match then returns 404",
-			matchResA:      false,
-			matchResB:      false,
-			expectedBody:   nil,
-			expectedStatus: 404,
+			name:           "Routes to a template without params",
+			method:         "GET",
+			path:           "/lists",
+			expectedRes:    namedResult{template: "GET /lists"},
+			expectedStatus: 200,
 		},
 		{
-			name:           "Route A matches then returns body A",
-			matchResA:      true,
-			matchResB:      false,
-			expectedBody:   bodyA,
+			name:           "Routes to a template without params with a trailing slash",
+			method:         "POST",
+			path:           "/lists/",
+			expectedRes:    namedResult{template: "POST /lists"},
 			expectedStatus: 200,
 		},
 		{
-			name:           "Route B matches then returns body B",
-			matchResA:      false,
-			matchResB:      true,
-			expectedBody:   bodyB,
+			name:           "Passes the list ID to the route",
+			method:         "PATCH",
+			path:           "/lists/b6cf642d",
+			expectedRes:    namedResult{template: "PATCH /lists/{listId}", params: iface.PathParams{ListID: "b6cf642d"}},
 			expectedStatus: 200,
 		},
 		{
-			name:           "Both routes matches then returns body A",
-			matchResA:      true,
-			matchResB:      true,
-			expectedBody:   bodyA,
+			name:           "Passes the list and item IDs to the route",
+			method:         "DELETE",
+			path:           "/lists/b6cf642d/items/73bb82c4/",
+			expectedRes:    namedResult{template: "DELETE /lists/{listId}/items/{itemId}", params: iface.PathParams{ListID: "b6cf642d", ItemID: "73bb82c4"}},
 			expectedStatus: 200,
 		},
+		{
+			name:           "Passes uppercase IDs to the route unchanged",
+			method:         "GET",
+			path:           "/lists/B6CF642D/items/73BB82C4",
+			expectedRes:    namedResult{template: "GET /lists/{listId}/items/{itemId}", params: iface.PathParams{ListID: "B6CF642D", ItemID: "73BB82C4"}},
+			expectedStatus: 200,
+		},
+		{
+			name:           "Routes to a template below an item",
+			method:         "POST",
+			path:           "/lists/b6cf642d/items/73bb82c4/restore",
+			expectedRes:    namedResult{template: "POST /lists/{listId}/items/{itemId}/restore", params: iface.PathParams{ListID: "b6cf642d", ItemID: "73bb82c4"}},
+			expectedStatus: 200,
+		},
+		{
+			name:           "Routes to a template with a colon in a segment",
+			method:         "POST",
+			path:           "/lists/b6cf642d/items:batch",
+			expectedRes:    namedResult{template: "POST /lists/{listId}/items:batch", params: iface.PathParams{ListID: "b6cf642d"}},
+			expectedStatus: 200,
+		},
+		{
+			name:           "Prefers a literal segment to a param",
+			method:         "POST",
+			path:           "/lists/b6cf642d/items/reorder",
+			expectedRes:    namedResult{template: "POST /lists/{listId}/items/reorder", params: iface.PathParams{ListID: "b6cf642d"}},
+			expectedStatus: 200,
+		},
+		{
+			name:           "Falls back to a param when the literal segment has no route for the method",
+			method:         "GET",
+			path:           "/lists/b6cf642d/items/reorder",
+			expectedRes:    namedResult{template: "GET /lists/{listId}/items/{itemId}", params: iface.PathParams{ListID: "b6cf642d", ItemID: "reorder"}},
+			expectedStatus: 200,
+		},
+		{
+			name:           "Returns 'Method Not Allowed' with the allowed methods for a known path",
+			method:         "POST",
+			path:           "/lists/b6cf642d",
+			expectedRes:    &iface.Response{Body: problem.ForStatus(405, "POST isn't allowed for /lists/b6cf642d"), Headers: map[string]string{"Allow": "DELETE, GET, PATCH"}},
+			expectedStatus: 405,
+		},
+		{
+			name:           "Returns 'Method Not Allowed' with the methods of every template the path matches",
+			method:         "PUT",
+			path:           "/lists/b6cf642d/items/reorder/",
+			expectedRes:    &iface.Response{Body: problem.ForStatus(405, "PUT isn't allowed for /lists/b6cf642d/items/reorder/"), Headers: map[string]string{"Allow": "DELETE, GET, PATCH, POST"}},
+			expectedStatus: 405,
+		},
+		{
+			name:           "Returns 'Not Found' for an unknown path",
+			method:         "GET",
+			path:           "/lists/b6cf642d/things",
+			expectedRes:    problem.ForStatus(404, "No route for GET /lists/b6cf642d/things"),
+			expectedStatus: 404,
+		},
+		{
+			name:           "Returns 'Not Found' for a path which only matches part of a template",
+			method:         "GET",
+			path:           "/lists/b6cf642d/items/73bb82c4/more",
+			expectedRes:    problem.ForStatus(404, "No route for GET /lists/b6cf642d/items/73bb82c4/more"),
+			expectedStatus: 404,
+		},
+		{
+			name:           "Returns 'Not Found' for an empty param",
+			method:         "GET",
+			path:           "/lists//items",
+			expectedRes:    problem.ForStatus(404, "No route for GET /lists//items"),
+			expectedStatus: 404,
+		},
+		{
+			name:           "Returns 'Not Found' for a param which isn't an ID",
+			method:         "GET",
+			path:           "/lists/b6cf.642d",
+			expectedRes:    problem.ForStatus(404, "No route for GET /lists/b6cf.642d"),
+			expectedStatus: 404,
+		},
+		{
+			name:           "Returns 'Not Found' when the path is empty",
+			method:         "GET",
+			path:           "",
+			expectedRes:    problem.ForStatus(404, "No route for GET "),
+			expectedStatus: 404,
+		},
+	}
+
+	r := router{routes: newNode()}
+	for _, template := range templates {
+		r.handle(template, &namedRoute{template: template})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, tt.method, "")
+
+			gotRes, gotStatusCode := r.Route(context.Background(), request)
+
+			assert.Equal(t, tt.expectedRes, gotRes)
+			assert.Equal(t, tt.expectedStatus, gotStatusCode)
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		expectedTemplate string
+		expectedParams   iface.PathParams
+	}{
+		{
+			name:             "Returns the template and params of the route",
+			method:           "GET",
+			path:             "/lists/b6cf642d/items/73bb82c4",
+			expectedTemplate: "GET /lists/{listId}/items/{itemId}",
+			expectedParams:   iface.PathParams{ListID: "b6cf642d", ItemID: "73bb82c4"},
+		},
+		{
+			name:             "Returns the route Route would prefer",
+			method:           "POST",
+			path:             "/lists/b6cf642d/items/reorder",
+			expectedTemplate: "POST /lists/{listId}/items/reorder",
+			expectedParams:   iface.PathParams{ListID: "b6cf642d"},
+		},
+		{
+			name:   "Returns nothing when the path has no route for the method",
+			method: "POST",
+			path:   "/lists/b6cf642d",
+		},
+		{
+			name:   "Returns nothing for an unknown path",
+			method: "GET",
+			path:   "/things",
+		},
 	}
 
-	request := events.APIGatewayV2HTTPRequest{}
+	r := router{routes: newNode()}
+	for _, template := range templates {
+		r.handle(template, &namedRoute{template: template})
+	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			routeA := &mockRoute{}
-			routeA.Test(t)
-			routeA.
-				On("Match", request).
-				Return(tt.matchResA)
-
-			routeA.
-				On("Handle", request).
-				Return(bodyA, 200)
-
-			routeB := &mockRoute{}
-			routeB.Test(t)
-			routeB.
-				On("Match", request).
-				Return(tt.matchResB)
-
-			routeB.
-				On("Handle", request).
-				Return(bodyB, 200)
+			gotTemplate, gotParams := r.Match(testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, tt.method, ""))
+
+			assert.Equal(t, tt.expectedTemplate, gotTemplate)
+			assert.Equal(t, tt.expectedParams, gotParams)
+		})
+	}
+}
 
+func TestTemplates(t *testing.T) {
+	r := router{routes: newNode()}
+	for _, template := range []string{"POST /lists", "GET /lists/{listId}/items", "GET /lists", "GET /lists/{listId}"} {
+		r.handle(template, &namedRoute{template: template})
+	}
+
+	assert.Equal(t, []string{"GET /lists", "GET /lists/{listId}", "GET /lists/{listId}/items", "POST /lists"}, r.routes.templates())
+}
+
+func TestHandlePanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+	}{
+		{name: "Without a method", template: "/lists"},
+		{name: "Without a leading slash", template: "GET lists"},
+		{name: "With a trailing slash", template: "GET /lists/"},
+		{name: "With an empty segment", template: "GET /lists//items"},
+		{name: "With an unknown param", template: "GET /lists/{id}"},
+		{name: "With an unclosed param", template: "GET /lists/{listId"},
+		{name: "With a param which conflicts with an existing one", template: "GET /lists/{itemId}/items"},
+		{name: "Which is already registered", template: "GET /lists/{listId}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := router{routes: newNode()}
+			r.handle("GET /lists/{listId}", &namedRoute{})
+
+			assert.Panics(t, func() { r.handle(tt.template, &namedRoute{}) })
+		})
+	}
+}
+
+type mockPublicRoute struct {
+	mockRoute
+}
+
+func (m *mockPublicRoute) Public() bool {
+	return true
+}
+
+// recordMiddleware appends name to calls each time a request passes through it
+func recordMiddleware(name string, calls *[]string) iface.Middleware {
+	return func(next iface.HandlerFunc) iface.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+			*calls = append(*calls, name)
+			return next(ctx, request, params)
+		}
+	}
+}
+
+func TestRouteMiddleware(t *testing.T) {
+	body := map[string]string{"route": "A"}
+	params := iface.PathParams{ListID: "list-1"}
+
+	tests := []struct {
+		name           string
+		path           string
+		public         bool
+		expectedCalls  []string
+		expectedBody   interface{}
+		expectedStatus int
+	}{
+		{
+			name:           "Both chains run for a route which isn't public",
+			path:           "/lists/list-1",
+			expectedCalls:  []string{"global", "route"},
+			expectedBody:   body,
+			expectedStatus: 200,
+		},
+		{
+			name:           "Only the global chain runs for a public route",
+			path:           "/lists/list-1",
+			public:         true,
+			expectedCalls:  []string{"global"},
+			expectedBody:   body,
+			expectedStatus: 200,
+		},
+		{
+			name:           "Only the global chain runs when no routes match",
+			path:           "/other",
+			expectedCalls:  []string{"global"},
+			expectedBody:   problem.ForStatus(404, "No route for GET /other"),
+			expectedStatus: 404,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, "GET", "")
+
+			var route iface.RouteHandler
+			if tt.public {
+				public := &mockPublicRoute{}
+				public.Test(t)
+				public.On("Handle", request, params).Return(body, 200)
+				route = public
+			} else {
+				plain := &mockRoute{}
+				plain.Test(t)
+				plain.On("Handle", request, params).Return(body, 200)
+				route = plain
+			}
+
+			calls := []string{}
 			r := router{
-				routes: []iface.RouteHandler{routeA, routeB},
+				routes:          newNode(),
+				middleware:      []iface.Middleware{recordMiddleware("global", &calls)},
+				routeMiddleware: []iface.Middleware{recordMiddleware("route", &calls)},
 			}
+			r.handle("GET /lists/{listId}", route)
 
-			gotRes, gotStatusCode := r.Route(request)
+			gotRes, gotStatusCode := r.Route(context.Background(), request)
 
+			assert.Equal(t, tt.expectedCalls, calls)
 			assert.Equal(t, tt.expectedBody, gotRes)
 			assert.Equal(t, tt.expectedStatus, gotStatusCode)
 		})
 	}
 }
+
+func TestRouteSpans(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		expectedSpans []string
+		expectedRoute string
+		expectedCode  int
+	}{
+		{
+			name:          "Spans the route and its handler",
+			method:        "GET",
+			path:          "/lists/b6cf642d",
+			expectedSpans: []string{"RouteHandler.Handle", "router.Route"},
+			expectedRoute: "GET /lists/{listId}",
+			expectedCode:  200,
+		},
+		{
+			name:          "Only spans the route when nothing matched",
+			method:        "GET",
+			path:          "/things",
+			expectedSpans: []string{"router.Route"},
+			expectedCode:  404,
+		},
+	}
+
+	r := router{routes: newNode()}
+	for _, template := range templates {
+		r.handle(template, &namedRoute{template: template})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracingtest.Record(t)
+
+			r.Route(context.Background(), testhelpers.CreateAPIGatewayV2HTTPRequest(tt.path, tt.method, ""))
+
+			spans := recorder.Ended()
+			assert.Equal(t, tt.expectedSpans, tracingtest.Names(spans))
+			routeSpan := spans[len(spans)-1]
+			assert.Equal(t, trace.SpanKindServer, routeSpan.SpanKind())
+			assert.Subset(t, routeSpan.Attributes(), []attribute.KeyValue{
+				attribute.String("http.method", tt.method),
+				attribute.String("http.target", tt.path),
+				attribute.Int("http.status_code", tt.expectedCode),
+			})
+			if tt.expectedRoute != "" {
+				assert.Equal(t, routeSpan.SpanContext().SpanID(), spans[0].Parent().SpanID())
+				assert.Contains(t, spans[0].Attributes(), attribute.String("http.route", tt.expectedRoute))
+			}
+		})
+	}
+}
+
+// spanMiddleware spans the rest of the request as name
+func spanMiddleware(name string) iface.Middleware {
+	return func(next iface.HandlerFunc) iface.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayV2HTTPRequest, params iface.PathParams) (interface{}, int) {
+			ctx, span := tracing.Start(ctx, name)
+			defer span.End()
+			return next(ctx, request, params)
+		}
+	}
+}
+
+func TestRouteSpansOnlyTheHandler(t *testing.T) {
+	recorder := tracingtest.Record(t)
+
+	r := router{
+		routes:          newNode(),
+		routeMiddleware: []iface.Middleware{spanMiddleware("auth")},
+	}
+	r.handle("GET /lists/{listId}", &namedRoute{template: "GET /lists/{listId}"}, spanMiddleware("access"))
+
+	r.Route(context.Background(), testhelpers.CreateAPIGatewayV2HTTPRequest("/lists/b6cf642d", "GET", ""))
+
+	spans := recorder.Ended()
+	assert.Equal(t, []string{"RouteHandler.Handle", "access", "auth", "router.Route"}, tracingtest.Names(spans))
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("http.route", "GET /lists/{listId}"))
+}
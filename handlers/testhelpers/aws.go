@@ -14,3 +14,13 @@ func CreateAPIGatewayV2HTTPRequest(path string, method string, body string) even
 		},
 	}
 }
+
+// WithCaller returns a copy of request as if the JWT authorizer had identified the caller as callerID
+func WithCaller(request events.APIGatewayV2HTTPRequest, callerID string) events.APIGatewayV2HTTPRequest {
+	request.RequestContext.Authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+		JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{
+			Claims: map[string]string{"sub": callerID},
+		},
+	}
+	return request
+}
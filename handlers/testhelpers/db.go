@@ -1,6 +1,8 @@
 package testhelpers
 
 import (
+	"context"
+
 	"github.com/mount-joy/thelist-lambda/data"
 	"github.com/stretchr/testify/mock"
 )
@@ -10,44 +12,122 @@ type MockDB struct {
 	mock.Mock
 }
 
+// BatchWriteItems mocks the DB BatchWriteItems method
+func (m *MockDB) BatchWriteItems(ctx context.Context, listID string, operations []data.BatchOperation) ([]data.BatchResult, error) {
+	args := m.Called(listID, operations)
+	return args.Get(0).([]data.BatchResult), args.Error(1)
+}
+
+// CompleteIdempotentRequest mocks the DB CompleteIdempotentRequest method
+func (m *MockDB) CompleteIdempotentRequest(ctx context.Context, claim data.IdempotencyRecord, response data.IdempotentResponse) error {
+	args := m.Called(claim, response)
+	return args.Error(0)
+}
+
 // CreateItem mocks the DB CreateItem method
-func (m *MockDB) CreateItem(listID string, name string) (*data.Item, error) {
-	args := m.Called(listID, name)
+func (m *MockDB) CreateItem(ctx context.Context, listID string, fields data.NewItem) (*data.Item, error) {
+	args := m.Called(listID, fields)
 	return args.Get(0).(*data.Item), args.Error(1)
 }
 
 // CreateList mocks the DB CreateList method
-func (m *MockDB) CreateList(listName string) (*data.List, error) {
-	args := m.Called(listName)
+func (m *MockDB) CreateList(ctx context.Context, listName string, ownerID string) (*data.List, error) {
+	args := m.Called(listName, ownerID)
 	return args.Get(0).(*data.List), args.Error(1)
 }
 
 // GetItem mocks the DB GetItem method
-func (m *MockDB) GetItem(listID string, itemID string) (*data.Item, error) {
+func (m *MockDB) GetItem(ctx context.Context, listID string, itemID string) (*data.Item, error) {
 	args := m.Called(listID, itemID)
 	return args.Get(0).(*data.Item), args.Error(1)
 }
 
+// GetItemChanges mocks the DB GetItemChanges method
+func (m *MockDB) GetItemChanges(ctx context.Context, listID string, since string) (*[]data.Item, error) {
+	args := m.Called(listID, since)
+	return args.Get(0).(*[]data.Item), args.Error(1)
+}
+
 // GetList mocks the DB GetItem method
-func (m *MockDB) GetList(listID string) (*data.List, error) {
+func (m *MockDB) GetList(ctx context.Context, listID string) (*data.List, error) {
 	args := m.Called(listID)
 	return args.Get(0).(*data.List), args.Error(1)
 }
 
 // DeleteItem mocks the DB DeleteItem method
-func (m *MockDB) DeleteItem(listID string, itemID string) error {
-	args := m.Called(listID, itemID)
+func (m *MockDB) DeleteItem(ctx context.Context, listID string, itemID string, expectedVersion *int64) error {
+	args := m.Called(listID, itemID, expectedVersion)
 	return args.Error(1)
 }
 
+// DeleteCompletedItems mocks the DB DeleteCompletedItems method
+func (m *MockDB) DeleteCompletedItems(ctx context.Context, listID string) (int, error) {
+	args := m.Called(listID)
+	return args.Int(0), args.Error(1)
+}
+
+// DeleteList mocks the DB DeleteList method
+func (m *MockDB) DeleteList(ctx context.Context, listID string, expectedVersion *int64) error {
+	args := m.Called(listID, expectedVersion)
+	return args.Error(0)
+}
+
+// GetActivity mocks the DB GetActivity method
+func (m *MockDB) GetActivity(ctx context.Context, listID string, limit int64, startKey *data.ActivityKey) (*[]data.Activity, *data.ActivityKey, error) {
+	args := m.Called(listID, limit, startKey)
+	return args.Get(0).(*[]data.Activity), args.Get(1).(*data.ActivityKey), args.Error(2)
+}
+
 // GetItemsOnList mocks the DB GetItemsOnList method
-func (m *MockDB) GetItemsOnList(input string) (*[]data.Item, error) {
+func (m *MockDB) GetItemsOnList(ctx context.Context, input string) (*[]data.Item, error) {
 	args := m.Called(input)
 	return args.Get(0).(*[]data.Item), args.Error(1)
 }
 
+// GetItemsOnListPage mocks the DB GetItemsOnListPage method
+func (m *MockDB) GetItemsOnListPage(ctx context.Context, listID string, limit int64, startKey *data.ItemPositionKey) (*[]data.Item, *data.ItemPositionKey, error) {
+	args := m.Called(listID, limit, startKey)
+	return args.Get(0).(*[]data.Item), args.Get(1).(*data.ItemPositionKey), args.Error(2)
+}
+
+// GetListsForOwner mocks the DB GetListsForOwner method
+func (m *MockDB) GetListsForOwner(ctx context.Context, ownerID string, limit int64, startKey *data.OwnerListKey) (*[]data.List, *data.OwnerListKey, error) {
+	args := m.Called(ownerID, limit, startKey)
+	return args.Get(0).(*[]data.List), args.Get(1).(*data.OwnerListKey), args.Error(2)
+}
+
+// ReleaseIdempotencyKey mocks the DB ReleaseIdempotencyKey method
+func (m *MockDB) ReleaseIdempotencyKey(ctx context.Context, claim data.IdempotencyRecord) error {
+	args := m.Called(claim)
+	return args.Error(0)
+}
+
+// ReorderItems mocks the DB ReorderItems method
+func (m *MockDB) ReorderItems(ctx context.Context, listID string, itemIDs []string) (*[]data.Item, error) {
+	args := m.Called(listID, itemIDs)
+	return args.Get(0).(*[]data.Item), args.Error(1)
+}
+
+// RestoreItem mocks the DB RestoreItem method
+func (m *MockDB) RestoreItem(ctx context.Context, listID string, itemID string) (*data.Item, error) {
+	args := m.Called(listID, itemID)
+	return args.Get(0).(*data.Item), args.Error(1)
+}
+
+// StartIdempotentRequest mocks the DB StartIdempotentRequest method
+func (m *MockDB) StartIdempotentRequest(ctx context.Context, key string, fingerprint string) (*data.IdempotencyRecord, error) {
+	args := m.Called(key, fingerprint)
+	return args.Get(0).(*data.IdempotencyRecord), args.Error(1)
+}
+
 // UpdateItem mocks the DB UpdateItem method
-func (m *MockDB) UpdateItem(listID string, itemID string, newName string, isCompleted *bool) (*data.Item, error) {
-	args := m.Called(listID, itemID, newName, isCompleted)
+func (m *MockDB) UpdateItem(ctx context.Context, listID string, itemID string, update data.ItemUpdate, expectedVersion *int64) (*data.Item, error) {
+	args := m.Called(listID, itemID, update, expectedVersion)
 	return args.Get(0).(*data.Item), args.Error(1)
 }
+
+// UpdateList mocks the DB UpdateList method
+func (m *MockDB) UpdateList(ctx context.Context, listID string, newName string, expectedVersion *int64) (*data.List, error) {
+	args := m.Called(listID, newName, expectedVersion)
+	return args.Get(0).(*data.List), args.Error(1)
+}
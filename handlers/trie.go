@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mount-joy/thelist-lambda/handlers/iface"
+)
+
+// Names of the parameters which can appear in a path template, and the PathParams field each one fills
+const (
+	listIDParam = "listId"
+	itemIDParam = "itemId"
+)
+
+// node is one segment of the path templates, routes are registered on the node their last segment ends at
+type node struct {
+	literals map[string]*node
+	// param matches any value for the segment, name is the parameter the value is stored as
+	param  *node
+	name   string
+	routes map[string]*route
+}
+
+// route is a handler along with the template it was registered for
+type route struct {
+	template string
+	handler  iface.RouteHandler
+}
+
+// match is a node the path ended at, along with the values of the parameters on the way there
+type match struct {
+	node   *node
+	params iface.PathParams
+}
+
+func newNode() *node {
+	return &node{
+		literals: map[string]*node{},
+		routes:   map[string]*route{},
+	}
+}
+
+// add registers handler for a template such as `GET /lists/{listId}/items/{itemId}`
+// It panics when the template is malformed or already registered, as routes are only added at startup
+func (n *node) add(template string, handler iface.RouteHandler) {
+	method, path, err := parseTemplate(template)
+	if err != nil {
+		panic(err)
+	}
+
+	current := n
+	for _, segment := range splitPath(path) {
+		if !isParam(segment) {
+			next, ok := current.literals[segment]
+			if !ok {
+				next = newNode()
+				current.literals[segment] = next
+			}
+			current = next
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}")
+		if name != listIDParam && name != itemIDParam {
+			panic(fmt.Errorf("Unknown parameter %q in route %q", name, template))
+		}
+		if current.param == nil {
+			current.param = newNode()
+			current.param.name = name
+		}
+		if current.param.name != name {
+			panic(fmt.Errorf("Parameter %q in route %q conflicts with %q", name, template, current.param.name))
+		}
+		current = current.param
+	}
+
+	if _, ok := current.routes[method]; ok {
+		panic(fmt.Errorf("Route %q is already registered", template))
+	}
+	current.routes[method] = &route{template: template, handler: handler}
+}
+
+// lookup returns the nodes with routes which path ends at, literal segments come before parameters
+func (n *node) lookup(path string) []match {
+	if !strings.HasPrefix(path, "/") {
+		return nil
+	}
+	path = strings.TrimSuffix(path, "/")
+
+	var matches []match
+	n.find(splitPath(path), iface.PathParams{}, &matches)
+	return matches
+}
+
+func (n *node) find(segments []string, params iface.PathParams, matches *[]match) {
+	if len(segments) == 0 {
+		if len(n.routes) > 0 {
+			*matches = append(*matches, match{node: n, params: params})
+		}
+		return
+	}
+
+	segment, rest := segments[0], segments[1:]
+	if next, ok := n.literals[segment]; ok {
+		next.find(rest, params, matches)
+	}
+	if n.param != nil && isParamValue(segment) {
+		n.param.find(rest, setParam(params, n.param.name, segment), matches)
+	}
+}
+
+// templates returns the templates of every route registered under the node, sorted
+func (n *node) templates() []string {
+	var templates []string
+	n.collect(&templates)
+	sort.Strings(templates)
+	return templates
+}
+
+func (n *node) collect(templates *[]string) {
+	for _, r := range n.routes {
+		*templates = append(*templates, r.template)
+	}
+	for _, next := range n.literals {
+		next.collect(templates)
+	}
+	if n.param != nil {
+		n.param.collect(templates)
+	}
+}
+
+// setParam returns params with the parameter called name set to value
+func setParam(params iface.PathParams, name string, value string) iface.PathParams {
+	switch name {
+	case listIDParam:
+		params.ListID = value
+	case itemIDParam:
+		params.ItemID = value
+	}
+	return params
+}
+
+func parseTemplate(template string) (string, string, error) {
+	parts := strings.Fields(template)
+	if len(parts) != 2 || !strings.HasPrefix(parts[1], "/") {
+		return "", "", fmt.Errorf("Route %q should be in the form `METHOD /path`", template)
+	}
+
+	method, path := parts[0], parts[1]
+	if path != "/" && strings.HasSuffix(path, "/") {
+		return "", "", fmt.Errorf("Route %q has a trailing slash", template)
+	}
+	for _, segment := range splitPath(path) {
+		if segment == "" || (strings.ContainsAny(segment, "{}") && !isParam(segment)) {
+			return "", "", fmt.Errorf("Route %q has an invalid segment %q", template, segment)
+		}
+	}
+	return method, path, nil
+}
+
+// splitPath returns the segments of a path which starts with a slash
+func splitPath(path string) []string {
+	if path == "/" || path == "" {
+		return nil
+	}
+	return strings.Split(path[1:], "/")
+}
+
+func isParam(segment string) bool {
+	return len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") &&
+		!strings.ContainsAny(segment[1:len(segment)-1], "{}")
+}
+
+// isParamValue returns true if segment is made up of word characters and hyphens, like the IDs the api hands out
+func isParamValue(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for _, c := range segment {
+		if !(c == '-' || c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
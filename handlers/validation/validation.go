@@ -0,0 +1,157 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Longest names, in characters, which can be given to lists and items
+const (
+	MaxListNameLength = 100
+	MaxItemNameLength = 200
+)
+
+// ErrorEmptyBody is returned by Decode when there is no request body
+var ErrorEmptyBody = errors.New("The request body is empty")
+
+// ErrorNotAnObject is returned by Decode when the request body is JSON but not an object
+var ErrorNotAnObject = errors.New("The request body must be a JSON object")
+
+// unknownFieldPrefix starts the error encoding/json returns for a field which isn't on the struct
+const unknownFieldPrefix = "json: unknown field "
+
+// Errors - every field of a request body which is invalid, so they can all be fixed at once
+type Errors []problem.Field
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, field := range e {
+		messages = append(messages, fmt.Sprintf("%s %s", field.Name, field.Message))
+	}
+	return "Invalid fields: " + strings.Join(messages, "; ")
+}
+
+// Decode unmarshals the JSON object in body into v
+// Unknown fields and fields of the wrong type are Errors, anything which isn't a single JSON object is a plain error
+func Decode(body string, v interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+	case err == io.EOF:
+		return ErrorEmptyBody
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return ErrorNotAnObject
+		}
+		return Errors{{Name: typeErr.Field, Message: "must be " + jsonType(typeErr.Type)}}
+	case strings.HasPrefix(err.Error(), unknownFieldPrefix):
+		name, unquoteErr := strconv.Unquote(strings.TrimPrefix(err.Error(), unknownFieldPrefix))
+		if unquoteErr != nil {
+			return err
+		}
+		return Errors{{Name: name, Message: "is not a known field"}}
+	default:
+		return err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("The request body has data after the JSON object")
+	}
+	return nil
+}
+
+// jsonType describes the JSON value which would have been accepted for a Go type
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// Validator collects the invalid fields found while checking a request body
+type Validator struct {
+	errors Errors
+}
+
+// Add records that field is invalid
+func (v *Validator) Add(field string, message string) {
+	v.errors = append(v.errors, problem.Field{Name: field, Message: message})
+}
+
+// Required records that field is invalid if it wasn't given
+func (v *Validator) Required(field string, value string) {
+	if value == "" {
+		v.Add(field, "is required")
+	}
+}
+
+// Name returns value trimmed and normalised to NFC, recording that field is invalid if what's left is
+// blank, longer than maxLength characters or has control characters in it
+// An empty value is left for Required to check, as it means the name wasn't given
+func (v *Validator) Name(field string, value string, maxLength int) string {
+	if value == "" {
+		return ""
+	}
+
+	name := strings.TrimSpace(norm.NFC.String(value))
+	switch {
+	case name == "":
+		v.Add(field, "must not be blank")
+	case utf8.RuneCountInString(name) > maxLength:
+		v.Add(field, fmt.Sprintf("must be at most %d characters", maxLength))
+	case strings.IndexFunc(name, unicode.IsControl) != -1:
+		v.Add(field, "must not contain control characters")
+	}
+	return name
+}
+
+// Fields records the invalid fields found by the data package
+func (v *Validator) Fields(fieldErrors []data.FieldError) {
+	for _, fieldError := range fieldErrors {
+		v.Add(fieldError.Field, fieldError.Err.Error())
+	}
+}
+
+// Err returns Errors for every invalid field, or nil if they were all valid
+func (v *Validator) Err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors
+}
+
+// Respond returns the problem for an error from Decode or Validator.Err
+// Invalid fields are 'Unprocessable Entity', a body which couldn't be decoded is 'Bad Request'
+func Respond(err error) (*problem.Problem, int) {
+	var fields Errors
+	if errors.As(err, &fields) {
+		return problem.Respond(http.StatusUnprocessableEntity, "The request body has invalid fields", fields...)
+	}
+	return problem.Respond(http.StatusBadRequest, err.Error())
+}
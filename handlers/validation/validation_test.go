@@ -0,0 +1,188 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/data"
+	"github.com/mount-joy/thelist-lambda/handlers/problem"
+	"github.com/mount-joy/thelist-lambda/handlers/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	type input struct {
+		Name        string   `json:"Name"`
+		IsCompleted *bool    `json:"IsCompleted"`
+		Tags        []string `json:"Tags"`
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		expectedRes input
+		expectedErr error
+		wantErr     bool
+	}{
+		{
+			name:        "Decodes a JSON object",
+			body:        `{"Name": "bob", "IsCompleted": true}`,
+			expectedRes: input{Name: "bob", IsCompleted: testhelpers.BoolToPointer(true)},
+		},
+		{
+			name:        "Allows whitespace after the object",
+			body:        "{\"Name\": \"bob\"}\n",
+			expectedRes: input{Name: "bob"},
+		},
+		{
+			name:        "Returns Errors for a field which isn't known",
+			body:        `{"Name": "bob", "age": 55}`,
+			expectedRes: input{Name: "bob"},
+			expectedErr: Errors{{Name: "age", Message: "is not a known field"}},
+		},
+		{
+			name:        "Returns Errors for a string which should be a boolean",
+			body:        `{"IsCompleted": "yes"}`,
+			expectedRes: input{IsCompleted: testhelpers.BoolToPointer(false)},
+			expectedErr: Errors{{Name: "IsCompleted", Message: "must be a boolean"}},
+		},
+		{
+			name:        "Returns Errors for a number which should be a string",
+			body:        `{"Name": 74}`,
+			expectedErr: Errors{{Name: "Name", Message: "must be a string"}},
+		},
+		{
+			name:        "Returns Errors for a string which should be an array",
+			body:        `{"Tags": "a"}`,
+			expectedErr: Errors{{Name: "Tags", Message: "must be an array"}},
+		},
+		{
+			name:        "Returns ErrorEmptyBody when there is no body",
+			body:        "",
+			expectedErr: ErrorEmptyBody,
+		},
+		{
+			name:        "Returns ErrorNotAnObject when the body is an array",
+			body:        `["bob"]`,
+			expectedErr: ErrorNotAnObject,
+		},
+		{
+			name:    "Returns an error for bad json",
+			body:    `{"Name": "bob",}`,
+			wantErr: true,
+		},
+		{
+			name:        "Returns an error for data after the object",
+			body:        `{"Name": "bob"} {"Name": "alice"}`,
+			expectedRes: input{Name: "bob"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRes input
+			gotErr := Decode(tt.body, &gotRes)
+
+			assert.Equal(t, tt.expectedRes, gotRes)
+			switch {
+			case tt.expectedErr != nil:
+				assert.Equal(t, tt.expectedErr, gotErr)
+			case tt.wantErr:
+				assert.Error(t, gotErr)
+				var fields Errors
+				assert.False(t, errors.As(gotErr, &fields), "expected a plain error, got %v", gotErr)
+			default:
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expectedRes string
+		expectedErr error
+	}{
+		{
+			name:        "Leaves a valid name as it is",
+			value:       "Shopping",
+			expectedRes: "Shopping",
+		},
+		{
+			name:        "Trims whitespace from both ends",
+			value:       " \t Shopping \n",
+			expectedRes: "Shopping",
+		},
+		{
+			name:        "Normalises to NFC",
+			value:       "Cafe\u0301",
+			expectedRes: "Caf\u00e9",
+		},
+		{
+			name:        "Counts characters rather than bytes",
+			value:       strings.Repeat("é", 10),
+			expectedRes: strings.Repeat("é", 10),
+		},
+		{
+			name:  "Leaves an empty name for Required",
+			value: "",
+		},
+		{
+			name:        "Rejects a name which is all whitespace",
+			value:       "  \t",
+			expectedErr: Errors{{Name: "Name", Message: "must not be blank"}},
+		},
+		{
+			name:        "Rejects a name which is too long",
+			value:       strings.Repeat("a", 11),
+			expectedRes: strings.Repeat("a", 11),
+			expectedErr: Errors{{Name: "Name", Message: "must be at most 10 characters"}},
+		},
+		{
+			name:        "Rejects a name with control characters in it",
+			value:       "Shop\u0000ping",
+			expectedRes: "Shop\u0000ping",
+			expectedErr: Errors{{Name: "Name", Message: "must not contain control characters"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Validator{}
+
+			gotRes := v.Name("Name", tt.value, 10)
+
+			assert.Equal(t, tt.expectedRes, gotRes)
+			assert.Equal(t, tt.expectedErr, v.Err())
+		})
+	}
+}
+
+func TestValidatorCollectsEveryField(t *testing.T) {
+	v := Validator{}
+	v.Required("Name", "")
+	v.Fields([]data.FieldError{{Field: "Quantity", Err: data.ErrorInvalidQuantity}})
+
+	err := v.Err()
+
+	assert.Equal(t, Errors{
+		{Name: "Name", Message: "is required"},
+		{Name: "Quantity", Message: "must be greater than 0"},
+	}, err)
+	assert.Equal(t, "Invalid fields: Name is required; Quantity must be greater than 0", err.Error())
+	assert.NoError(t, (&Validator{}).Err())
+}
+
+func TestRespond(t *testing.T) {
+	gotRes, gotStatus := Respond(Errors{{Name: "Name", Message: "is required"}})
+	assert.Equal(t, problem.New(422, problem.CodeValidationFailed, "The request body has invalid fields", problem.Field{Name: "Name", Message: "is required"}), gotRes)
+	assert.Equal(t, 422, gotStatus)
+
+	gotRes, gotStatus = Respond(ErrorEmptyBody)
+	assert.Equal(t, problem.ForStatus(400, "The request body is empty"), gotRes)
+	assert.Equal(t, 400, gotStatus)
+}
@@ -0,0 +1,161 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Levels of the lines written by a Logger
+const (
+	LevelInfo  = "INFO"
+	LevelWarn  = "WARN"
+	LevelError = "ERROR"
+)
+
+// LevelForStatus is the level a request which got statusCode is logged at, client errors are only warnings
+func LevelForStatus(statusCode int) string {
+	switch {
+	case statusCode >= http.StatusInternalServerError:
+		return LevelError
+	case statusCode >= http.StatusBadRequest:
+		return LevelWarn
+	default:
+		return LevelInfo
+	}
+}
+
+// Logger writes each line as a JSON object with the time, level and message followed by its attributes
+// Attributes are given as alternating keys and values, like log/slog
+type Logger struct {
+	out   io.Writer
+	mu    *sync.Mutex
+	now   func() time.Time
+	attrs []attr
+}
+
+type attr struct {
+	key   string
+	value interface{}
+}
+
+// New returns a Logger which writes to out
+func New(out io.Writer) *Logger {
+	return &Logger{
+		out: out,
+		mu:  &sync.Mutex{},
+		now: time.Now,
+	}
+}
+
+var defaultLogger = New(os.Stdout)
+
+// Default returns the Logger used when there is no request to log for
+func Default() *Logger {
+	return defaultLogger
+}
+
+// With returns a Logger which adds args to every line, after the attributes l already adds
+func (l *Logger) With(args ...interface{}) *Logger {
+	with := *l
+	with.attrs = append(append([]attr{}, l.attrs...), toAttrs(args)...)
+	return &with
+}
+
+// Info logs msg at LevelInfo
+func (l *Logger) Info(msg string, args ...interface{}) {
+	l.log(LevelInfo, msg, args)
+}
+
+// Warn logs msg at LevelWarn
+func (l *Logger) Warn(msg string, args ...interface{}) {
+	l.log(LevelWarn, msg, args)
+}
+
+// Error logs msg at LevelError
+func (l *Logger) Error(msg string, args ...interface{}) {
+	l.log(LevelError, msg, args)
+}
+
+// Log logs msg at level
+func (l *Logger) Log(level string, msg string, args ...interface{}) {
+	l.log(level, msg, args)
+}
+
+func (l *Logger) log(level string, msg string, args []interface{}) {
+	attrs := append([]attr{
+		{key: "time", value: l.now().UTC().Format(time.RFC3339Nano)},
+		{key: "level", value: level},
+		{key: "msg", value: msg},
+	}, l.attrs...)
+	attrs = append(attrs, toAttrs(args)...)
+
+	line := &bytes.Buffer{}
+	line.WriteByte('{')
+	for i, a := range attrs {
+		if i > 0 {
+			line.WriteByte(',')
+		}
+		key, _ := json.Marshal(a.key)
+		line.Write(key)
+		line.WriteByte(':')
+		line.Write(marshalValue(a.value))
+	}
+	line.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.out.Write(line.Bytes())
+}
+
+// toAttrs pairs up keys and values, a value without a key is logged under "!BADKEY" as slog does
+func toAttrs(args []interface{}) []attr {
+	attrs := make([]attr, 0, len(args)/2)
+	for len(args) > 0 {
+		key, ok := args[0].(string)
+		if !ok || len(args) == 1 {
+			attrs = append(attrs, attr{key: "!BADKEY", value: args[0]})
+			args = args[1:]
+			continue
+		}
+		attrs = append(attrs, attr{key: key, value: args[1]})
+		args = args[2:]
+	}
+	return attrs
+}
+
+func marshalValue(value interface{}) []byte {
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case fmt.Stringer:
+		value = v.String()
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprintf("%+v", value))
+	}
+	return encoded
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx which carries l
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the Logger carried by ctx, or Default if it doesn't carry one
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+	return Default()
+}
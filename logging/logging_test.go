@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLogger() (*Logger, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	l := New(out)
+	l.now = func() time.Time { return time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return l, out
+}
+
+func TestLog(t *testing.T) {
+	tests := []struct {
+		name     string
+		log      func(l *Logger)
+		expected string
+	}{
+		{
+			name:     "Writes the time, level and message",
+			log:      func(l *Logger) { l.Info("Started") },
+			expected: `{"time":"2021-01-02T03:04:05Z","level":"INFO","msg":"Started"}`,
+		},
+		{
+			name:     "Writes attributes in the order they were given",
+			log:      func(l *Logger) { l.Warn("Slow", "route", "GET /lists", "latencyMs", 1500, "cached", false) },
+			expected: `{"time":"2021-01-02T03:04:05Z","level":"WARN","msg":"Slow","route":"GET /lists","latencyMs":1500,"cached":false}`,
+		},
+		{
+			name: "Writes the attributes from With before those of the line",
+			log: func(l *Logger) {
+				l.With("requestId", "request-1").With("listId", "list-1").Error("Failed", "status", 500)
+			},
+			expected: `{"time":"2021-01-02T03:04:05Z","level":"ERROR","msg":"Failed","requestId":"request-1","listId":"list-1","status":500}`,
+		},
+		{
+			name:     "Writes errors as their message",
+			log:      func(l *Logger) { l.Error("Failed", "error", errors.New("Connection refused")) },
+			expected: `{"time":"2021-01-02T03:04:05Z","level":"ERROR","msg":"Failed","error":"Connection refused"}`,
+		},
+		{
+			name:     "Writes a value without a key under !BADKEY",
+			log:      func(l *Logger) { l.Info("Odd", "requestId") },
+			expected: `{"time":"2021-01-02T03:04:05Z","level":"INFO","msg":"Odd","!BADKEY":"requestId"}`,
+		},
+		{
+			name:     "Escapes the message",
+			log:      func(l *Logger) { l.Info("Said \"hi\"\n") },
+			expected: `{"time":"2021-01-02T03:04:05Z","level":"INFO","msg":"Said \"hi\"\n"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, out := newTestLogger()
+
+			tt.log(l)
+
+			assert.Equal(t, tt.expected+"\n", out.String())
+		})
+	}
+}
+
+func TestWithDoesNotChangeTheParent(t *testing.T) {
+	l, out := newTestLogger()
+
+	_ = l.With("requestId", "request-1")
+	l.Info("Started")
+
+	assert.Equal(t, `{"time":"2021-01-02T03:04:05Z","level":"INFO","msg":"Started"}`+"\n", out.String())
+}
+
+func TestContext(t *testing.T) {
+	l, _ := newTestLogger()
+
+	assert.Same(t, l, FromContext(NewContext(context.Background(), l)))
+	assert.Same(t, Default(), FromContext(context.Background()))
+}
+
+func TestLevelForStatus(t *testing.T) {
+	assert.Equal(t, LevelInfo, LevelForStatus(200))
+	assert.Equal(t, LevelWarn, LevelForStatus(404))
+	assert.Equal(t, LevelWarn, LevelForStatus(422))
+	assert.Equal(t, LevelError, LevelForStatus(500))
+}
@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/mount-joy/thelist-lambda/config"
+)
+
+// UnmatchedRoute is the route dimension of requests which didn't match a route
+const UnmatchedRoute = "unmatched"
+
+// Dimensions every metric is emitted with
+const (
+	dimensionRoute       = "Route"
+	dimensionStatusClass = "StatusClass"
+)
+
+// Units of the metrics, as CloudWatch names them
+const (
+	unitCount        = "Count"
+	unitMilliseconds = "Milliseconds"
+)
+
+// DynamoDBUsage is what the calls to DynamoDB made for a request added up to
+type DynamoDBUsage struct {
+	Calls            int
+	Latency          time.Duration
+	ConsumedCapacity float64
+}
+
+// Recorder collects the DynamoDB calls made while handling a request
+// A nil Recorder ignores them, so calls made without one in their context aren't an error
+type Recorder struct {
+	mu    sync.Mutex
+	usage DynamoDBUsage
+}
+
+// RecordDynamoDBCall adds a call which took latency and consumed consumedCapacity units
+func (r *Recorder) RecordDynamoDBCall(latency time.Duration, consumedCapacity float64) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.usage.Calls++
+	r.usage.Latency += latency
+	r.usage.ConsumedCapacity += consumedCapacity
+}
+
+// DynamoDBUsage returns the calls recorded so far
+func (r *Recorder) DynamoDBUsage() DynamoDBUsage {
+	if r == nil {
+		return DynamoDBUsage{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.usage
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx which carries r
+func NewContext(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, contextKey{}, r)
+}
+
+// FromContext returns the Recorder carried by ctx, or nil if it doesn't carry one
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(contextKey{}).(*Recorder)
+	return r
+}
+
+// Emitter writes the metrics for each request as a line in CloudWatch Embedded Metric Format,
+// which CloudWatch turns into metrics when the Lambda logs it
+type Emitter struct {
+	out  io.Writer
+	conf config.Metrics
+	now  func() time.Time
+}
+
+// NewEmitter returns an Emitter which writes to out, using the namespace and names in conf
+func NewEmitter(out io.Writer, conf config.Metrics) *Emitter {
+	return &Emitter{
+		out:  out,
+		conf: conf,
+		now:  time.Now,
+	}
+}
+
+// Emit writes the metrics for a request to route which got a statusCode response after latency
+func (e *Emitter) Emit(route string, statusCode int, latency time.Duration, usage DynamoDBUsage) error {
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	names := e.conf.Names
+
+	document := map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"Timestamp": e.now().UnixNano() / int64(time.Millisecond),
+			"CloudWatchMetrics": []map[string]interface{}{
+				{
+					"Namespace":  e.conf.Namespace,
+					"Dimensions": [][]string{{dimensionRoute}, {dimensionRoute, dimensionStatusClass}},
+					"Metrics": []map[string]string{
+						{"Name": names.Requests, "Unit": unitCount},
+						{"Name": names.Latency, "Unit": unitMilliseconds},
+						{"Name": names.DynamoDBCalls, "Unit": unitCount},
+						{"Name": names.DynamoDBLatency, "Unit": unitMilliseconds},
+						{"Name": names.DynamoDBConsumedCapacity, "Unit": unitCount},
+					},
+				},
+			},
+		},
+		dimensionRoute:                 route,
+		dimensionStatusClass:           StatusClass(statusCode),
+		names.Requests:                 1,
+		names.Latency:                  milliseconds(latency),
+		names.DynamoDBCalls:            usage.Calls,
+		names.DynamoDBLatency:          milliseconds(usage.Latency),
+		names.DynamoDBConsumedCapacity: usage.ConsumedCapacity,
+	}
+
+	line, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	_, err = e.out.Write(append(line, '\n'))
+	return err
+}
+
+// StatusClass returns the class of a status code, such as 2xx
+func StatusClass(statusCode int) string {
+	return fmt.Sprintf("%dxx", statusCode/100)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
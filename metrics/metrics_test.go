@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mount-joy/thelist-lambda/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var testConfig = config.Metrics{
+	Namespace: "TheList",
+	Names: config.MetricNames{
+		Requests:                 "Requests",
+		Latency:                  "Latency",
+		DynamoDBCalls:            "DynamoDBCalls",
+		DynamoDBLatency:          "DynamoDBLatency",
+		DynamoDBConsumedCapacity: "DynamoDBConsumedCapacity",
+	},
+}
+
+func TestEmit(t *testing.T) {
+	tests := []struct {
+		name       string
+		conf       config.Metrics
+		route      string
+		statusCode int
+		usage      DynamoDBUsage
+		expected   string
+	}{
+		{
+			name:       "Emits every metric with the route and status class",
+			conf:       testConfig,
+			route:      "GET /lists/{listId}",
+			statusCode: 200,
+			usage:      DynamoDBUsage{Calls: 2, Latency: 30 * time.Millisecond, ConsumedCapacity: 1.5},
+			expected: `{"DynamoDBCalls":2,"DynamoDBConsumedCapacity":1.5,"DynamoDBLatency":30,"Latency":12.5,"Requests":1,"Route":"GET /lists/{listId}","StatusClass":"2xx",` +
+				`"_aws":{"CloudWatchMetrics":[{"Dimensions":[["Route"],["Route","StatusClass"]],"Metrics":[` +
+				`{"Name":"Requests","Unit":"Count"},{"Name":"Latency","Unit":"Milliseconds"},{"Name":"DynamoDBCalls","Unit":"Count"},` +
+				`{"Name":"DynamoDBLatency","Unit":"Milliseconds"},{"Name":"DynamoDBConsumedCapacity","Unit":"Count"}],"Namespace":"TheList"}],"Timestamp":1609556645000}}`,
+		},
+		{
+			name:       "Uses the namespace and names from the config",
+			conf:       config.Metrics{Namespace: "Other", Names: config.MetricNames{Requests: "Count", Latency: "Time", DynamoDBCalls: "Calls", DynamoDBLatency: "CallTime", DynamoDBConsumedCapacity: "Capacity"}},
+			route:      "POST /lists",
+			statusCode: 503,
+			expected: `{"CallTime":0,"Calls":0,"Capacity":0,"Count":1,"Route":"POST /lists","StatusClass":"5xx","Time":12.5,` +
+				`"_aws":{"CloudWatchMetrics":[{"Dimensions":[["Route"],["Route","StatusClass"]],"Metrics":[` +
+				`{"Name":"Count","Unit":"Count"},{"Name":"Time","Unit":"Milliseconds"},{"Name":"Calls","Unit":"Count"},` +
+				`{"Name":"CallTime","Unit":"Milliseconds"},{"Name":"Capacity","Unit":"Count"}],"Namespace":"Other"}],"Timestamp":1609556645000}}`,
+		},
+		{
+			name:       "Emits requests which didn't match a route as unmatched",
+			conf:       testConfig,
+			route:      "",
+			statusCode: 404,
+			expected: `{"DynamoDBCalls":0,"DynamoDBConsumedCapacity":0,"DynamoDBLatency":0,"Latency":12.5,"Requests":1,"Route":"unmatched","StatusClass":"4xx",` +
+				`"_aws":{"CloudWatchMetrics":[{"Dimensions":[["Route"],["Route","StatusClass"]],"Metrics":[` +
+				`{"Name":"Requests","Unit":"Count"},{"Name":"Latency","Unit":"Milliseconds"},{"Name":"DynamoDBCalls","Unit":"Count"},` +
+				`{"Name":"DynamoDBLatency","Unit":"Milliseconds"},{"Name":"DynamoDBConsumedCapacity","Unit":"Count"}],"Namespace":"TheList"}],"Timestamp":1609556645000}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			e := NewEmitter(out, tt.conf)
+			e.now = func() time.Time { return time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+			err := e.Emit(tt.route, tt.statusCode, 12500*time.Microsecond, tt.usage)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected+"\n", out.String())
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r := &Recorder{}
+	ctx := NewContext(context.Background(), r)
+
+	FromContext(ctx).RecordDynamoDBCall(10*time.Millisecond, 0.5)
+	FromContext(ctx).RecordDynamoDBCall(5*time.Millisecond, 1)
+
+	assert.Equal(t, DynamoDBUsage{Calls: 2, Latency: 15 * time.Millisecond, ConsumedCapacity: 1.5}, r.DynamoDBUsage())
+}
+
+func TestRecorderWithoutContext(t *testing.T) {
+	r := FromContext(context.Background())
+
+	assert.Nil(t, r)
+	r.RecordDynamoDBCall(10*time.Millisecond, 0.5)
+	assert.Equal(t, DynamoDBUsage{}, r.DynamoDBUsage())
+}
+
+func TestStatusClass(t *testing.T) {
+	assert.Equal(t, "2xx", StatusClass(204))
+	assert.Equal(t, "4xx", StatusClass(422))
+	assert.Equal(t, "5xx", StatusClass(500))
+}
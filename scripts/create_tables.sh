@@ -4,14 +4,44 @@ aws dynamodb create-table \
   --endpoint-url http://localhost:8000 \
   --region eu-west-2 \
   --table-name items \
-  --attribute-definitions "AttributeName=ListId,AttributeType=S" "AttributeName=Id,AttributeType=S" \
+  --attribute-definitions "AttributeName=ListId,AttributeType=S" "AttributeName=Id,AttributeType=S" "AttributeName=Updated,AttributeType=S" "AttributeName=Position,AttributeType=N" \
   --key-schema "AttributeName=ListId,KeyType=HASH" "AttributeName=Id,KeyType=SORT" \
+  --global-secondary-indexes "IndexName=ListUpdatedIndex,KeySchema=[{AttributeName=ListId,KeyType=HASH},{AttributeName=Updated,KeyType=RANGE}],Projection={ProjectionType=ALL}" "IndexName=ListPositionIndex,KeySchema=[{AttributeName=ListId,KeyType=HASH},{AttributeName=Position,KeyType=RANGE}],Projection={ProjectionType=ALL}" \
   --billing-mode PAY_PER_REQUEST
 
+aws dynamodb update-time-to-live \
+  --endpoint-url http://localhost:8000 \
+  --region eu-west-2 \
+  --table-name items \
+  --time-to-live-specification "Enabled=true,AttributeName=ExpiresAt"
+
 aws dynamodb create-table \
   --endpoint-url http://localhost:8000 \
   --region eu-west-2 \
   --table-name lists \
+  --attribute-definitions "AttributeName=Id,AttributeType=S" "AttributeName=OwnerId,AttributeType=S" "AttributeName=Updated,AttributeType=S" \
+  --key-schema "AttributeName=Id,KeyType=HASH" \
+  --global-secondary-indexes "IndexName=OwnerIndex,KeySchema=[{AttributeName=OwnerId,KeyType=HASH},{AttributeName=Updated,KeyType=RANGE}],Projection={ProjectionType=ALL}" \
+  --billing-mode PAY_PER_REQUEST
+
+aws dynamodb create-table \
+  --endpoint-url http://localhost:8000 \
+  --region eu-west-2 \
+  --table-name activity \
+  --attribute-definitions "AttributeName=ListId,AttributeType=S" "AttributeName=Id,AttributeType=S" \
+  --key-schema "AttributeName=ListId,KeyType=HASH" "AttributeName=Id,KeyType=RANGE" \
+  --billing-mode PAY_PER_REQUEST
+
+aws dynamodb create-table \
+  --endpoint-url http://localhost:8000 \
+  --region eu-west-2 \
+  --table-name idempotency \
   --attribute-definitions "AttributeName=Id,AttributeType=S" \
   --key-schema "AttributeName=Id,KeyType=HASH" \
   --billing-mode PAY_PER_REQUEST
+
+aws dynamodb update-time-to-live \
+  --endpoint-url http://localhost:8000 \
+  --region eu-west-2 \
+  --table-name idempotency \
+  --time-to-live-specification "Enabled=true,AttributeName=ExpiresAt"
@@ -1,15 +1,19 @@
 #!/usr/bin/env bash
 # Creates 5 lists and randomly adds up to 10 items to each one
+# The lists are owned by $OWNER_ID, which should be the "sub" claim of the JWT used locally
+
+OWNER_ID=${OWNER_ID:-local-user}
 
 ITEMS=("Apples" "Pears" "Oranges" "Limes" "Lemons" "Butter" "Salt" "Cereal" "Bread" "Milk" "Cheese")
 
 for list in {1..5}; do
   ID=$(uuidgen | cut -c1-8)
+  UPDATED=$(date -u +%Y-%m-%dT%H:%M:%SZ)
   aws dynamodb put-item \
     --endpoint-url http://localhost:8000 \
     --region eu-west-2 \
     --table-name lists \
-    --item "{ \"Id\": { \"S\": \"$ID\" }, \"Name\": { \"S\": \"List $list\" } }" \
+    --item "{ \"Id\": { \"S\": \"$ID\" }, \"Name\": { \"S\": \"List $list\" }, \"OwnerId\": { \"S\": \"$OWNER_ID\" }, \"Updated\": { \"S\": \"$UPDATED\" } }" \
     --condition-expression "attribute_not_exists(Id)"
 
   N=$(( $RANDOM % 10 ))
@@ -23,6 +27,6 @@ for list in {1..5}; do
       --endpoint-url http://localhost:8000 \
       --region eu-west-2 \
       --table-name items \
-      --item "{ \"ListId\": { \"S\": \"$ID\" }, \"Id\": { \"S\": \"$ITEM_ID\" }, \"Item\": { \"S\": \"$ITEM_NAME\" } }"
+      --item "{ \"ListId\": { \"S\": \"$ID\" }, \"Id\": { \"S\": \"$ITEM_ID\" }, \"Item\": { \"S\": \"$ITEM_NAME\" }, \"Position\": { \"N\": \"$(( (item + 1) * 1024 ))\" } }"
   done
 done
@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/mount-joy/thelist-lambda/config"
+	"go.opentelemetry.io/contrib/propagators/aws/xray"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans started by this module
+const instrumentationName = "github.com/mount-joy/thelist-lambda"
+
+// propagator reads the trace a request is part of from its X-Amzn-Trace-Id header
+var propagator = xray.Propagator{}
+
+// Setup has spans exported to the exporter in conf, nothing is exported for TracingExporterNone
+func Setup(conf config.Tracing) error {
+	otel.SetTextMapPropagator(propagator)
+
+	switch conf.Exporter {
+	case config.TracingExporterNone, "":
+		return nil
+	case config.TracingExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return err
+		}
+		Use(exporter)
+		return nil
+	default:
+		return fmt.Errorf("Unknown tracing exporter: %s", conf.Exporter)
+	}
+}
+
+// Use has spans exported to exporter, with IDs X-Ray accepts
+// Each span is exported as it ends, as Lambda may freeze the process as soon as a request is handled
+func Use(exporter sdktrace.SpanExporter) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithIDGenerator(xray.NewIDGenerator()),
+	))
+}
+
+// Extract returns a copy of ctx which carries the trace from the X-Amzn-Trace-Id header, if there is one
+func Extract(ctx context.Context, headers map[string]string) context.Context {
+	return propagator.Extract(ctx, headerCarrier(headers))
+}
+
+// Start starts a span called name, as a child of the span in ctx
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// EndWithStatus records the status code a span's request got and ends it, it's an error if the code is 5xx
+func EndWithStatus(span trace.Span, statusCode int) {
+	span.SetAttributes(attribute.Int("http.status_code", statusCode))
+	if statusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(statusCode))
+	}
+	span.End()
+}
+
+// headerCarrier lets the propagator read API Gateway's headers, which are lower case
+type headerCarrier map[string]string
+
+func (h headerCarrier) Get(key string) string {
+	if value, ok := h[key]; ok {
+		return value
+	}
+	for k, value := range h {
+		if strings.EqualFold(k, key) {
+			return value
+		}
+	}
+	return ""
+}
+
+func (h headerCarrier) Set(key string, value string) {
+	h[key] = value
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+var _ propagation.TextMapCarrier = headerCarrier{}
@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mount-joy/thelist-lambda/config"
+	"github.com/mount-joy/thelist-lambda/tracing/tracingtest"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter config.TracingExporter
+		wantErr  bool
+	}{
+		{name: "Nothing is exported when spans aren't exported", exporter: config.TracingExporterNone},
+		{name: "Nothing is exported when the exporter isn't set", exporter: ""},
+		{name: "Spans can be exported to stdout", exporter: config.TracingExporterStdout},
+		{name: "An error for an unknown exporter", exporter: "carrier-pigeon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracingtest.Record(t)
+
+			gotErr := Setup(config.Tracing{Exporter: tt.exporter})
+
+			assert.Equal(t, tt.wantErr, gotErr != nil)
+		})
+	}
+}
+
+func TestUseExportsAsSpansEnd(t *testing.T) {
+	tracingtest.Record(t)
+	exporter := tracetest.NewInMemoryExporter()
+	Use(exporter)
+
+	_, span := Start(context.Background(), "span")
+	span.End()
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "span", spans[0].Name)
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name            string
+		headers         map[string]string
+		expectedTraceID string
+		expectedSampled bool
+	}{
+		{
+			name:            "Reads the header API Gateway lower cases",
+			headers:         map[string]string{"x-amzn-trace-id": "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"},
+			expectedTraceID: "5759e988bd862e3fe1be46a994272793",
+			expectedSampled: true,
+		},
+		{
+			name:            "Reads the header as X-Ray names it",
+			headers:         map[string]string{"X-Amzn-Trace-Id": "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=0"},
+			expectedTraceID: "5759e988bd862e3fe1be46a994272793",
+		},
+		{
+			name:    "Has no trace without the header",
+			headers: map[string]string{"content-type": "application/json"},
+		},
+		{
+			name:    "Has no trace when the header is malformed",
+			headers: map[string]string{"x-amzn-trace-id": "Root=nonsense"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := trace.SpanContextFromContext(Extract(context.Background(), tt.headers))
+
+			assert.Equal(t, tt.expectedTraceID != "", sc.IsValid())
+			if tt.expectedTraceID != "" {
+				assert.Equal(t, tt.expectedTraceID, sc.TraceID().String())
+				assert.True(t, sc.IsRemote())
+				assert.Equal(t, tt.expectedSampled, sc.IsSampled())
+			}
+		})
+	}
+}
+
+func TestEndWithStatus(t *testing.T) {
+	recorder := tracingtest.Record(t)
+
+	_, ok := Start(context.Background(), "ok")
+	EndWithStatus(ok, 404)
+	_, failed := Start(context.Background(), "failed")
+	EndWithStatus(failed, 503)
+
+	spans := recorder.Ended()
+	assert.Equal(t, []string{"ok", "failed"}, tracingtest.Names(spans))
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "Service Unavailable", spans[1].Status().Description)
+}